@@ -0,0 +1,190 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+const (
+	authInstallation = "installation"
+	authJWT          = "jwt"
+)
+
+var (
+	apiMethod  string
+	apiHeaders []string
+	apiInput   string
+	apiAuth    string
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api <endpoint>",
+	Short: "Make an authenticated GitHub API request",
+	Long: `Make a request to the GitHub REST API and print the response body.
+
+By default the request is authenticated with an installation token for
+--installation-id, --org, --repo or --user. With --auth jwt it is sent with
+the App JWT instead, which app-level endpoints such as /app/hook/deliveries
+require; no installation target is needed in that case.`,
+	Example: `  gh app-token api --app-id 12345 --private-key app.pem --org my-org repos/my-org/my-repo
+  gh app-token api --app-id 12345 --private-key app.pem --auth jwt /app/hook/deliveries`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAPIFlags(cmd); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		client, token, err := apiClient(cmd.Context(), appToken)
+		if err != nil {
+			return err
+		}
+		if token != nil {
+			defer revokeToken(cmd.Context(), appToken, token.Token)
+		}
+
+		req, err := newAPIRequest(cmd.Context(), appToken.BaseURL(), args[0])
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if _, err := cmd.OutOrStdout().Write(body); err != nil {
+			return err
+		}
+
+		// Decode the error the same way go-github does, so callers can
+		// recover it with errors.As.
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return github.CheckResponse(resp)
+	},
+}
+
+func validateAPIFlags(cmd *cobra.Command) error {
+	if err := validateAppFlags(); err != nil {
+		return err
+	}
+
+	switch apiAuth {
+	case authInstallation:
+		return validateTargetFlags()
+	case authJWT:
+		// Targets picked up from the environment are ignored; only an
+		// explicit flag is treated as a mistake.
+		for _, name := range []string{"installation-id", "org", "repo", "user", "enterprise"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--auth jwt cannot be used with --%s", name)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid --auth %q: must be %s or %s", apiAuth, authInstallation, authJWT)
+	}
+}
+
+// apiClient returns the HTTP client for --auth: the app's JWT client, or one
+// that sends an installation token minted for the resolved installation. The
+// token is returned for the caller to revoke once the request is done.
+func apiClient(ctx context.Context, appToken *app.AppToken) (*http.Client, *app.Token, error) {
+	if apiAuth == authJWT {
+		return appToken.Client(), nil, nil
+	}
+
+	id, err := resolveInstallationID(ctx, appToken)
+	if err == nil {
+		var token *app.Token
+		token, err = appToken.CreateToken(ctx, id)
+		if err == nil {
+			src := app.TokenSourceFunc(func(context.Context) (*app.Token, error) { return token, nil })
+			return appToken.TokenClient(src), token, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("failed to get token: %w", withInstallURL(ctx, appToken, err))
+}
+
+// newAPIRequest builds the request for endpoint, which is resolved against
+// baseURL. An absolute URL must point at baseURL's host, so that credentials
+// are never sent anywhere else.
+func newAPIRequest(ctx context.Context, baseURL *url.URL, endpoint string) (*http.Request, error) {
+	ref, err := url.Parse(strings.TrimPrefix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	u := baseURL.ResolveReference(ref)
+	if u.Scheme != baseURL.Scheme || u.Host != baseURL.Host {
+		return nil, fmt.Errorf("invalid endpoint %q: must be on %s://%s", endpoint, baseURL.Scheme, baseURL.Host)
+	}
+
+	method := apiMethod
+	var body io.Reader
+	if apiInput != "" {
+		if apiInput == "-" {
+			body = os.Stdin
+		} else {
+			f, err := os.Open(apiInput)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open input: %w", err)
+			}
+			body = f
+		}
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, h := range apiHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q: must be in format 'name: value'", h)
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return req, nil
+}
+
+func init() {
+	addTargetFlags(apiCmd)
+	apiCmd.Flags().StringVarP(&apiMethod, "method", "X", "", "HTTP method (default GET, or POST with --input)")
+	apiCmd.Flags().StringArrayVarP(&apiHeaders, "header", "H", nil, "Add a request header in 'name: value' format (repeatable)")
+	apiCmd.Flags().StringVar(&apiInput, "input", "", "File to use as the request body (- for stdin)")
+	apiCmd.Flags().StringVar(&apiAuth, "auth", authInstallation, "Authenticate as: installation (installation token) or jwt (App JWT)")
+	apiCmd.Flags().SortFlags = false
+
+	rootCmd.AddCommand(apiCmd)
+}
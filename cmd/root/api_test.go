@@ -0,0 +1,103 @@
+package root
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestValidateAPIFlags(t *testing.T) {
+	defer func() { appID, privateKeyPath, installationID, org, apiAuth = 0, "", 0, "", authInstallation }()
+
+	tests := []struct {
+		name    string
+		auth    string
+		args    []string
+		org     string
+		wantErr bool
+	}{
+		{name: "installation with target", auth: authInstallation, args: []string{"--org", "my-org"}, org: "my-org"},
+		{name: "installation without target", auth: authInstallation, wantErr: true},
+		{name: "jwt without target", auth: authJWT},
+		{name: "jwt ignores env target", auth: authJWT, org: "env-org"},
+		{name: "jwt with explicit target", auth: authJWT, args: []string{"--org", "my-org"}, org: "my-org", wantErr: true},
+		{name: "unknown auth", auth: "basic", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addTargetFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			appID, privateKeyPath, installationID = 123, "test.pem", 0
+			org, apiAuth = tt.org, tt.auth
+
+			err := validateAPIFlags(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAPIFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewAPIRequest(t *testing.T) {
+	defer func() { apiMethod, apiHeaders, apiInput = "", nil, "" }()
+
+	baseURL, _ := url.Parse("https://ghe.example.com/api/v3/")
+	input := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(input, []byte(`{"active":true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		method     string
+		headers    []string
+		input      string
+		wantMethod string
+		wantURL    string
+		wantErr    bool
+	}{
+		{name: "leading slash", endpoint: "/app/hook/deliveries", wantMethod: "GET", wantURL: "https://ghe.example.com/api/v3/app/hook/deliveries"},
+		{name: "relative with query", endpoint: "app/hook/deliveries?per_page=10", wantMethod: "GET", wantURL: "https://ghe.example.com/api/v3/app/hook/deliveries?per_page=10"},
+		{name: "absolute URL", endpoint: "https://ghe.example.com/api/v3/app", wantMethod: "GET", wantURL: "https://ghe.example.com/api/v3/app"},
+		{name: "absolute URL on another host", endpoint: "https://attacker.example/x", wantErr: true},
+		{name: "absolute URL with another scheme", endpoint: "http://ghe.example.com/api/v3/app", wantErr: true},
+		{name: "input defaults to POST", endpoint: "app/hook/config", input: input, wantMethod: "POST", wantURL: "https://ghe.example.com/api/v3/app/hook/config"},
+		{name: "explicit method", endpoint: "app/hook/config", method: "patch", input: input, wantMethod: "PATCH", wantURL: "https://ghe.example.com/api/v3/app/hook/config"},
+		{name: "header", endpoint: "app", headers: []string{"X-GitHub-Api-Version: 2022-11-28"}, wantMethod: "GET", wantURL: "https://ghe.example.com/api/v3/app"},
+		{name: "invalid header", endpoint: "app", headers: []string{"no-colon"}, wantErr: true},
+		{name: "missing input", endpoint: "app", input: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiMethod, apiHeaders, apiInput = tt.method, tt.headers, tt.input
+
+			req, err := newAPIRequest(context.Background(), baseURL, tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAPIRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if req.Method != tt.wantMethod {
+				t.Errorf("Method = %v, want %v", req.Method, tt.wantMethod)
+			}
+			if got := req.URL.String(); got != tt.wantURL {
+				t.Errorf("URL = %v, want %v", got, tt.wantURL)
+			}
+			if len(tt.headers) > 0 && req.Header.Get("X-GitHub-Api-Version") != "2022-11-28" {
+				t.Errorf("header not set: %v", req.Header)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package root
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var batchTargetsFile string
+
+// batchTargets is the --targets file of batch.
+type batchTargets struct {
+	Orgs        []string `yaml:"orgs"`
+	Repos       []string `yaml:"repos"`
+	Users       []string `yaml:"users"`
+	Enterprises []string `yaml:"enterprises"`
+}
+
+// batchTarget is one installation target of batch.
+type batchTarget struct {
+	kind string // org, repo, user or enterprise
+	name string
+}
+
+// String returns the key of the target in the output, e.g. org:acme.
+func (t batchTarget) String() string {
+	return t.kind + ":" + t.name
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Mint tokens for many installation targets at once",
+	Long: `Mint a token for each organization, repository, user and enterprise listed in
+the --targets file and print them as one JSON object keyed by target:
+
+  orgs: [acme, widgets]
+  repos: [acme/api]
+  users: [octocat]
+  enterprises: [acme-corp]
+
+Installations are looked up and tokens minted --concurrency targets at a
+time. A target that fails gets an "error" entry instead of a token, and the
+command exits non-zero. With --fail-fast the targets not started after the
+first failure are skipped.`,
+	Example: `  gh app-token batch --app-id 12345 --private-key app.pem --targets targets.yml`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+		if err := validatePermissionsFlags(); err != nil {
+			return err
+		}
+		if err := validatePoolFlags(); err != nil {
+			return err
+		}
+		targets, err := loadBatchTargets(batchTargetsFile)
+		if err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if err := checkServerPermissions(cmd.Context(), appToken); err != nil {
+			return err
+		}
+		opts, err := tokenOptions()
+		if err != nil {
+			return err
+		}
+
+		results, mintErr := mintBatch(cmd.Context(), appToken, targets, opts)
+
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+		return mintErr
+	},
+}
+
+// loadBatchTargets reads a --targets file. Duplicates are dropped.
+func loadBatchTargets(path string) ([]batchTarget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	var file batchTargets
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var targets []batchTarget
+	seen := map[batchTarget]bool{}
+	add := func(kind string, names []string) error {
+		for _, name := range names {
+			if name == "" {
+				return fmt.Errorf("%s: empty %s", path, kind)
+			}
+			if kind == "repo" {
+				if _, _, err := splitRepo(name); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+			t := batchTarget{kind: kind, name: name}
+			if !seen[t] {
+				seen[t] = true
+				targets = append(targets, t)
+			}
+		}
+		return nil
+	}
+	for _, list := range []struct {
+		kind  string
+		names []string
+	}{
+		{"org", file.Orgs}, {"repo", file.Repos}, {"user", file.Users}, {"enterprise", file.Enterprises},
+	} {
+		if err := add(list.kind, list.names); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s lists no targets", path)
+	}
+	return targets, nil
+}
+
+// findTargetInstallation discovers the installation for one batch target.
+func findTargetInstallation(ctx context.Context, appToken *app.AppToken, t batchTarget) (*github.Installation, error) {
+	switch t.kind {
+	case "org":
+		return appToken.FindOrgInstallation(ctx, t.name)
+	case "repo":
+		owner, name, err := splitRepo(t.name)
+		if err != nil {
+			return nil, err
+		}
+		return appToken.FindRepoInstallation(ctx, owner, name)
+	case "user":
+		return appToken.FindUserInstallation(ctx, t.name)
+	default:
+		return appToken.FindEnterpriseInstallation(ctx, t.name)
+	}
+}
+
+// batchResult is the entry of one target in the output of batch: either
+// its token or why it failed.
+type batchResult struct {
+	*tokenJSON
+	Error string `json:"error,omitempty"`
+}
+
+// mintBatch mints a token for every target with forEach and returns the
+// result of each, keyed by target. The error reports how many targets failed,
+// and with --fail-fast wraps the failure that stopped the others.
+func mintBatch(ctx context.Context, appToken *app.AppToken, targets []batchTarget, opts *github.InstallationTokenOptions) (map[string]batchResult, error) {
+	tokens := make([]*tokenJSON, len(targets))
+	errs := forEach(ctx, len(targets), func(ctx context.Context, i int) error {
+		start := time.Now()
+		installation, err := findTargetInstallation(ctx, appToken, targets[i])
+		if err == nil {
+			err = checkScopeSubset(ctx, appToken, installation)
+		}
+		var token *app.Token
+		if err == nil {
+			token, err = appToken.CreateTokenWithOptions(ctx, installation.GetID(), opts)
+		}
+		if !errors.Is(err, context.Canceled) {
+			recordMint(targets[i].String(), time.Since(start), err)
+		}
+		if err != nil {
+			return err
+		}
+		tokens[i] = &tokenJSON{
+			Token:               token.Token,
+			ExpiresAt:           token.ExpiresAt,
+			Permissions:         token.Permissions,
+			RepositorySelection: token.RepositorySelection,
+		}
+		return nil
+	})
+
+	results := map[string]batchResult{}
+	for i, t := range targets {
+		if errs[i] != nil {
+			results[t.String()] = batchResult{Error: errs[i].Error()}
+			continue
+		}
+		results[t.String()] = batchResult{tokenJSON: tokens[i]}
+	}
+	n := countFailed(errs)
+	if n == 0 {
+		return results, ctx.Err()
+	}
+	if failFast {
+		for i, err := range errs {
+			if err != nil && !errors.Is(err, errSkipped) {
+				return results, fmt.Errorf("%d of %d targets failed, the others were skipped: %s: %w", n, len(targets), targets[i], err)
+			}
+		}
+	}
+	return results, fmt.Errorf("%d of %d targets failed", n, len(targets))
+}
+
+func init() {
+	batchCmd.Flags().StringVar(&batchTargetsFile, "targets", "", "YAML file listing orgs, repos, users and enterprises")
+	batchCmd.Flags().StringVar(&permissions, "permissions", "", "Narrow every token to these permissions, e.g. contents:read")
+	batchCmd.Flags().BoolVar(&ensureScopeSubset, "ensure-scope-subset", false, "Fail a target before minting if --permissions exceed those of its installation")
+	addPoolFlags(batchCmd)
+	if err := batchCmd.MarkFlagRequired("targets"); err != nil {
+		panic(err)
+	}
+
+	rootCmd.AddCommand(batchCmd)
+}
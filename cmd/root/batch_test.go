@@ -0,0 +1,100 @@
+package root
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func TestLoadBatchTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []batchTarget
+		wantErr bool
+	}{
+		{
+			name: "every kind",
+			file: "orgs: [acme, acme]\nrepos: [acme/api]\nusers: [octocat]\nenterprises: [acme-corp]\n",
+			want: []batchTarget{{"org", "acme"}, {"repo", "acme/api"}, {"user", "octocat"}, {"enterprise", "acme-corp"}},
+		},
+		{name: "invalid repo", file: "repos: [api]\n", wantErr: true},
+		{name: "empty name", file: "orgs: ['']\n", wantErr: true},
+		{name: "no targets", file: "orgs: []\n", wantErr: true},
+		{name: "not YAML", file: "orgs: [\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "targets.yml")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadBatchTargets(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadBatchTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadBatchTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMintBatch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/acme/installation", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+	mux.HandleFunc("/api/v3/users/octocat/installation", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":2}`))
+	})
+	mux.HandleFunc("/api/v3/orgs/missing/installation", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	mux.HandleFunc("POST /api/v3/app/installations/{id}/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_` + r.PathValue("id") + `","expires_at":"2030-01-01T00:00:00Z"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appToken, err := app.NewFromKey(12345, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appToken.WithEnterprise(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := mintBatch(t.Context(), appToken, []batchTarget{{"org", "acme"}, {"user", "octocat"}}, nil)
+	if err != nil {
+		t.Fatalf("mintBatch() error = %v", err)
+	}
+	if len(results) != 2 || results["org:acme"].Token != "ghs_1" || results["user:octocat"].Token != "ghs_2" {
+		t.Errorf("mintBatch() = %v, want a token per target", results)
+	}
+
+	results, err = mintBatch(t.Context(), appToken, []batchTarget{{"org", "acme"}, {"org", "missing"}}, nil)
+	if err == nil || err.Error() != "1 of 2 targets failed" {
+		t.Errorf("mintBatch() error = %v, want 1 of 2 targets failed", err)
+	}
+	if results["org:acme"].Token != "ghs_1" || results["org:acme"].Error != "" {
+		t.Errorf("mintBatch() org:acme = %+v, want its token", results["org:acme"])
+	}
+	if results["org:missing"].tokenJSON != nil || results["org:missing"].Error == "" {
+		t.Errorf("mintBatch() org:missing = %+v, want its error", results["org:missing"])
+	}
+}
@@ -0,0 +1,94 @@
+package root
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf16"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	expiryTokenFile string
+	expiryMin       time.Duration
+)
+
+var checkExpiryCmd = &cobra.Command{
+	Use:   "check-expiry",
+	Short: "Fail when a stored token is close to expiry",
+	Long: `Read a token file written with --output json --token-file and exit with a
+non-zero status when the token expires within --min. The remaining lifetime is
+printed unless --quiet is given.
+
+Run it from cron or a monitoring agent to catch a refresh job that has stopped
+replacing the token.`,
+	Example: `  gh app-token --app-id 12345 --private-key app.pem --org my-org --output json --token-file /run/token.json
+  gh app-token check-expiry --token-file /run/token.json --min 10m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if expiryMin < 0 {
+			return fmt.Errorf("--min must not be negative")
+		}
+
+		data, err := os.ReadFile(expiryTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		expiresAt, err := parseTokenExpiry(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse token file %s: %w", expiryTokenFile, err)
+		}
+
+		remaining := time.Until(expiresAt).Round(time.Second)
+		if remaining < expiryMin {
+			if remaining <= 0 {
+				return fmt.Errorf("token expired at %s", expiresAt.Local().Format(time.RFC3339))
+			}
+			return fmt.Errorf("token expires in %s, less than %s", remaining, expiryMin)
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ token expires in %s\n", remaining)
+		}
+		return nil
+	},
+}
+
+// parseTokenExpiry returns the expiry of a token file written by --output
+// json, undoing --encoding utf16le if needed.
+func parseTokenExpiry(data []byte) (time.Time, error) {
+	if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+		data = decodeUTF16LE(data[2:])
+	}
+
+	var t tokenJSON
+	if err := json.Unmarshal(data, &t); err != nil {
+		return time.Time{}, fmt.Errorf("not a JSON token file (write it with --output %s): %w", outputJSON, err)
+	}
+	if t.ExpiresAt.IsZero() {
+		return time.Time{}, fmt.Errorf("expires_at is missing")
+	}
+	return t.ExpiresAt, nil
+}
+
+func decodeUTF16LE(data []byte) []byte {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(data[i:]))
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+func init() {
+	checkExpiryCmd.Flags().StringVar(&expiryTokenFile, "token-file", "", "Token file written with --output json")
+	checkExpiryCmd.Flags().DurationVar(&expiryMin, "min", 10*time.Minute, "Minimum remaining lifetime")
+	if err := checkExpiryCmd.MarkFlagRequired("token-file"); err != nil {
+		panic(err)
+	}
+
+	rootCmd.AddCommand(checkExpiryCmd)
+}
@@ -0,0 +1,43 @@
+package root
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func TestParseTokenExpiry(t *testing.T) {
+	expiresAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	output = outputJSON
+	defer func() { output = outputText }()
+
+	var buf bytes.Buffer
+	if err := writeToken(&buf, &app.Token{Token: "ghs_secret", ExpiresAt: expiresAt}, nil); err != nil {
+		t.Fatalf("writeToken() error = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"utf8", buf.Bytes(), false},
+		{"utf16le crlf", encodeTokenFile(buf.Bytes(), true, encodingUTF16LE), false},
+		{"text", []byte("ghs_secret\n"), true},
+		{"no expiry", []byte(`{"token":"ghs_secret"}`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTokenExpiry(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTokenExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(expiresAt) {
+				t.Errorf("parseTokenExpiry() = %v, want %v", got, expiresAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package root
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/spf13/cobra"
+)
+
+const defaultHost = "github.com"
+
+// hostname is the GitHub host given with --hostname.
+var hostname string
+
+func validateHostnameFlag() error {
+	if strings.Contains(hostname, "/") {
+		return fmt.Errorf("--hostname takes a host name such as ghe.example.com, not a URL")
+	}
+	return nil
+}
+
+// apiHost returns the GitHub host to talk to, from the first of: --hostname,
+// GH_HOST, GH_ENTERPRISE_HOST, GITHUB_API_URL (set by GitHub Actions, also
+// on GHES runners), the host of the config file, and the host gh itself
+// defaults to.
+func apiHost() string {
+	if hostname != "" {
+		return hostname
+	}
+	for _, name := range []string{"GH_HOST", "GH_ENTERPRISE_HOST"} {
+		if host := os.Getenv(name); host != "" {
+			return host
+		}
+	}
+	if host, ok := apiURLHost(os.Getenv("GITHUB_API_URL")); ok {
+		return host
+	}
+	if configHost != "" {
+		return configHost
+	}
+	return ghDefaultHost()
+}
+
+// ghDefaultHost returns the host gh resolves when GH_HOST is unset: the only
+// host in its hosts.yml, or github.com.
+func ghDefaultHost() string {
+	host, _ := auth.DefaultHost()
+	return host
+}
+
+// newAppToken builds an AppToken for --app-id and the configured private key,
+// pointed at apiHost() and caching metadata for --metadata-ttl. The signer is
+// returned for callers that need the public key.
+func newAppToken(ctx context.Context) (*app.AppToken, crypto.Signer, error) {
+	signer, err := loadSigner(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create app token: %w", err)
+	}
+
+	appToken, err := newAppTokenForHost(ctx, signer, apiHost())
+	if err != nil {
+		return nil, nil, err
+	}
+	if metadataTTL > 0 {
+		path, err := metadataCachePath()
+		if err != nil {
+			logf("warning: metadata cache disabled: %v", err)
+		} else {
+			appToken.WithCache(&fileCache{path: path}, metadataTTL)
+		}
+	}
+	return appToken, signer, nil
+}
+
+// newAppTokenForHost builds an AppToken for --client-id, --app-id or
+// --app-slug signed by signer and pointed at host. The client ID wins when
+// both IDs come from the environment or the config file.
+func newAppTokenForHost(ctx context.Context, signer crypto.Signer, host string) (*app.AppToken, error) {
+	if err := resolveAppSlug(ctx); err != nil {
+		return nil, err
+	}
+
+	var appToken *app.AppToken
+	var err error
+	if clientID != "" {
+		appToken, err = app.NewFromSignerWithClientID(clientID, signer)
+	} else {
+		appToken, err = app.NewFromSigner(appID, signer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app token: %w", err)
+	}
+
+	if host != defaultHost {
+		if err := appToken.WithEnterprise(host); err != nil {
+			return nil, fmt.Errorf("failed to set enterprise base URL: %w", err)
+		}
+	}
+	appToken.WithRetryPolicy(retryPolicy())
+
+	return appToken, nil
+}
+
+// resolveAppSlug sets the client ID, or the app ID on servers that do not
+// report client IDs, of the app named by --app-slug when neither ID is set.
+// Private apps are looked up with gh's token for the API host, if any.
+func resolveAppSlug(ctx context.Context) error {
+	if appSlug == "" || appID != 0 || clientID != "" {
+		return nil
+	}
+
+	host := apiHost()
+	baseURL := ""
+	if host != defaultHost {
+		baseURL = host
+	}
+	token, _ := auth.TokenForHost(host)
+	found, err := app.FindAppBySlug(ctx, baseURL, appSlug, token)
+	if err != nil {
+		return err
+	}
+
+	clientID = found.ClientID
+	if clientID == "" {
+		appID = found.ID
+	}
+	if verbose {
+		logf("app %s is %s (ID %d)", appSlug, found.ClientID, found.ID)
+	}
+	return nil
+}
+
+// appIssuer returns the client ID or app ID the app JWT is issued for.
+func appIssuer() string {
+	if clientID != "" {
+		return clientID
+	}
+	return strconv.FormatInt(appID, 10)
+}
+
+// addTargetFlags registers the installation target flags on cmd.
+func addTargetFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.Int64Var(&installationID, "installation-id", 0, "GitHub App Installation ID (env: GH_APP_TOKEN_INSTALLATION_ID)")
+	flags.StringVar(&org, "org", "", "Organization name to get installation ID (env: GH_APP_TOKEN_ORG)")
+	flags.StringVar(&repo, "repo", "", "Repository (owner/repo or its URL) to get installation ID (env: GH_APP_TOKEN_REPO)")
+	flags.StringVar(&user, "user", "", "Username to get installation ID (env: GH_APP_TOKEN_USER)")
+	flags.StringVar(&enterprise, "enterprise", "", "Enterprise slug to get installation ID (env: GH_APP_TOKEN_ENTERPRISE)")
+
+	// Make installation identification flags mutually exclusive
+	cmd.MarkFlagsMutuallyExclusive("installation-id", "org", "repo", "user", "enterprise")
+}
+
+// addRepoFlag registers --repo for commands that act on one repository.
+func addRepoFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository (owner/repo or its URL) (env: GH_APP_TOKEN_REPO)")
+}
+
+// splitRepo splits an owner/repo name.
+func splitRepo(s string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(s, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("repo must be in format 'owner/repo'")
+	}
+	return owner, name, nil
+}
+
+// newRepoAppToken checks --repo and returns an AppToken with the ID of the
+// app's installation on the repository.
+func newRepoAppToken(ctx context.Context) (appToken *app.AppToken, id int64, owner, name string, err error) {
+	if err := validateAppFlags(); err != nil {
+		return nil, 0, "", "", err
+	}
+	if repo == "" {
+		repo = detectRepo()
+	}
+	if repo == "" {
+		return nil, 0, "", "", fmt.Errorf("--repo is required")
+	}
+	if err := normalizeRepo(); err != nil {
+		return nil, 0, "", "", err
+	}
+	owner, name, err = splitRepo(repo)
+	if err != nil {
+		return nil, 0, "", "", err
+	}
+
+	appToken, _, err = newAppToken(ctx)
+	if err != nil {
+		return nil, 0, "", "", err
+	}
+	installation, err := appToken.FindRepoInstallation(ctx, owner, name)
+	if err != nil {
+		return nil, 0, "", "", withInstallURL(ctx, appToken, err)
+	}
+	return appToken, installation.GetID(), owner, name, nil
+}
+
+// revokeToken revokes a token minted for a single command once it is done.
+// Failures are only warned about so that they never replace the command's
+// own result.
+func revokeToken(ctx context.Context, appToken *app.AppToken, token string) {
+	revoked, err := appToken.RevokeToken(context.WithoutCancel(ctx), token)
+	switch {
+	case err != nil:
+		logf("warning: %v", err)
+	case verbose && revoked:
+		logf("revoked the installation token")
+	case verbose:
+		logf("the installation token had already expired or been revoked")
+	}
+}
@@ -0,0 +1,259 @@
+package root
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+// gitUsername is the user name GitHub expects with an installation token.
+const gitUsername = "x-access-token"
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <owner/repo> [<directory>]",
+	Short: "Clone a repository with an installation token",
+	Long: `Clone a repository through the git-credential command, which gives git a fresh
+token limited to the repository whenever it asks, and keep it configured in
+the clone. Later fetches and pushes keep working after a token expires, and
+no token is passed on the git command line or stored in .git/config.
+
+The helper is configured with the host, app, profile and key flags of the
+clone (--private-key, --signer-cmd and --passphrase-file). A key given by
+--private-key-pem is refused, as it would be stored in .git/config.`,
+	Example: `  gh app-token clone --app-id 12345 --private-key app.pem owner/repo`,
+	Args:    cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+		owner, name, err := splitRepo(args[0])
+		if err != nil {
+			return err
+		}
+		name = strings.TrimSuffix(name, ".git")
+		dir := name
+		if len(args) == 2 {
+			dir = args[1]
+		}
+
+		helper, err := credentialHelper()
+		if err != nil {
+			return err
+		}
+
+		// Fail with the install URL rather than git's prompt when the app
+		// cannot access the repository
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if _, err := appToken.FindRepoInstallation(cmd.Context(), owner, name); err != nil {
+			return fmt.Errorf("failed to get token: %w", withInstallURL(cmd.Context(), appToken, err))
+		}
+
+		if err := runGit(cmd, "", cloneArgs(apiHost(), owner, name, dir, helper)...); err != nil {
+			return fmt.Errorf("git clone failed: %w", err)
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ cloned %s/%s into %s\n", owner, name, dir)
+		}
+		return nil
+	},
+}
+
+var gitCredentialCmd = &cobra.Command{
+	Use:   "git-credential <operation>",
+	Short: "Git credential helper that returns installation tokens",
+	Long: `Implement the git credential helper protocol: for "get", print an installation
+token for the repository git asks about, limited to that repository. Other
+operations are ignored. clone configures this automatically; it requires
+credential.useHttpPath so that git sends the repository path.`,
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] != "get" {
+			return nil
+		}
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		req, err := readCredentialRequest(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		// Leave other hosts and requests without a path to other helpers
+		if req["protocol"] != "https" || req["host"] != apiHost() || req["path"] == "" {
+			return nil
+		}
+		owner, name, err := splitRepo(strings.TrimSuffix(req["path"], ".git"))
+		if err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		token, err := repoToken(cmd.Context(), appToken, owner, name)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "username=%s\n", gitUsername)
+		fmt.Fprintf(w, "password=%s\n", token.Token)
+		if !token.ExpiresAt.IsZero() {
+			fmt.Fprintf(w, "password_expiry_utc=%d\n", token.ExpiresAt.Unix())
+		}
+		return nil
+	},
+}
+
+// cloneArgs returns the git arguments that clone owner/name into dir with
+// helper as the only credential helper for host. git clone writes the
+// --config values into the clone before fetching, so the same helper serves
+// the clone and every later fetch and push.
+func cloneArgs(host, owner, name, dir, helper string) []string {
+	prefix := fmt.Sprintf("credential.https://%s.", host)
+	return []string{
+		"clone",
+		// An empty helper resets the list, so global helpers are not asked first
+		"--config", prefix + "helper=",
+		"--config", prefix + "helper=" + helper,
+		"--config", prefix + "useHttpPath=true",
+		repoURL(host, owner, name).String(), dir,
+	}
+}
+
+// repoToken mints a token limited to owner/name.
+func repoToken(ctx context.Context, appToken *app.AppToken, owner, name string) (*app.Token, error) {
+	installation, err := appToken.FindRepoInstallation(ctx, owner, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", withInstallURL(ctx, appToken, err))
+	}
+
+	return appToken.CreateTokenWithOptions(ctx, installation.GetID(), &github.InstallationTokenOptions{
+		Repositories: []string{name},
+	})
+}
+
+// repoURL returns the HTTPS clone URL of owner/name on host.
+func repoURL(host, owner, name string) *url.URL {
+	return &url.URL{Scheme: "https", Host: host, Path: "/" + owner + "/" + strings.TrimSuffix(name, ".git") + ".git"}
+}
+
+// readCredentialRequest reads the key=value lines git sends to a credential
+// helper, up to a blank line or EOF.
+func readCredentialRequest(r io.Reader) (map[string]string, error) {
+	req := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid credential line %q", line)
+		}
+		req[key] = value
+	}
+	return req, scanner.Err()
+}
+
+// credentialHelper returns the credential.helper value that runs this binary
+// with the app flags of the current invocation. A key given as PEM is
+// refused, as the helper would store it in the git config.
+func credentialHelper() (string, error) {
+	if privateKeyPEM != "" {
+		return "", fmt.Errorf("the credential helper cannot use a key given by --private-key-pem; use --private-key with a file or key URI")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	// The helper runs with git's environment, which may lack GH_HOST or
+	// resolve another host once gh's hosts.yml changes, so the host is
+	// always pinned
+	args := []string{"GH_HOST=" + apiHost(), exe, "git-credential"}
+	switch {
+	case clientID != "":
+		args = append(args, "--client-id", clientID)
+	case appSlug != "":
+		args = append(args, "--app-slug", appSlug)
+	default:
+		args = append(args, "--app-id", strconv.FormatInt(appID, 10))
+	}
+	if profile := selectedProfile(); profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	if privateKeyPath != "" {
+		key := privateKeyPath
+		// git runs the helper inside the clone; key URIs are left alone
+		if !strings.Contains(key, "://") {
+			if key, err = filepath.Abs(key); err != nil {
+				return "", err
+			}
+		}
+		args = append(args, "--private-key", key)
+	}
+	if signerCmd != "" {
+		// Like the key, a command given by a relative path would depend on
+		// the directory git runs the helper in
+		command := strings.Fields(signerCmd)
+		if strings.ContainsRune(command[0], filepath.Separator) && !filepath.IsAbs(command[0]) {
+			if command[0], err = filepath.Abs(command[0]); err != nil {
+				return "", err
+			}
+		}
+		args = append(args, "--signer-cmd", strings.Join(command, " "))
+	}
+	if passphraseFile != "" {
+		path, err := filepath.Abs(passphraseFile)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--passphrase-file", path)
+	}
+
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return "!" + strings.Join(quoted, " "), nil
+}
+
+// shellQuote quotes s for the POSIX shell git runs helpers with.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func runGit(cmd *cobra.Command, dir string, args ...string) error {
+	git := exec.CommandContext(cmd.Context(), "git", args...)
+	git.Dir = dir
+	git.Stdout = cmd.ErrOrStderr()
+	git.Stderr = cmd.ErrOrStderr()
+	return git.Run()
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd, gitCredentialCmd)
+}
@@ -0,0 +1,82 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	commentNumber   int
+	commentBody     string
+	commentBodyFile string
+)
+
+var commentCmd = &cobra.Command{
+	Use:   "comment",
+	Short: "Comment on a pull request or issue",
+	Long: `Post a comment on a pull request or issue of --repo and print its URL. A token
+limited to issues:write on the repository is minted for the request and
+revoked afterwards.`,
+	Example: `  gh app-token comment --app-id 12345 --private-key app.pem --repo owner/repo --pr 42 --body-file out.md`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := readCommentBody(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+
+		appToken, id, owner, name, err := newRepoAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		comment, err := appToken.CreateComment(cmd.Context(), id, owner, name, commentNumber, body)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), comment.GetHTMLURL())
+		return nil
+	},
+}
+
+// readCommentBody returns --body, or the content of --body-file (- for
+// stdin).
+func readCommentBody(stdin io.Reader) (string, error) {
+	if commentBodyFile == "" {
+		if commentBody == "" {
+			return "", fmt.Errorf("--body or --body-file is required")
+		}
+		return commentBody, nil
+	}
+
+	var data []byte
+	var err error
+	if commentBodyFile == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(commentBodyFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("body is empty")
+	}
+	return string(data), nil
+}
+
+func init() {
+	addRepoFlag(commentCmd)
+	commentCmd.Flags().IntVar(&commentNumber, "pr", 0, "Number of the pull request or issue")
+	commentCmd.Flags().StringVarP(&commentBody, "body", "b", "", "Comment text")
+	commentCmd.Flags().StringVarP(&commentBodyFile, "body-file", "F", "", "Read the comment text from a file (- for stdin)")
+	_ = commentCmd.MarkFlagRequired("pr")
+	commentCmd.MarkFlagsMutuallyExclusive("body", "body-file")
+	commentCmd.Flags().SortFlags = false
+
+	rootCmd.AddCommand(commentCmd)
+}
@@ -0,0 +1,38 @@
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCommentBody(t *testing.T) {
+	t.Cleanup(func() { commentBody, commentBodyFile = "", "" })
+
+	if _, err := readCommentBody(nil); err == nil {
+		t.Error("readCommentBody() error = nil, want error without a body")
+	}
+
+	commentBody = "LGTM"
+	if got, err := readCommentBody(nil); err != nil || got != "LGTM" {
+		t.Errorf("readCommentBody() = %q, %v, want LGTM", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.md")
+	if err := os.WriteFile(path, []byte("## Report\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	commentBody, commentBodyFile = "", path
+	if got, err := readCommentBody(nil); err != nil || got != "## Report\n" {
+		t.Errorf("readCommentBody() = %q, %v, want the file content", got, err)
+	}
+
+	commentBodyFile = "-"
+	if got, err := readCommentBody(strings.NewReader("from stdin")); err != nil || got != "from stdin" {
+		t.Errorf("readCommentBody() = %q, %v, want stdin", got, err)
+	}
+	if _, err := readCommentBody(strings.NewReader("")); err == nil {
+		t.Error("readCommentBody() error = nil, want error for an empty body")
+	}
+}
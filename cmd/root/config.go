@@ -0,0 +1,233 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/perm"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// createAppTokenAction is the official action that import-action translates.
+const createAppTokenAction = "actions/create-github-app-token"
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage gh app-token configuration",
+}
+
+var configImportActionCmd = &cobra.Command{
+	Use:   "import-action <workflow.yml>",
+	Short: "Translate actions/create-github-app-token steps into gh app-token steps",
+	Long: `Read a GitHub Actions workflow and print an equivalent gh app-token step for
+every step that uses actions/create-github-app-token. The generated steps keep
+the step id and expose the token as the same "token" output, so later
+references such as steps.<id>.outputs.token keep working.
+
+Inputs that have no equivalent are listed as comments above the step.`,
+	Example: `  gh app-token config import-action .github/workflows/release.yml`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read workflow: %w", err)
+		}
+
+		steps, err := findActionSteps(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse workflow %s: %w", args[0], err)
+		}
+		if len(steps) == 0 {
+			return fmt.Errorf("%s does not use %s", args[0], createAppTokenAction)
+		}
+
+		w := cmd.OutOrStdout()
+		for i, s := range steps {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			writeActionStep(w, s)
+		}
+		return nil
+	},
+}
+
+type actionStep struct {
+	Job  string            `yaml:"-"`
+	ID   string            `yaml:"id"`
+	Name string            `yaml:"name"`
+	Uses string            `yaml:"uses"`
+	With map[string]string `yaml:"with"`
+}
+
+// findActionSteps returns the steps of every job, in job name order, that use
+// actions/create-github-app-token.
+func findActionSteps(data []byte) ([]actionStep, error) {
+	var wf struct {
+		Jobs map[string]struct {
+			Steps []actionStep `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]string, 0, len(wf.Jobs))
+	for name := range wf.Jobs {
+		jobs = append(jobs, name)
+	}
+	sort.Strings(jobs)
+
+	var steps []actionStep
+	for _, job := range jobs {
+		for _, s := range wf.Jobs[job].Steps {
+			if strings.HasPrefix(s.Uses, createAppTokenAction+"@") {
+				s.Job = job
+				steps = append(steps, s)
+			}
+		}
+	}
+	return steps, nil
+}
+
+// translateAction maps the inputs of the action to environment variables and
+// flags. notes describes the inputs that cannot be carried over exactly.
+func translateAction(with map[string]string) (env [][2]string, args []string, notes []string) {
+	inputs := make([]string, 0, len(with))
+	for name := range with {
+		inputs = append(inputs, name)
+	}
+	sort.Strings(inputs)
+
+	owner := with["owner"]
+	var repos []string
+	for _, r := range strings.FieldsFunc(with["repositories"], func(r rune) bool { return r == ',' || r == '\n' }) {
+		if r = strings.TrimSpace(r); r != "" {
+			repos = append(repos, r)
+		}
+	}
+
+	var perms []string
+	for _, name := range inputs {
+		value := with[name]
+		switch {
+		case name == "app-id":
+			env = append(env, [2]string{"GH_APP_TOKEN_APP_ID", value})
+		case name == "client-id":
+			env = append(env, [2]string{"GH_APP_TOKEN_CLIENT_ID", value})
+		case name == "private-key":
+			env = append(env, [2]string{"GH_APP_TOKEN_PRIVATE_KEY_PEM", value})
+		case name == "github-api-url":
+			if host, ok := apiURLHost(value); ok {
+				if host != defaultHost {
+					env = append(env, [2]string{"GH_HOST", host})
+				}
+			} else {
+				notes = append(notes, fmt.Sprintf("github-api-url %q: the runner's GITHUB_API_URL is used; set GH_HOST to use another host", value))
+			}
+		case name == "owner" || name == "repositories":
+		case name == "skip-token-revoke":
+			notes = append(notes, "skip-token-revoke: gh app-token never revokes tokens; they expire after an hour")
+		case strings.HasPrefix(name, "permission-"):
+			p := strings.TrimPrefix(name, "permission-") + ":" + strings.TrimSpace(value)
+			if _, err := perm.Parse(p); err != nil && !strings.Contains(value, "${{") {
+				notes = append(notes, fmt.Sprintf("%s: %v; it is not applied", name, err))
+				continue
+			}
+			perms = append(perms, p)
+		default:
+			notes = append(notes, fmt.Sprintf("%s is not supported", name))
+		}
+	}
+
+	switch {
+	case len(repos) > 0:
+		if owner == "" {
+			owner = "${{ github.repository_owner }}"
+		}
+		args = append(args, "--repo", owner+"/"+repos[0])
+		notes = append(notes, fmt.Sprintf("repositories: the token covers the whole installation, not only %s", strings.Join(repos, ", ")))
+	case owner != "":
+		args = append(args, "--org", owner)
+		notes = append(notes, fmt.Sprintf("owner: use --user instead of --org if %s is a user account", owner))
+	default:
+		args = append(args, "--repo", "${{ github.repository }}")
+		notes = append(notes, "the action scopes the token to the current repository; the token covers the whole installation")
+	}
+
+	if len(perms) > 0 {
+		args = append(args, "--permissions", strings.Join(perms, ","))
+	}
+
+	return env, args, notes
+}
+
+// apiURLHost returns the host gh app-token should use for a github-api-url
+// input, or false when the value cannot be resolved statically.
+func apiURLHost(apiURL string) (string, bool) {
+	if strings.Contains(apiURL, "${{") {
+		return "", false
+	}
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	if u.Host == "api.github.com" {
+		return defaultHost, true
+	}
+	return u.Host, true
+}
+
+func writeActionStep(w io.Writer, s actionStep) {
+	env, args, notes := translateAction(s.With)
+
+	label := s.ID
+	if label == "" {
+		label = s.Name
+	}
+	fmt.Fprintf(w, "# jobs.%s: %s (%s)\n", s.Job, orNone(label), s.Uses)
+	for _, n := range notes {
+		fmt.Fprintf(w, "# note: %s\n", n)
+	}
+
+	if s.Name != "" {
+		fmt.Fprintf(w, "- name: %s\n", s.Name)
+		if s.ID != "" {
+			fmt.Fprintf(w, "  id: %s\n", s.ID)
+		}
+	} else if s.ID != "" {
+		fmt.Fprintf(w, "- id: %s\n", s.ID)
+	} else {
+		fmt.Fprintln(w, "- name: Create GitHub App token")
+	}
+
+	fmt.Fprintln(w, "  env:")
+	fmt.Fprintln(w, "    GH_TOKEN: ${{ github.token }}")
+	for _, kv := range env {
+		fmt.Fprintf(w, "    %s: %s\n", kv[0], kv[1])
+	}
+
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if strings.HasPrefix(a, "--") {
+			quoted[i] = a
+		} else {
+			quoted[i] = fmt.Sprintf("%q", a)
+		}
+	}
+	fmt.Fprintln(w, "  run: |")
+	fmt.Fprintln(w, "    gh extension install buty4649/gh-app-token")
+	fmt.Fprintf(w, "    token=$(gh app-token %s)\n", strings.Join(quoted, " "))
+	fmt.Fprintln(w, `    echo "::add-mask::$token"`)
+	fmt.Fprintln(w, `    echo "token=$token" >> "$GITHUB_OUTPUT"`)
+}
+
+func init() {
+	configCmd.AddCommand(configImportActionCmd)
+	rootCmd.AddCommand(configCmd)
+}
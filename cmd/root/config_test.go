@@ -0,0 +1,161 @@
+package root
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testWorkflow = `
+name: release
+on: push
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Generate token
+        id: app-token
+        uses: actions/create-github-app-token@v2
+        with:
+          app-id: ${{ vars.APP_ID }}
+          private-key: ${{ secrets.PRIVATE_KEY }}
+          owner: acme
+          repositories: |
+            widgets
+            gadgets
+          permission-contents: write
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - id: token
+        uses: actions/create-github-app-token@v1
+        with:
+          app-id: 12345
+          private-key: ${{ secrets.PRIVATE_KEY }}
+          github-api-url: https://ghe.example.com/api/v3
+`
+
+func TestFindActionSteps(t *testing.T) {
+	steps, err := findActionSteps([]byte(testWorkflow))
+	if err != nil {
+		t.Fatalf("findActionSteps() error = %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("findActionSteps() found %d steps, want 2", len(steps))
+	}
+	if steps[0].Job != "build" || steps[1].Job != "release" {
+		t.Errorf("jobs = %s, %s, want build, release", steps[0].Job, steps[1].Job)
+	}
+
+	if _, err := findActionSteps([]byte("jobs: [")); err == nil {
+		t.Error("findActionSteps() error = nil, want error for invalid YAML")
+	}
+}
+
+func TestTranslateAction(t *testing.T) {
+	tests := []struct {
+		name      string
+		with      map[string]string
+		wantEnv   map[string]string
+		wantArgs  string
+		wantNotes []string
+	}{
+		{
+			name:     "current repository",
+			with:     map[string]string{"app-id": "1", "private-key": "k"},
+			wantEnv:  map[string]string{"GH_APP_TOKEN_APP_ID": "1", "GH_APP_TOKEN_PRIVATE_KEY_PEM": "k"},
+			wantArgs: "--repo ${{ github.repository }}",
+		},
+		{
+			name:     "client ID",
+			with:     map[string]string{"client-id": "Iv1.abc", "private-key": "k"},
+			wantEnv:  map[string]string{"GH_APP_TOKEN_CLIENT_ID": "Iv1.abc", "GH_APP_TOKEN_PRIVATE_KEY_PEM": "k"},
+			wantArgs: "--repo ${{ github.repository }}",
+		},
+		{
+			name:      "owner",
+			with:      map[string]string{"owner": "acme"},
+			wantArgs:  "--org acme",
+			wantNotes: []string{"--user"},
+		},
+		{
+			name:      "repositories without owner",
+			with:      map[string]string{"repositories": "widgets, gadgets"},
+			wantArgs:  "--repo ${{ github.repository_owner }}/widgets",
+			wantNotes: []string{"widgets, gadgets"},
+		},
+		{
+			name:     "enterprise",
+			with:     map[string]string{"github-api-url": "https://ghe.example.com/api/v3"},
+			wantEnv:  map[string]string{"GH_HOST": "ghe.example.com"},
+			wantArgs: "--repo ${{ github.repository }}",
+		},
+		{
+			name:     "github.com API URL",
+			with:     map[string]string{"github-api-url": "https://api.github.com"},
+			wantEnv:  map[string]string{},
+			wantArgs: "--repo ${{ github.repository }}",
+		},
+		{
+			name:     "permissions",
+			with:     map[string]string{"owner": "acme", "permission-issues": "write", "permission-pull-requests": "read"},
+			wantArgs: "--org acme --permissions issues:write,pull-requests:read",
+		},
+		{
+			name:      "unsupported inputs",
+			with:      map[string]string{"permission-issues": "admin", "private-key-id": "abc"},
+			wantArgs:  "--repo ${{ github.repository }}",
+			wantNotes: []string{"private-key-id is not supported", "permission-issues:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, args, notes := translateAction(tt.with)
+
+			if tt.wantEnv != nil {
+				if len(env) != len(tt.wantEnv) {
+					t.Errorf("env = %v, want %v", env, tt.wantEnv)
+				}
+				for _, kv := range env {
+					if tt.wantEnv[kv[0]] != kv[1] {
+						t.Errorf("env %s = %q, want %q", kv[0], kv[1], tt.wantEnv[kv[0]])
+					}
+				}
+			}
+			if got := strings.Join(args, " "); got != tt.wantArgs {
+				t.Errorf("args = %q, want %q", got, tt.wantArgs)
+			}
+			joined := strings.Join(notes, "\n")
+			for _, want := range tt.wantNotes {
+				if !strings.Contains(joined, want) {
+					t.Errorf("notes %q do not mention %q", joined, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteActionStep(t *testing.T) {
+	var buf bytes.Buffer
+	writeActionStep(&buf, actionStep{
+		Job:  "release",
+		ID:   "app-token",
+		Name: "Generate token",
+		Uses: "actions/create-github-app-token@v2",
+		With: map[string]string{"app-id": "${{ vars.APP_ID }}", "private-key": "${{ secrets.PRIVATE_KEY }}", "owner": "acme"},
+	})
+
+	got := buf.String()
+	for _, want := range []string{
+		"- name: Generate token\n  id: app-token\n",
+		"    GH_APP_TOKEN_APP_ID: ${{ vars.APP_ID }}\n",
+		`token=$(gh app-token --org "acme")`,
+		`echo "::add-mask::$token"`,
+		`echo "token=$token" >> "$GITHUB_OUTPUT"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeActionStep() output does not contain %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are resolved with the precedence flags > environment variables >
+// config file: loadEnv fills in what no flag set, then applyConfigFile fills
+// in what is still unset. Settings whose zero value is meaningful, such as
+// --record-stats=false, count as set when given at all, which
+// cmd.Flags().Changed tells.
+
+// configFile holds the defaults written by init. Flags and environment
+// variables take precedence over every field.
+type configFile struct {
+	AppID int64 `yaml:"app_id,omitempty"`
+	// ClientID or AppSlug identify the app instead of AppID
+	ClientID string `yaml:"client_id,omitempty"`
+	AppSlug  string `yaml:"app_slug,omitempty"`
+	// PrivateKey is a key file path or a key URI such as awskms://...
+	PrivateKey string `yaml:"private_key,omitempty"`
+	Host       string `yaml:"host,omitempty"`
+	// MaxKeyAge is --max-key-age in days
+	MaxKeyAge int `yaml:"max_key_age,omitempty"`
+	// RecordStats is --record-stats
+	RecordStats bool `yaml:"record_stats,omitempty"`
+
+	// Default installation target; at most one is set
+	InstallationID int64  `yaml:"installation_id,omitempty"`
+	Org            string `yaml:"org,omitempty"`
+	Repo           string `yaml:"repo,omitempty"`
+	User           string `yaml:"user,omitempty"`
+	Enterprise     string `yaml:"enterprise,omitempty"`
+
+	// Profiles are selected with --profile or GH_APP_TOKEN_PROFILE instead of
+	// the settings above. They do not inherit from them, nor nest.
+	Profiles map[string]*configFile `yaml:"profiles,omitempty"`
+}
+
+var (
+	// configHost is the host from the config file, used by apiHost when
+	// GH_HOST is not set.
+	configHost string
+	// profileName selects a profile of the config file (--profile).
+	profileName string
+)
+
+// configPath returns GH_APP_TOKEN_CONFIG, or config.yml in the gh-app-token
+// directory under $XDG_CONFIG_HOME (default ~/.config) on every platform,
+// like gh itself.
+func configPath() (string, error) {
+	if path := os.Getenv("GH_APP_TOKEN_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh-app-token", "config.yml"), nil
+}
+
+// loadConfigFile reads the config file at path. A missing file yields an
+// empty config.
+func loadConfigFile(path string) (*configFile, error) {
+	cfg := &configFile{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if cfg.targets() > 1 {
+		return nil, fmt.Errorf("%s: installation_id, org, repo, user and enterprise cannot be used together", path)
+	}
+	for name, p := range cfg.Profiles {
+		if p == nil {
+			// An empty profile is a valid, if useless, YAML mapping
+			cfg.Profiles[name] = &configFile{}
+			continue
+		}
+		if len(p.Profiles) > 0 {
+			return nil, fmt.Errorf("%s: profile %s cannot contain profiles", path, name)
+		}
+		if p.targets() > 1 {
+			return nil, fmt.Errorf("%s: profile %s: installation_id, org, repo, user and enterprise cannot be used together", path, name)
+		}
+	}
+	return cfg, nil
+}
+
+// profile returns the named profile, or the top-level settings for "".
+func (c *configFile) profile(name string) (*configFile, error) {
+	if name == "" {
+		return c, nil
+	}
+	if p, ok := c.Profiles[name]; ok {
+		return p, nil
+	}
+
+	names := slices.Sorted(maps.Keys(c.Profiles))
+	if len(names) == 0 {
+		return nil, fmt.Errorf("profile %q not found: the config file has no profiles", name)
+	}
+	return nil, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// selectedProfile returns --profile, falling back to GH_APP_TOKEN_PROFILE.
+func selectedProfile() string {
+	if profileName != "" {
+		return profileName
+	}
+	return os.Getenv("GH_APP_TOKEN_PROFILE")
+}
+
+func (c *configFile) targets() int {
+	n := 0
+	for _, set := range []bool{c.InstallationID != 0, c.Org != "", c.Repo != "", c.User != "", c.Enterprise != ""} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *configFile) save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// loadEnv fills in the settings that no flag provided from the GH_APP_TOKEN_*
+// environment variables, falling back to GITHUB_APP_ID,
+// GITHUB_APP_PRIVATE_KEY and GITHUB_APP_INSTALLATION_ID. Like the config file, the key and the target are
+// taken as a whole, and so are the app ID, client ID and slug: a flag hides
+// every variable of its kind, so that a flag never conflicts with the
+// environment.
+func loadEnv(cmd *cobra.Command) error {
+	if appID == 0 && clientID == "" && appSlug == "" {
+		if name, env := lookupEnv("GH_APP_TOKEN_APP_ID", "GITHUB_APP_ID"); env != "" {
+			var err error
+			appID, err = strconv.ParseInt(env, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+		clientID = os.Getenv("GH_APP_TOKEN_CLIENT_ID")
+		appSlug = os.Getenv("GH_APP_TOKEN_APP_SLUG")
+	}
+
+	if privateKeyPath == "" && privateKeyPEM == "" && signerCmd == "" {
+		switch {
+		case os.Getenv("GH_APP_TOKEN_PRIVATE_KEY") != "":
+			privateKeyPath = os.Getenv("GH_APP_TOKEN_PRIVATE_KEY")
+		case os.Getenv("GH_APP_TOKEN_PRIVATE_KEY_PEM") != "":
+			privateKeyPEM = os.Getenv("GH_APP_TOKEN_PRIVATE_KEY_PEM")
+		case os.Getenv("GH_APP_TOKEN_SIGNER_CMD") != "":
+			signerCmd = os.Getenv("GH_APP_TOKEN_SIGNER_CMD")
+		default:
+			// Holds the key itself, like the private-key input of the action
+			privateKeyPEM = os.Getenv("GITHUB_APP_PRIVATE_KEY")
+		}
+	}
+
+	if env := os.Getenv("GH_APP_TOKEN_MAX_KEY_AGE"); !cmd.Flags().Changed("max-key-age") && env != "" {
+		var err error
+		maxKeyAge, err = strconv.Atoi(env)
+		if err != nil {
+			return fmt.Errorf("invalid GH_APP_TOKEN_MAX_KEY_AGE: %w", err)
+		}
+	}
+
+	if env := os.Getenv("GH_APP_TOKEN_RECORD_STATS"); !cmd.Flags().Changed("record-stats") && env != "" {
+		var err error
+		recordStats, err = strconv.ParseBool(env)
+		if err != nil {
+			return fmt.Errorf("invalid GH_APP_TOKEN_RECORD_STATS: %w", err)
+		}
+	}
+
+	if installationID == 0 && org == "" && repo == "" && user == "" && enterprise == "" {
+		if name, env := lookupEnv("GH_APP_TOKEN_INSTALLATION_ID", "GITHUB_APP_INSTALLATION_ID"); env != "" {
+			var err error
+			installationID, err = strconv.ParseInt(env, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+		org = os.Getenv("GH_APP_TOKEN_ORG")
+		repo = os.Getenv("GH_APP_TOKEN_REPO")
+		user = os.Getenv("GH_APP_TOKEN_USER")
+		enterprise = os.Getenv("GH_APP_TOKEN_ENTERPRISE")
+	}
+	return nil
+}
+
+// lookupEnv returns the first of names that is set to a non-empty value,
+// with that value. The GITHUB_APP_* names of actions/create-github-app-token
+// and octokit are passed after the GH_APP_TOKEN_* ones as fallbacks.
+func lookupEnv(names ...string) (name, value string) {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return name, value
+		}
+	}
+	return "", ""
+}
+
+// applyConfigFile fills in the settings that neither a flag nor an
+// environment variable provided. The target is taken only if no target was
+// given at all, as the target flags exclude each other.
+func applyConfigFile(cmd *cobra.Command, cfg *configFile) {
+	if appID == 0 && clientID == "" && appSlug == "" {
+		appID = cfg.AppID
+		clientID = cfg.ClientID
+		appSlug = cfg.AppSlug
+	}
+	if privateKeyPath == "" && privateKeyPEM == "" && signerCmd == "" {
+		privateKeyPath = cfg.PrivateKey
+	}
+	configHost = cfg.Host
+	if !cmd.Flags().Changed("max-key-age") && os.Getenv("GH_APP_TOKEN_MAX_KEY_AGE") == "" {
+		maxKeyAge = cfg.MaxKeyAge
+	}
+	if !cmd.Flags().Changed("record-stats") && os.Getenv("GH_APP_TOKEN_RECORD_STATS") == "" {
+		recordStats = cfg.RecordStats
+	}
+
+	if installationID == 0 && org == "" && repo == "" && user == "" && enterprise == "" {
+		installationID = cfg.InstallationID
+		org = cfg.Org
+		repo = cfg.Repo
+		user = cfg.User
+		enterprise = cfg.Enterprise
+	}
+}
+
+// loadConfig applies the selected profile of the config file to the settings
+// left unset.
+func loadConfig(cmd *cobra.Command) error {
+	name := selectedProfile()
+	path, err := configPath()
+	if err != nil {
+		if name != "" {
+			return fmt.Errorf("cannot read profile %q: %w", name, err)
+		}
+		// Without a home directory there is no config file to read
+		return nil
+	}
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	p, err := cfg.profile(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	applyConfigFile(cmd, p)
+	return nil
+}
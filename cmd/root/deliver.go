@@ -0,0 +1,100 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/auth/keyring"
+	"github.com/spf13/cobra"
+)
+
+// deliverKeyring is the only --deliver target so far.
+const deliverKeyring = "keyring"
+
+var deliver string
+
+func validateDeliverFlags() error {
+	if deliver == "" {
+		return nil
+	}
+	if _, err := parseDeliverTarget(deliver); err != nil {
+		return err
+	}
+	if tokenFile != "" {
+		return fmt.Errorf("--deliver cannot be used with --token-file")
+	}
+	if output == outputAgeEncrypt {
+		return fmt.Errorf("--deliver cannot be used with --output %s", outputAgeEncrypt)
+	}
+	return nil
+}
+
+// parseDeliverTarget returns the name in a keyring:<name> target.
+func parseDeliverTarget(target string) (string, error) {
+	name, ok := strings.CutPrefix(target, deliverKeyring+":")
+	if !ok || name == "" {
+		return "", fmt.Errorf("invalid target %q (want %s:<name>)", target, deliverKeyring)
+	}
+	return name, nil
+}
+
+// deliverToken stores the token and its expiry in the OS keyring for the
+// read command.
+func deliverToken(token *app.Token) error {
+	name, err := parseDeliverTarget(deliver)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tokenJSON{
+		Token:               token.Token,
+		ExpiresAt:           token.ExpiresAt,
+		Permissions:         token.Permissions,
+		RepositorySelection: token.RepositorySelection,
+	})
+	if err != nil {
+		return err
+	}
+	return keyring.StoreToken(name, string(data))
+}
+
+var readCmd = &cobra.Command{
+	Use:   "read keyring:<name>",
+	Short: "Print a token handed off with --deliver",
+	Long: `Print the token another gh app-token run stored with --deliver keyring:<name>.
+Fails if no token is stored under the name or it has expired.
+
+Only processes of the same user can read the OS keyring, so tokens reach
+sibling processes without files or environment variables.`,
+	Example: `  gh app-token --app-id 12345 --private-key app.pem --org my-org --deliver keyring:ci
+  GH_TOKEN=$(gh app-token read keyring:ci) gh repo list my-org`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, err := parseDeliverTarget(args[0])
+		if err != nil {
+			return err
+		}
+
+		data, err := keyring.LoadToken(name)
+		if err != nil {
+			return err
+		}
+		var t tokenJSON
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return fmt.Errorf("failed to parse token %q: %w", name, err)
+		}
+		if !t.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("token %q expired at %s", name, t.ExpiresAt.Local().Format(time.RFC3339))
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), t.Token)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(readCmd)
+}
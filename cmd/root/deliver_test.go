@@ -0,0 +1,70 @@
+package root
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+func TestValidateDeliverFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		deliver   string
+		tokenFile string
+		output    string
+		wantErr   bool
+	}{
+		{"unset", "", "", outputText, false},
+		{"keyring", "keyring:ci", "", outputJSON, false},
+		{"missing name", "keyring:", "", outputText, true},
+		{"unknown target", "file:token.txt", "", outputText, true},
+		{"with token file", "keyring:ci", "token.txt", outputText, true},
+		{"with age-encrypt", "keyring:ci", "", outputAgeEncrypt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliver, tokenFile, output = tt.deliver, tt.tokenFile, tt.output
+			defer func() { deliver, tokenFile, output = "", "", outputText }()
+
+			if err := validateDeliverFlags(); (err != nil) != tt.wantErr {
+				t.Errorf("validateDeliverFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliverAndRead(t *testing.T) {
+	gokeyring.MockInit()
+	defer func() { deliver = ""; readCmd.SetOut(nil) }()
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{"valid", time.Now().Add(time.Hour), false},
+		{"expired", time.Now().Add(-time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliver = "keyring:ci"
+			if err := emitToken(&app.Token{Token: "ghs_secret", ExpiresAt: tt.expiresAt}, nil); err != nil {
+				t.Fatalf("emitToken() error = %v", err)
+			}
+
+			var buf bytes.Buffer
+			readCmd.SetOut(&buf)
+			err := readCmd.RunE(readCmd, []string{"keyring:ci"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("read error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.TrimSpace(buf.String()) != "ghs_secret" {
+				t.Errorf("read output = %q, want ghs_secret", buf.String())
+			}
+		})
+	}
+}
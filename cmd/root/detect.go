@@ -0,0 +1,103 @@
+package root
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// detectRepo returns the repository to use when no installation target was
+// given at all: GITHUB_REPOSITORY, set by GitHub Actions, or the origin
+// remote of the git checkout in the current directory if it is on the API
+// host. It returns "" when neither is available.
+func detectRepo() string {
+	if r := os.Getenv("GITHUB_REPOSITORY"); r != "" {
+		if verbose {
+			logf("using repository %s from GITHUB_REPOSITORY", r)
+		}
+		return r
+	}
+
+	remote, err := originURL()
+	if err != nil {
+		// Not a checkout, no origin remote, or no git at all
+		return ""
+	}
+	host, owner, name, err := parseRepoURL(remote)
+	if err != nil {
+		return ""
+	}
+	if host != apiHost() {
+		if verbose {
+			logf("ignoring the origin remote %s: it is not on %s", remote, apiHost())
+		}
+		return ""
+	}
+
+	r := owner + "/" + name
+	if verbose {
+		logf("using repository %s from the origin remote", r)
+	}
+	return r
+}
+
+// originURL returns the URL of the origin remote in the current directory.
+// It is a variable so that tests do not depend on where they run.
+var originURL = func() (string, error) {
+	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// parseRepoURL returns the host, owner and name of a repository URL as git
+// remotes use them: https://host/owner/repo(.git), ssh://git@host/owner/repo
+// or the scp-like git@host:owner/repo.git.
+func parseRepoURL(s string) (host, owner, name string, err error) {
+	var path string
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid repository URL %q: %w", s, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if userHost, p, ok := strings.Cut(s, ":"); ok && !strings.Contains(userHost, "/") {
+		_, host, _ = strings.Cut(userHost, "@")
+		if host == "" {
+			host = userHost
+		}
+		path = p
+	}
+	if host == "" {
+		return "", "", "", fmt.Errorf("invalid repository URL %q", s)
+	}
+
+	owner, name, err = splitRepo(strings.TrimSuffix(strings.Trim(path, "/"), ".git"))
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid repository URL %q: %w", s, err)
+	}
+	return host, owner, name, nil
+}
+
+// normalizeRepo rewrites a --repo given as a clone URL to owner/repo. A URL
+// on a host other than apiHost() selects that host, so a GHES repository can
+// be named by its URL alone; it is an error if --hostname names another one.
+func normalizeRepo() error {
+	if !strings.Contains(repo, ":") {
+		repo = strings.TrimSuffix(repo, ".git")
+		return nil
+	}
+
+	host, owner, name, err := parseRepoURL(repo)
+	if err != nil {
+		return err
+	}
+	if host != apiHost() {
+		if hostname != "" {
+			return fmt.Errorf("--repo %s is on %s, not on --hostname %s", repo, host, hostname)
+		}
+		hostname = host
+	}
+	repo = owner + "/" + name
+	return nil
+}
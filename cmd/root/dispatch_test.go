@@ -0,0 +1,23 @@
+package root
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDispatchFields(t *testing.T) {
+	got, err := parseDispatchFields([]string{"env=prod", "note=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("parseDispatchFields() error = %v", err)
+	}
+	want := map[string]any{"env": "prod", "note": "a=b", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDispatchFields() = %v, want %v", got, want)
+	}
+
+	for _, f := range []string{"env", "=prod"} {
+		if _, err := parseDispatchFields([]string{f}); err == nil {
+			t.Errorf("parseDispatchFields(%q) error = nil, want error", f)
+		}
+	}
+}
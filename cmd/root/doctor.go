@@ -0,0 +1,207 @@
+package root
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+// maxClockSkew is how far the local clock may be off before app JWTs are
+// rejected: their issue time is backdated by one minute.
+const maxClockSkew = time.Minute
+
+// finding is the result of one doctor check. A skipped check has neither
+// detail nor error.
+type finding struct {
+	check  string
+	detail string
+	err    error
+	hint   string
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the configuration",
+	Long: `Check the configuration step by step and report what to fix:
+
+  key           the private key can be read and is a valid RSA key, no
+                older than --max-key-age
+  host          the GitHub host is reachable
+  clock         the local clock agrees with the host's
+  app           the key belongs to --app-id (GET /app)
+  installation  the app is installed on --installation-id, --org, --repo
+                or --user, if given, and not suspended
+
+Checks that depend on a failed one are skipped. The exit status is non-zero
+if any check fails.`,
+	Example: `  gh app-token doctor --app-id 12345 --private-key app.pem --org my-org`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		findings := runDoctor(cmd.Context())
+		if err := writeFindings(cmd.OutOrStdout(), findings); err != nil {
+			return err
+		}
+
+		var failed int
+		for _, f := range findings {
+			if f.err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(findings))
+		}
+		return nil
+	},
+}
+
+// doctorChecks lists the checks in the order they run.
+var doctorChecks = []string{"key", "host", "clock", "app", "installation"}
+
+// runDoctor runs the checks up to the first failure and reports the rest as
+// skipped.
+func runDoctor(ctx context.Context) []finding {
+	findings := diagnose(ctx)
+	for _, check := range doctorChecks[len(findings):] {
+		findings = append(findings, finding{check: check})
+	}
+	return findings
+}
+
+func diagnose(ctx context.Context) []finding {
+	key := finding{check: "key"}
+	if err := validateAppFlags(); err != nil {
+		key.err = err
+		return []finding{key}
+	}
+	signer, err := loadSigner(ctx)
+	if err == nil {
+		if k, ok := signer.(*auth.PrivateKey); ok {
+			err = auth.ValidatePrivateKey(k.Reveal())
+		}
+	}
+	var fingerprint string
+	if err == nil {
+		fingerprint, err = auth.Fingerprint(signer.Public())
+	}
+	if err != nil {
+		key.err, key.hint = err, errorHint(err)
+		return []finding{key}
+	}
+	key.detail = fingerprint
+	if first, err := firstSeen(fingerprint, time.Now()); err == nil {
+		key.detail += fmt.Sprintf(", first used %s", first.Format(time.DateOnly))
+		// An old key still works, so the other checks go on
+		if w := keyAgeWarning(fingerprint, first, time.Now()); w != "" {
+			key.err, key.hint = errors.New(w), msg("hint.key_age")
+		}
+	}
+
+	host := finding{check: "host"}
+	appToken, err := newAppTokenForHost(ctx, signer, apiHost())
+	if err != nil {
+		host.err = err
+		return []finding{key, host}
+	}
+	serverTime, err := appToken.ServerTime(ctx)
+	if err != nil {
+		host.err, host.hint = err, msg("hint.unreachable", apiHost())
+		return []finding{key, host}
+	}
+	host.detail = appToken.BaseURL().String()
+
+	clock := checkClock(time.Now(), serverTime)
+	if clock.err != nil {
+		return []finding{key, host, clock}
+	}
+
+	appCheck := finding{check: "app"}
+	ghApp, err := appToken.GetApp(ctx)
+	if err != nil {
+		appCheck.err, appCheck.hint = err, errorHint(err)
+		return []finding{key, host, clock, appCheck}
+	}
+	appCheck.detail = fmt.Sprintf("%s (%s), owned by %s", ghApp.GetName(), ghApp.GetSlug(), ghApp.GetOwner().GetLogin())
+
+	return []finding{key, host, clock, appCheck, checkInstallation(ctx, appToken)}
+}
+
+// checkClock compares the local time with the host's Date header.
+func checkClock(local, server time.Time) finding {
+	f := finding{check: "clock"}
+	skew := local.Sub(server).Round(time.Second)
+	switch {
+	case skew >= maxClockSkew:
+		f.err = fmt.Errorf("local clock is %s ahead of the server", skew)
+	case skew <= -maxClockSkew:
+		f.err = fmt.Errorf("local clock is %s behind the server", -skew)
+	default:
+		f.detail = fmt.Sprintf("%s off", skew.Abs())
+		return f
+	}
+	f.hint = msg("hint.clock_skew")
+	return f
+}
+
+func checkInstallation(ctx context.Context, appToken *app.AppToken) finding {
+	f := finding{check: "installation"}
+	if installationID == 0 && org == "" && repo == "" && user == "" && enterprise == "" {
+		return f
+	}
+	if err := validateTargetFlags(); err != nil {
+		f.err = err
+		return f
+	}
+
+	id, err := resolveInstallationID(ctx, appToken)
+	var installation *github.Installation
+	if err == nil {
+		installation, err = appToken.GetInstallation(ctx, id)
+	}
+	if err != nil {
+		err = withInstallURL(ctx, appToken, err)
+		f.err, f.hint = err, errorHint(err)
+		return f
+	}
+
+	account := installation.GetAccount().GetLogin()
+	if installation.SuspendedAt != nil {
+		f.err = fmt.Errorf("installation %d on %s is suspended", id, account)
+		f.hint = msg("hint.suspended")
+		return f
+	}
+	f.detail = fmt.Sprintf("%d on %s", id, account)
+	return f
+}
+
+func writeFindings(w io.Writer, findings []finding) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range findings {
+		switch {
+		case f.err != nil:
+			fmt.Fprintf(tw, "✗ %s\t%s\n", f.check, f.err)
+			if f.hint != "" {
+				fmt.Fprintf(tw, "  \t%s: %s\n", msg("hint"), f.hint)
+			}
+		case f.detail != "":
+			fmt.Fprintf(tw, "✓ %s\t%s\n", f.check, f.detail)
+		default:
+			fmt.Fprintf(tw, "- %s\tskipped\n", f.check)
+		}
+	}
+	return tw.Flush()
+}
+
+func init() {
+	addTargetFlags(doctorCmd)
+
+	rootCmd.AddCommand(doctorCmd)
+}
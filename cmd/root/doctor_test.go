@@ -0,0 +1,74 @@
+package root
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckClock(t *testing.T) {
+	server := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		offset  time.Duration
+		wantErr string
+	}{
+		{"in sync", 2 * time.Second, ""},
+		{"ahead", 90 * time.Second, "1m30s ahead"},
+		{"behind", -5 * time.Minute, "5m0s behind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := checkClock(server.Add(tt.offset), server)
+			if tt.wantErr == "" {
+				if f.err != nil || f.detail == "" {
+					t.Errorf("checkClock() = %+v, want a passing check", f)
+				}
+				return
+			}
+			if f.err == nil || !strings.Contains(f.err.Error(), tt.wantErr) || f.hint == "" {
+				t.Errorf("checkClock() = %+v, want error containing %q and a hint", f, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteFindings(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+
+	var buf bytes.Buffer
+	err := writeFindings(&buf, []finding{
+		{check: "key", detail: "SHA256:abc"},
+		{check: "app", err: errors.New("bad credentials"), hint: "check the app ID"},
+		{check: "installation"},
+	})
+	if err != nil {
+		t.Fatalf("writeFindings() error = %v", err)
+	}
+
+	want := "✓ key           SHA256:abc\n" +
+		"✗ app           bad credentials\n" +
+		"                hint: check the app ID\n" +
+		"- installation  skipped\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeFindings() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunDoctor_skipsAfterFailure(t *testing.T) {
+	appID = 0
+	findings := runDoctor(t.Context())
+	if len(findings) != len(doctorChecks) {
+		t.Fatalf("runDoctor() returned %d findings, want %d", len(findings), len(doctorChecks))
+	}
+	if findings[0].err == nil {
+		t.Error("key check passed without an app ID")
+	}
+	for _, f := range findings[1:] {
+		if f.err != nil || f.detail != "" {
+			t.Errorf("%s = %+v, want skipped", f.check, f)
+		}
+	}
+}
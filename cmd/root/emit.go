@@ -0,0 +1,174 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	emitCurlrcPath  string
+	emitGhHostsPath string
+	execCurlrc      bool
+	execGhHosts     bool
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec -- <command> [<args>...]",
+	Short: "Run a command with the token in temporary config files",
+	Long: `Mint an installation token, write it to temporary config files for tools that
+read credentials only from files, and run the command with the environment
+pointed at them:
+
+  --curlrc    a .curlrc with an Authorization header; CURL_HOME is set
+  --gh-hosts  a gh hosts.yml for the host; GH_CONFIG_DIR is set and token
+              variables that would take precedence are removed
+
+The files are deleted and the token is revoked when the command exits, and
+its exit status is passed through. To run gh itself, the gh command is
+simpler.`,
+	Example: `  gh app-token exec --app-id 12345 --private-key app.pem --org my-org --curlrc -- curl https://api.github.com/orgs/my-org/repos
+  gh app-token exec --app-id 12345 --private-key app.pem --org my-org --gh-hosts -- ./release.sh`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !execCurlrc && !execGhHosts {
+			return fmt.Errorf("--curlrc or --gh-hosts is required")
+		}
+		if err := validateFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		token, err := getToken(cmd.Context(), newProgress(), appToken, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get token: %w", withInstallURL(cmd.Context(), appToken, err))
+		}
+		defer revokeToken(cmd.Context(), appToken, token.Token)
+
+		dir, err := os.MkdirTemp("", "gh-app-token-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		env, err := emitTemporary(dir, os.Environ(), apiHost(), token.Token)
+		if err != nil {
+			return err
+		}
+
+		child := exec.CommandContext(cmd.Context(), args[0], args[1:]...)
+		child.Env = env
+		child.Stdin = os.Stdin
+		child.Stdout = cmd.OutOrStdout()
+		child.Stderr = cmd.ErrOrStderr()
+
+		err = child.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &exitError{code: exitErr.ExitCode()}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run %s: %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+// emitTemporary writes the config files selected for exec into dir and
+// returns environ pointed at them.
+func emitTemporary(dir string, environ []string, host, token string) ([]string, error) {
+	env := environ
+	if execCurlrc {
+		if err := os.WriteFile(filepath.Join(dir, ".curlrc"), curlrc(token), 0o600); err != nil {
+			return nil, err
+		}
+		env = append(env, "CURL_HOME="+dir)
+	}
+	if execGhHosts {
+		ghDir := filepath.Join(dir, "gh")
+		data, err := ghHosts(host, token)
+		if err == nil {
+			err = os.Mkdir(ghDir, 0o700)
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(ghDir, "hosts.yml"), data, 0o600)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The token variables would take precedence over hosts.yml
+		env = append(withoutGhTokens(env), "GH_CONFIG_DIR="+ghDir, "GH_HOST="+host)
+	}
+	return env, nil
+}
+
+// withoutGhTokens returns environ without the variables gh reads a token or
+// host from.
+func withoutGhTokens(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		switch name, _, _ := strings.Cut(kv, "="); name {
+		case "GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GH_HOST", "GH_CONFIG_DIR":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// writeEmitters writes the files requested with --emit-curlrc and
+// --emit-gh-hosts. They are left in place; removing them is up to the
+// caller.
+func writeEmitters(host, token string) error {
+	if emitCurlrcPath != "" {
+		if err := writeFileAtomic(emitCurlrcPath, curlrc(token), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", emitCurlrcPath, err)
+		}
+	}
+	if emitGhHostsPath != "" {
+		data, err := ghHosts(host, token)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(emitGhHostsPath, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", emitGhHostsPath, err)
+		}
+	}
+	return nil
+}
+
+// curlrc returns a curl config that sends the token with every request.
+// curl has no per-host headers, so the file must only be used for requests
+// to GitHub.
+func curlrc(token string) []byte {
+	return []byte("header = " + strconv.Quote("Authorization: Bearer "+token) + "\n")
+}
+
+// ghHosts returns a gh hosts.yml that authenticates host with the token.
+func ghHosts(host, token string) ([]byte, error) {
+	return yaml.Marshal(map[string]map[string]string{
+		host: {
+			"oauth_token":  token,
+			"user":         gitUsername,
+			"git_protocol": "https",
+		},
+	})
+}
+
+func init() {
+	addTargetFlags(execCmd)
+	execCmd.Flags().BoolVar(&execCurlrc, "curlrc", false, "Provide the token in a temporary .curlrc (CURL_HOME)")
+	execCmd.Flags().BoolVar(&execGhHosts, "gh-hosts", false, "Provide the token in a temporary gh hosts.yml (GH_CONFIG_DIR)")
+
+	rootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,74 @@
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCurlrc(t *testing.T) {
+	want := `header = "Authorization: Bearer ghs_secret"` + "\n"
+	if got := string(curlrc("ghs_secret")); got != want {
+		t.Errorf("curlrc() = %q, want %q", got, want)
+	}
+}
+
+func TestGhHosts(t *testing.T) {
+	data, err := ghHosts("ghe.example.com", "ghs_secret")
+	if err != nil {
+		t.Fatalf("ghHosts() error = %v", err)
+	}
+	var hosts map[string]map[string]string
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		t.Fatalf("Failed to parse hosts.yml: %v", err)
+	}
+	if got := hosts["ghe.example.com"]["oauth_token"]; got != "ghs_secret" {
+		t.Errorf("oauth_token = %q, want ghs_secret in:\n%s", got, data)
+	}
+}
+
+func TestEmitTemporary(t *testing.T) {
+	execCurlrc, execGhHosts = true, true
+	defer func() { execCurlrc, execGhHosts = false, false }()
+
+	dir := t.TempDir()
+	environ := []string{"PATH=/usr/bin", "GH_TOKEN=ghp_personal", "GH_CONFIG_DIR=/home/me/.config/gh"}
+	env, err := emitTemporary(dir, environ, "ghe.example.com", "ghs_secret")
+	if err != nil {
+		t.Fatalf("emitTemporary() error = %v", err)
+	}
+
+	want := []string{"PATH=/usr/bin", "CURL_HOME=" + dir, "GH_CONFIG_DIR=" + filepath.Join(dir, "gh"), "GH_HOST=ghe.example.com"}
+	if !slices.Equal(env, want) {
+		t.Errorf("emitTemporary() env = %v, want %v", env, want)
+	}
+	for _, name := range []string{".curlrc", filepath.Join("gh", "hosts.yml")} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s was not written: %v", name, err)
+			continue
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %v, want 0600", name, fi.Mode().Perm())
+		}
+	}
+}
+
+func TestWriteEmitters(t *testing.T) {
+	dir := t.TempDir()
+	emitCurlrcPath = filepath.Join(dir, "curlrc")
+	emitGhHostsPath = filepath.Join(dir, "hosts.yml")
+	defer func() { emitCurlrcPath, emitGhHostsPath = "", "" }()
+
+	if err := writeEmitters("github.com", "ghs_secret"); err != nil {
+		t.Fatalf("writeEmitters() error = %v", err)
+	}
+	for _, path := range []string{emitCurlrcPath, emitGhHostsPath} {
+		if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+			t.Errorf("%s = %v, %v, want a 0600 file", path, fi, err)
+		}
+	}
+}
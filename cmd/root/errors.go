@@ -0,0 +1,61 @@
+package root
+
+import (
+	"context"
+	"errors"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// notInstalledError carries the URL where the app can be installed on the
+// account that was looked up.
+type notInstalledError struct {
+	error
+	installURL string
+}
+
+func (e *notInstalledError) Unwrap() error {
+	return e.error
+}
+
+// withInstallURL annotates a discovery failure caused by the app not being
+// installed with the app's installation page, resolved via GET /app.
+func withInstallURL(ctx context.Context, appToken *app.AppToken, err error) error {
+	if !errors.Is(err, app.ErrAppNotInstalled) {
+		return err
+	}
+
+	u, urlErr := appToken.InstallationURL(ctx)
+	if urlErr != nil {
+		return err
+	}
+	return &notInstalledError{error: err, installURL: u}
+}
+
+// errorHint suggests a fix for well-known failure classes, in the language
+// selected by --lang or the locale.
+func errorHint(err error) string {
+	var notInstalled *notInstalledError
+	if errors.As(err, &notInstalled) {
+		return msg("hint.install_app", notInstalled.installURL)
+	}
+
+	switch {
+	case errors.Is(err, app.ErrBadCredentials):
+		return msg("hint.bad_credentials")
+	case errors.Is(err, app.ErrAppNotInstalled):
+		return msg("hint.app_not_installed")
+	case errors.Is(err, app.ErrInstallationNotFound):
+		return msg("hint.installation_not_found")
+	case errors.Is(err, app.ErrRateLimited):
+		return msg("hint.rate_limited")
+	case errors.Is(err, app.ErrMaintenance):
+		return msg("hint.maintenance")
+	case errors.Is(err, auth.ErrIncorrectPassphrase):
+		return msg("hint.incorrect_passphrase")
+	case errors.Is(err, auth.ErrInvalidKey):
+		return msg("hint.invalid_key")
+	}
+	return ""
+}
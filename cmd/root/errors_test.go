@@ -0,0 +1,25 @@
+package root
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func TestErrorHint(t *testing.T) {
+	if hint := errorHint(fmt.Errorf("failed to get token: %w", app.ErrBadCredentials)); hint == "" {
+		t.Error("errorHint() = \"\", want a hint for bad credentials")
+	}
+	notInstalled := &notInstalledError{error: app.ErrAppNotInstalled, installURL: "https://github.com/apps/bot/installations/new"}
+	if hint := errorHint(fmt.Errorf("failed to get token: %w", notInstalled)); !strings.Contains(hint, notInstalled.installURL) {
+		t.Errorf("errorHint() = %q, want it to contain %q", hint, notInstalled.installURL)
+	}
+	if hint := errorHint(fmt.Errorf("failed to get token: %w", app.ErrMaintenance)); !strings.Contains(hint, "maintenance") {
+		t.Errorf("errorHint() = %q, want a hint for maintenance mode", hint)
+	}
+	if hint := errorHint(fmt.Errorf("something else")); hint != "" {
+		t.Errorf("errorHint() = %q, want no hint", hint)
+	}
+}
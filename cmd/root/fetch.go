@@ -0,0 +1,60 @@
+package root
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fetchPath   string
+	fetchRef    string
+	fetchOutput string
+)
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Download a file from a repository",
+	Long: `Download a file of --repo through the contents API, e.g. a bootstrap script
+in a private repository on a host that has nothing but this binary and the
+app key. A token limited to contents:read on the repository is minted for the
+request and revoked afterwards.
+
+The file is written to stdout, or with -o to a file (mode 0600).`,
+	Example: `  gh app-token fetch --app-id 12345 --private-key app.pem --repo owner/repo --path scripts/bootstrap.sh --ref main -o bootstrap.sh`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appToken, id, owner, name, err := newRepoAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		data, err := appToken.FetchContent(cmd.Context(), id, owner, name, fetchPath, fetchRef)
+		if err != nil {
+			return err
+		}
+
+		if fetchOutput == "" || fetchOutput == "-" {
+			_, err := cmd.OutOrStdout().Write(data)
+			return err
+		}
+		if err := writeFileAtomic(fetchOutput, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", fetchOutput, err)
+		}
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ wrote %s (%d bytes)\n", fetchOutput, len(data))
+		}
+		return nil
+	},
+}
+
+func init() {
+	addRepoFlag(fetchCmd)
+	fetchCmd.Flags().StringVar(&fetchPath, "path", "", "Path of the file in the repository")
+	fetchCmd.Flags().StringVar(&fetchRef, "ref", "", "Branch, tag or commit to read from (default: the default branch)")
+	fetchCmd.Flags().StringVarP(&fetchOutput, "output", "o", "", "Write to this file instead of stdout")
+	_ = fetchCmd.MarkFlagRequired("path")
+	fetchCmd.Flags().SortFlags = false
+
+	rootCmd.AddCommand(fetchCmd)
+}
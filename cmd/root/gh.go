@@ -0,0 +1,100 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// exitError makes Execute exit with code without printing anything, for
+// commands that pass through the status of a child process.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+var ghCmd = &cobra.Command{
+	Use:   "gh -- <gh args>",
+	Short: "Run the gh CLI with an installation token",
+	Long: `Mint an installation token for --installation-id, --org, --repo or --user and
+run gh with it in GH_TOKEN (GH_ENTERPRISE_TOKEN on GitHub Enterprise Server),
+so existing gh commands run as the app. The token is revoked when gh exits,
+and gh's exit status is passed through.
+
+Put the gh arguments after --, so that their flags are not parsed here.`,
+	Example: `  gh app-token gh --app-id 12345 --private-key app.pem --org my-org -- repo list my-org
+  gh app-token gh --app-id 12345 --private-key app.pem --repo owner/repo -- pr create --fill`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		token, err := getToken(cmd.Context(), newProgress(), appToken, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get token: %w", withInstallURL(cmd.Context(), appToken, err))
+		}
+		defer revokeToken(cmd.Context(), appToken, token.Token)
+
+		gh := exec.CommandContext(cmd.Context(), ghPath(), args...)
+		gh.Env = ghEnv(os.Environ(), apiHost(), token.Token)
+		gh.Stdin = os.Stdin
+		gh.Stdout = cmd.OutOrStdout()
+		gh.Stderr = cmd.ErrOrStderr()
+
+		err = gh.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &exitError{code: exitErr.ExitCode()}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run gh: %w", err)
+		}
+		return nil
+	},
+}
+
+// ghPath returns the gh executable. gh exports GH_PATH to extensions, so the
+// same binary runs even when it is not on PATH.
+func ghPath() string {
+	if path := os.Getenv("GH_PATH"); path != "" {
+		return path
+	}
+	return "gh"
+}
+
+// ghEnv returns environ with the token in the variable gh reads for host.
+// Tokens that would take precedence over it are removed.
+func ghEnv(environ []string, host, token string) []string {
+	env := make([]string, 0, len(environ)+2)
+	for _, kv := range environ {
+		switch name, _, _ := strings.Cut(kv, "="); name {
+		case "GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GH_HOST":
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	env = append(env, "GH_HOST="+host)
+	if host == defaultHost {
+		return append(env, "GH_TOKEN="+token)
+	}
+	return append(env, "GH_ENTERPRISE_TOKEN="+token)
+}
+
+func init() {
+	addTargetFlags(ghCmd)
+
+	rootCmd.AddCommand(ghCmd)
+}
@@ -0,0 +1,22 @@
+package root
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGhEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "GH_TOKEN=ghp_personal", "GITHUB_TOKEN=ghs_actions", "GH_HOST=old.example.com"}
+
+	got := ghEnv(environ, "github.com", "ghs_app")
+	want := []string{"PATH=/usr/bin", "GH_HOST=github.com", "GH_TOKEN=ghs_app"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ghEnv() = %v, want %v", got, want)
+	}
+
+	got = ghEnv(environ, "ghe.example.com", "ghs_app")
+	want = []string{"PATH=/usr/bin", "GH_HOST=ghe.example.com", "GH_ENTERPRISE_TOKEN=ghs_app"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ghEnv() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,259 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deliveriesLimit  int
+	deliveriesFailed bool
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the App webhook",
+	Long: `Manage the App webhook.
+
+These commands authenticate with the App JWT, so no installation target is needed.`,
+}
+
+var deliveriesCmd = &cobra.Command{
+	Use:   "deliveries",
+	Short: "Inspect and redeliver App webhook deliveries",
+}
+
+var deliveriesListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List recent webhook deliveries",
+	Example: `  gh app-token hook deliveries list --app-id 12345 --private-key app.pem --failed`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		deliveries, err := appToken.ListHookDeliveries(cmd.Context(), deliveriesLimit)
+		if err != nil {
+			return err
+		}
+		if deliveriesFailed {
+			deliveries = failedDeliveries(deliveries)
+		}
+
+		return writeDeliveries(cmd.OutOrStdout(), deliveries)
+	},
+}
+
+var deliveriesRedeliverCmd = &cobra.Command{
+	Use:     "redeliver <delivery-id>...",
+	Short:   "Redeliver webhook deliveries",
+	Example: `  gh app-token hook deliveries redeliver --app-id 12345 --private-key app.pem 1234567890`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(args))
+		for _, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid delivery ID %q", arg)
+			}
+			ids = append(ids, id)
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := appToken.RedeliverHookDelivery(cmd.Context(), id); err != nil {
+				return err
+			}
+			if !quiet {
+				fmt.Fprintf(cmd.ErrOrStderr(), "✓ redelivery of %d requested\n", id)
+			}
+		}
+		return nil
+	},
+}
+
+var (
+	hookURL         string
+	hookSecret      string
+	hookContentType string
+	hookInsecureSSL bool
+)
+
+var hookConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change the App webhook configuration",
+}
+
+var hookConfigGetCmd = &cobra.Command{
+	Use:     "get",
+	Short:   "Show the webhook URL, content type and TLS verification setting",
+	Example: `  gh app-token hook config get --app-id 12345 --private-key app.pem`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		config, err := appToken.GetHookConfig(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		return writeHookConfig(cmd.OutOrStdout(), config)
+	},
+}
+
+var hookConfigSetCmd = &cobra.Command{
+	Use:     "set",
+	Aliases: []string{"update"},
+	Short:   "Change the webhook URL, secret, content type or TLS verification",
+	Long: `Change the App webhook configuration. Only the settings given as flags are
+changed; the others keep their current value.
+
+Pass an empty --secret to remove the secret.`,
+	Example: `  gh app-token hook config set --app-id 12345 --private-key app.pem --url https://hooks.example.com/github --secret "$WEBHOOK_SECRET"`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		config, err := hookConfigFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		updated, err := appToken.UpdateHookConfig(cmd.Context(), config)
+		if err != nil {
+			return err
+		}
+
+		if quiet {
+			return nil
+		}
+		return writeHookConfig(cmd.OutOrStdout(), updated)
+	},
+}
+
+// hookConfigFromFlags builds the update from the flags that were given, so
+// omitted settings are left untouched.
+func hookConfigFromFlags(cmd *cobra.Command) (*github.HookConfig, error) {
+	flags := cmd.Flags()
+	config := &github.HookConfig{}
+	if flags.Changed("url") {
+		config.URL = github.Ptr(hookURL)
+	}
+	if flags.Changed("secret") {
+		config.Secret = github.Ptr(hookSecret)
+	}
+	if flags.Changed("content-type") {
+		if hookContentType != "json" && hookContentType != "form" {
+			return nil, fmt.Errorf("--content-type must be json or form")
+		}
+		config.ContentType = github.Ptr(hookContentType)
+	}
+	if flags.Changed("insecure-ssl") {
+		config.InsecureSSL = github.Ptr("0")
+		if hookInsecureSSL {
+			config.InsecureSSL = github.Ptr("1")
+		}
+	}
+
+	if *config == (github.HookConfig{}) {
+		return nil, fmt.Errorf("nothing to change: give at least one of --url, --secret, --content-type or --insecure-ssl")
+	}
+	return config, nil
+}
+
+func writeHookConfig(w io.Writer, config *github.HookConfig) error {
+	verify := "yes"
+	if config.GetInsecureSSL() == "1" {
+		verify = "no"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "URL:\t%s\n", orNone(config.GetURL()))
+	fmt.Fprintf(tw, "Content type:\t%s\n", orNone(config.GetContentType()))
+	fmt.Fprintf(tw, "Secret:\t%s\n", orNone(config.GetSecret()))
+	fmt.Fprintf(tw, "Verify TLS:\t%s\n", verify)
+	return tw.Flush()
+}
+
+// failedDeliveries keeps the deliveries that did not get a 2xx response,
+// including those that timed out without any status code.
+func failedDeliveries(deliveries []*github.HookDelivery) []*github.HookDelivery {
+	var failed []*github.HookDelivery
+	for _, d := range deliveries {
+		if code := d.GetStatusCode(); code < 200 || code > 299 {
+			failed = append(failed, d)
+		}
+	}
+	return failed
+}
+
+func writeDeliveries(w io.Writer, deliveries []*github.HookDelivery) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDELIVERED AT\tSTATUS\tEVENT\tREDELIVERY\tGUID")
+	for _, d := range deliveries {
+		event := d.GetEvent()
+		if action := d.GetAction(); action != "" {
+			event += "." + action
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d %s\t%s\t%t\t%s\n",
+			d.GetID(),
+			d.GetDeliveredAt().Format(time.RFC3339),
+			d.GetStatusCode(),
+			d.GetStatus(),
+			event,
+			d.GetRedelivery(),
+			d.GetGUID(),
+		)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	deliveriesListCmd.Flags().IntVarP(&deliveriesLimit, "limit", "L", 30, "Maximum number of deliveries to fetch")
+	deliveriesListCmd.Flags().BoolVar(&deliveriesFailed, "failed", false, "Only show deliveries that did not get a 2xx response")
+
+	hookConfigSetCmd.Flags().StringVar(&hookURL, "url", "", "URL that webhook deliveries are sent to")
+	hookConfigSetCmd.Flags().StringVar(&hookSecret, "secret", "", "Secret used to sign webhook payloads")
+	hookConfigSetCmd.Flags().StringVar(&hookContentType, "content-type", "", "Payload format: json or form")
+	hookConfigSetCmd.Flags().BoolVar(&hookInsecureSSL, "insecure-ssl", false, "Skip TLS certificate verification of the webhook URL")
+	hookConfigSetCmd.Flags().SortFlags = false
+
+	deliveriesCmd.AddCommand(deliveriesListCmd, deliveriesRedeliverCmd)
+	hookConfigCmd.AddCommand(hookConfigGetCmd, hookConfigSetCmd)
+	hookCmd.AddCommand(deliveriesCmd, hookConfigCmd)
+	rootCmd.AddCommand(hookCmd)
+}
@@ -0,0 +1,117 @@
+package root
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+func TestFailedDeliveries(t *testing.T) {
+	deliveries := []*github.HookDelivery{
+		{ID: github.Ptr(int64(1)), StatusCode: github.Ptr(200)},
+		{ID: github.Ptr(int64(2)), StatusCode: github.Ptr(502)},
+		{ID: github.Ptr(int64(3)), StatusCode: github.Ptr(0)},
+		{ID: github.Ptr(int64(4)), StatusCode: github.Ptr(204)},
+	}
+
+	got := failedDeliveries(deliveries)
+	if len(got) != 2 || got[0].GetID() != 2 || got[1].GetID() != 3 {
+		t.Errorf("failedDeliveries() = %v, want deliveries 2 and 3", got)
+	}
+}
+
+func TestWriteDeliveries(t *testing.T) {
+	deliveredAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	deliveries := []*github.HookDelivery{
+		{
+			ID:          github.Ptr(int64(12345)),
+			GUID:        github.Ptr("0b989ba4-242f-11e5-81e1-c7b6966d2516"),
+			DeliveredAt: &github.Timestamp{Time: deliveredAt},
+			Status:      github.Ptr("OK"),
+			StatusCode:  github.Ptr(200),
+			Event:       github.Ptr("issues"),
+			Action:      github.Ptr("opened"),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeDeliveries(&buf, deliveries); err != nil {
+		t.Fatalf("writeDeliveries() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("writeDeliveries() wrote %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	for _, want := range []string{"12345", "2025-01-02T03:04:05Z", "200 OK", "issues.opened", "false", "0b989ba4"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("row %q does not contain %q", lines[1], want)
+		}
+	}
+}
+
+func TestHookConfigFromFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"url only", []string{"--url", "https://example.com/hook"}, `{"url":"https://example.com/hook"}`, false},
+		{"clear secret", []string{"--secret", ""}, `{"secret":""}`, false},
+		{"insecure ssl", []string{"--insecure-ssl"}, `{"insecure_ssl":"1"}`, false},
+		{"verify ssl", []string{"--insecure-ssl=false", "--content-type", "json"}, `{"content_type":"json","insecure_ssl":"0"}`, false},
+		{"unknown content type", []string{"--content-type", "xml"}, "", true},
+		{"nothing", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVar(&hookURL, "url", "", "")
+			cmd.Flags().StringVar(&hookSecret, "secret", "", "")
+			cmd.Flags().StringVar(&hookContentType, "content-type", "", "")
+			cmd.Flags().BoolVar(&hookInsecureSSL, "insecure-ssl", false, "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			config, err := hookConfigFromFlags(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hookConfigFromFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := json.Marshal(config)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("hookConfigFromFlags() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHookConfig(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeHookConfig(&buf, &github.HookConfig{
+		URL:         github.Ptr("https://example.com/hook"),
+		ContentType: github.Ptr("json"),
+		InsecureSSL: github.Ptr("1"),
+	})
+	if err != nil {
+		t.Fatalf("writeHookConfig() error = %v", err)
+	}
+
+	got := strings.Join(strings.Fields(buf.String()), " ")
+	want := "URL: https://example.com/hook Content type: json Secret: (none) Verify TLS: no"
+	if got != want {
+		t.Errorf("writeHookConfig() = %q, want %q", got, want)
+	}
+}
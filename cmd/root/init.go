@@ -0,0 +1,280 @@
+package root
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Answers to the target question of init.
+const (
+	targetNone           = "none"
+	targetOrg            = "org"
+	targetRepo           = "repo"
+	targetUser           = "user"
+	targetEnterprise     = "enterprise"
+	targetInstallationID = "installation-id"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a config file interactively",
+	Long: `Ask for the app ID, private key, host and default installation target, and
+write them to the config file, so that later invocations need no flags.
+Current values are offered as defaults, so init can also edit the file.
+With --profile, the answers are stored as that named profile and the other
+settings of the file are kept.
+
+The file is config.yml in $XDG_CONFIG_HOME/gh-app-token (default
+~/.config/gh-app-token), or GH_APP_TOKEN_CONFIG. Flags and environment
+variables always take precedence over it.`,
+	Example: `  gh app-token init
+  gh app-token            # mints a token for the default target
+  gh app-token init --profile prod-bot
+  gh app-token --profile prod-bot`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("init needs an interactive terminal")
+		}
+
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		file, err := loadConfigFile(path)
+		if err != nil {
+			return err
+		}
+		name := selectedProfile()
+		current, err := file.profile(name)
+		if err != nil {
+			// A new profile starts empty
+			current = &configFile{}
+		}
+
+		cfg, err := promptConfig(cmd.InOrStdin(), cmd.ErrOrStderr(), current)
+		if err != nil {
+			return err
+		}
+		setProfile(file, name, cfg)
+		if err := file.save(path); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		if !quiet {
+			if name != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "✓ wrote profile %s to %s\n", name, path)
+			} else {
+				fmt.Fprintf(cmd.ErrOrStderr(), "✓ wrote %s\n", path)
+			}
+		}
+		return nil
+	},
+}
+
+// setProfile replaces the named profile of file, or its top-level settings
+// for "", with cfg.
+func setProfile(file *configFile, name string, cfg *configFile) {
+	if name != "" {
+		if file.Profiles == nil {
+			file.Profiles = map[string]*configFile{}
+		}
+		file.Profiles[name] = cfg
+		return
+	}
+	cfg.Profiles = file.Profiles
+	*file = *cfg
+}
+
+// prompter asks questions on w and reads the answers from r.
+type prompter struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+// ask returns the answer to question, or def for an empty answer.
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.w, "? %s [%s] ", question, def)
+	} else {
+		fmt.Fprintf(p.w, "? %s ", question)
+	}
+
+	answer, err := p.r.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// askUntil repeats question until check accepts the answer.
+func (p *prompter) askUntil(question, def string, check func(string) error) (string, error) {
+	for {
+		answer, err := p.ask(question, def)
+		if err != nil {
+			return "", err
+		}
+		if err := check(answer); err != nil {
+			fmt.Fprintf(p.w, "X %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// promptConfig asks for every setting of the config file, offering current
+// as defaults.
+func promptConfig(r io.Reader, w io.Writer, current *configFile) (*configFile, error) {
+	p := &prompter{r: bufio.NewReader(r), w: w}
+	cfg := &configFile{}
+
+	var def string
+	if current.AppID != 0 {
+		def = strconv.FormatInt(current.AppID, 10)
+	}
+	answer, err := p.askUntil("App ID:", def, func(s string) error {
+		if id, err := strconv.ParseInt(s, 10, 64); err != nil || id <= 0 {
+			return fmt.Errorf("the app ID must be a positive number")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg.AppID, _ = strconv.ParseInt(answer, 10, 64)
+
+	cfg.PrivateKey, err = p.askUntil("Private key (file path or key URI):", current.PrivateKey, checkKeyLocation)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(cfg.PrivateKey, "://") {
+		// Relative paths would depend on the working directory
+		if cfg.PrivateKey, err = filepath.Abs(cfg.PrivateKey); err != nil {
+			return nil, err
+		}
+	}
+
+	def = current.Host
+	if def == "" {
+		def = ghDefaultHost()
+	}
+	host, err := p.ask("GitHub host:", def)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "https://"), "/")
+	// github.com is only implied while gh defaults to it too
+	if host != defaultHost || host != ghDefaultHost() {
+		cfg.Host = host
+	}
+
+	kinds := []string{targetNone, targetOrg, targetRepo, targetUser, targetEnterprise, targetInstallationID}
+	kind, value := currentTarget(current)
+	kind, err = p.askUntil("Default target ("+strings.Join(kinds, ", ")+"):", kind, func(s string) error {
+		if slices.Contains(kinds, s) {
+			return nil
+		}
+		return fmt.Errorf("choose one of %s", strings.Join(kinds, ", "))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if kind == targetNone {
+		return cfg, nil
+	}
+
+	_, err = p.askUntil(targetQuestion(kind), value, func(s string) error {
+		return setTarget(cfg, kind, s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func checkKeyLocation(s string) error {
+	if s == "" {
+		return fmt.Errorf("a private key is required")
+	}
+	if strings.Contains(s, "://") {
+		return nil
+	}
+	if _, err := os.Stat(s); err != nil {
+		return fmt.Errorf("cannot read %s: %w", s, err)
+	}
+	return nil
+}
+
+// currentTarget returns the kind and value of the target in cfg.
+func currentTarget(cfg *configFile) (kind, value string) {
+	switch {
+	case cfg.Org != "":
+		return targetOrg, cfg.Org
+	case cfg.Repo != "":
+		return targetRepo, cfg.Repo
+	case cfg.User != "":
+		return targetUser, cfg.User
+	case cfg.Enterprise != "":
+		return targetEnterprise, cfg.Enterprise
+	case cfg.InstallationID != 0:
+		return targetInstallationID, strconv.FormatInt(cfg.InstallationID, 10)
+	}
+	return targetNone, ""
+}
+
+func targetQuestion(kind string) string {
+	switch kind {
+	case targetOrg:
+		return "Organization:"
+	case targetRepo:
+		return "Repository (owner/repo):"
+	case targetUser:
+		return "User:"
+	case targetEnterprise:
+		return "Enterprise (slug):"
+	}
+	return "Installation ID:"
+}
+
+// setTarget validates value and stores it as the target of the given kind.
+func setTarget(cfg *configFile, kind, value string) error {
+	if value == "" {
+		return fmt.Errorf("a value is required")
+	}
+
+	switch kind {
+	case targetOrg:
+		cfg.Org = value
+	case targetRepo:
+		if _, _, err := splitRepo(value); err != nil {
+			return err
+		}
+		cfg.Repo = value
+	case targetUser:
+		cfg.User = value
+	case targetEnterprise:
+		cfg.Enterprise = value
+	case targetInstallationID:
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("the installation ID must be a positive number")
+		}
+		cfg.InstallationID = id
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,65 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPromptConfig(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(key, []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid answers are asked again
+	in := strings.Join([]string{
+		"abc", "12345",
+		"/does/not/exist", key,
+		"https://ghe.example.com/",
+		"team", "repo",
+		"no-slash", "owner/repo",
+	}, "\n") + "\n"
+	var out bytes.Buffer
+	got, err := promptConfig(strings.NewReader(in), &out, &configFile{})
+	if err != nil {
+		t.Fatalf("promptConfig() error = %v", err)
+	}
+
+	want := &configFile{AppID: 12345, PrivateKey: key, Host: "ghe.example.com", Repo: "owner/repo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("promptConfig() = %+v, want %+v", got, want)
+	}
+	if n := strings.Count(out.String(), "X "); n != 4 {
+		t.Errorf("got %d retries, want 4:\n%s", n, out.String())
+	}
+}
+
+func TestPromptConfig_defaults(t *testing.T) {
+	current := &configFile{AppID: 1, PrivateKey: "awskms://alias/app", Org: "acme"}
+
+	got, err := promptConfig(strings.NewReader("\n\n\n\n\n"), &bytes.Buffer{}, current)
+	if err != nil {
+		t.Fatalf("promptConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, current) {
+		t.Errorf("promptConfig() = %+v, want the current config %+v", got, current)
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	file := &configFile{AppID: 1, Profiles: map[string]*configFile{"old": {AppID: 2}}}
+
+	setProfile(file, "new", &configFile{AppID: 3})
+	if file.AppID != 1 || file.Profiles["old"].AppID != 2 || file.Profiles["new"].AppID != 3 {
+		t.Errorf("setProfile() with a name = %+v, want a profile added", file)
+	}
+
+	setProfile(file, "", &configFile{AppID: 4})
+	if file.AppID != 4 || len(file.Profiles) != 2 {
+		t.Errorf("setProfile() without a name = %+v, want the top level replaced and the profiles kept", file)
+	}
+}
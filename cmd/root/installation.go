@@ -0,0 +1,238 @@
+package root
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+var installationCmd = &cobra.Command{
+	Use:   "installation",
+	Short: "Inspect and manage a single installation of the app",
+}
+
+var installationGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show an installation without minting a token",
+	Long: `Show the account, permissions, repository selection, events and suspended
+state of the installation selected by --installation-id, --org, --repo or
+--user. Only the app JWT is used; no installation token is minted.`,
+	Example: `  gh app-token installation get --app-id 12345 --private-key app.pem --org my-org`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		id, err := resolveInstallationID(cmd.Context(), appToken)
+		if err != nil {
+			return withInstallURL(cmd.Context(), appToken, err)
+		}
+
+		installation, err := appToken.GetInstallation(cmd.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return writeInstallation(cmd.OutOrStdout(), installation)
+	},
+}
+
+var installationIDCmd = &cobra.Command{
+	Use:   "id",
+	Short: "Print the ID of an installation without minting a token",
+	Long: `Look up the installation for --org, --repo, --user or --enterprise and print
+only its ID, e.g. to store it in a CI variable so that later runs can pass
+--installation-id and skip the lookup. Only the app JWT is used; no
+installation token is minted.`,
+	Example: `  gh app-token installation id --app-id 12345 --private-key app.pem --org my-org`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		id, err := resolveInstallationID(cmd.Context(), appToken)
+		if err != nil {
+			return withInstallURL(cmd.Context(), appToken, err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), id)
+		return nil
+	},
+}
+
+var installationSuspendCmd = &cobra.Command{
+	Use:   "suspend",
+	Short: "Suspend an installation",
+	Long: `Suspend the installation so that it can no longer access the account's
+resources, e.g. while responding to an incident. Existing tokens stop working
+and no new ones can be minted until it is unsuspended.`,
+	Example: `  gh app-token installation suspend --app-id 12345 --private-key app.pem --installation-id 67890`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeSuspension(cmd, true)
+	},
+}
+
+var installationUnsuspendCmd = &cobra.Command{
+	Use:     "unsuspend",
+	Short:   "Unsuspend an installation",
+	Example: `  gh app-token installation unsuspend --app-id 12345 --private-key app.pem --installation-id 67890`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeSuspension(cmd, false)
+	},
+}
+
+var deleteYes bool
+
+var installationDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Uninstall the app from an account",
+	Long: `Uninstall the app from the account of the installation, e.g. to clean up
+ephemeral test organizations. Existing tokens stop working immediately and the
+installation cannot be restored; the account has to install the app again.
+
+Asks for confirmation on an interactive terminal; pass --yes to skip it in
+scripts.`,
+	Example: `  gh app-token installation delete --app-id 12345 --private-key app.pem --installation-id 67890 --yes`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFlags(); err != nil {
+			return err
+		}
+		if !deleteYes && !isTerminal(os.Stdin) {
+			return fmt.Errorf("--yes is required when not running interactively")
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		id, err := resolveInstallationID(cmd.Context(), appToken)
+		if err != nil {
+			return withInstallURL(cmd.Context(), appToken, err)
+		}
+
+		if !deleteYes {
+			installation, err := appToken.GetInstallation(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			ok, err := confirm(cmd.InOrStdin(), cmd.ErrOrStderr(),
+				fmt.Sprintf("Uninstall the app from %s (installation %d)?", installation.GetAccount().GetLogin(), id))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("aborted")
+			}
+		}
+
+		if err := appToken.DeleteInstallation(cmd.Context(), id); err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ installation %d deleted\n", id)
+		}
+		return nil
+	},
+}
+
+// confirm asks a yes/no question on w and reads the answer from r. Anything
+// but y or yes is a no.
+func confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func changeSuspension(cmd *cobra.Command, suspend bool) error {
+	if err := validateFlags(); err != nil {
+		return err
+	}
+
+	appToken, _, err := newAppToken(cmd.Context())
+	if err != nil {
+		return err
+	}
+	id, err := resolveInstallationID(cmd.Context(), appToken)
+	if err != nil {
+		return withInstallURL(cmd.Context(), appToken, err)
+	}
+
+	verb := "suspended"
+	if suspend {
+		err = appToken.SuspendInstallation(cmd.Context(), id)
+	} else {
+		verb = "unsuspended"
+		err = appToken.UnsuspendInstallation(cmd.Context(), id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Fprintf(cmd.ErrOrStderr(), "✓ installation %d %s\n", id, verb)
+	}
+	return nil
+}
+
+func writeInstallation(w io.Writer, inst *github.Installation) error {
+	perms, err := appPermissions(inst.GetPermissions())
+	if err != nil {
+		return err
+	}
+
+	suspended := "no"
+	if inst.SuspendedAt != nil {
+		suspended = "since " + inst.GetSuspendedAt().Format(time.RFC3339)
+		if by := inst.GetSuspendedBy().GetLogin(); by != "" {
+			suspended += " by " + by
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", inst.GetID())
+	fmt.Fprintf(tw, "Account:\t%s (%s)\n", inst.GetAccount().GetLogin(), strings.ToLower(inst.GetTargetType()))
+	fmt.Fprintf(tw, "Repositories:\t%s\n", inst.GetRepositorySelection())
+	fmt.Fprintf(tw, "Permissions:\t%s\n", orNone(strings.Join(perms, ", ")))
+	fmt.Fprintf(tw, "Events:\t%s\n", orNone(strings.Join(inst.Events, ", ")))
+	fmt.Fprintf(tw, "Suspended:\t%s\n", suspended)
+	fmt.Fprintf(tw, "URL:\t%s\n", inst.GetHTMLURL())
+	return tw.Flush()
+}
+
+func init() {
+	for _, c := range []*cobra.Command{installationGetCmd, installationIDCmd, installationSuspendCmd, installationUnsuspendCmd, installationDeleteCmd} {
+		addTargetFlags(c)
+		installationCmd.AddCommand(c)
+	}
+	installationDeleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Do not ask for confirmation")
+	rootCmd.AddCommand(installationCmd)
+}
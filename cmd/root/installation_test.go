@@ -0,0 +1,89 @@
+package root
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestWriteInstallation(t *testing.T) {
+	suspendedAt := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name string
+		inst *github.Installation
+		want []string
+	}{
+		{
+			name: "active",
+			inst: &github.Installation{
+				ID:                  github.Ptr(int64(123)),
+				Account:             &github.User{Login: github.Ptr("acme")},
+				TargetType:          github.Ptr("Organization"),
+				RepositorySelection: github.Ptr("selected"),
+				Permissions:         &github.InstallationPermissions{Issues: github.Ptr("write")},
+				Events:              []string{"issues", "push"},
+			},
+			want: []string{"123", "acme (organization)", "selected", "issues:write", "issues, push", "Suspended: no"},
+		},
+		{
+			name: "suspended",
+			inst: &github.Installation{
+				ID:          github.Ptr(int64(456)),
+				SuspendedAt: &github.Timestamp{Time: suspendedAt},
+				SuspendedBy: &github.User{Login: github.Ptr("octocat")},
+			},
+			want: []string{"since 2025-03-04T05:06:07Z by octocat", "Permissions: (none)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeInstallation(&buf, tt.inst); err != nil {
+				t.Fatalf("writeInstallation() error = %v", err)
+			}
+			// Compare with the column padding collapsed
+			got := strings.Join(strings.Fields(buf.String()), " ")
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("writeInstallation() output does not contain %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"yes", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var w bytes.Buffer
+			got, err := confirm(strings.NewReader(tt.input), &w, "Delete?")
+			if err != nil {
+				t.Fatalf("confirm() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("confirm() = %v, want %v", got, tt.want)
+			}
+			if w.String() != "Delete? [y/N] " {
+				t.Errorf("prompt = %q", w.String())
+			}
+		})
+	}
+
+	if _, err := confirm(strings.NewReader(""), io.Discard, "Delete?"); err == nil {
+		t.Error("confirm() error = nil on EOF, want error")
+	}
+}
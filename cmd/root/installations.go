@@ -0,0 +1,297 @@
+package root
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchAccountsOnly   bool
+	noInstallationsCache bool
+)
+
+var installationsCmd = &cobra.Command{
+	Use:   "installations",
+	Short: "Work with all installations of the app",
+	Long: `Work with all installations of the app.
+
+The listing is cached with the ETag of every page under the user cache
+directory, so that repeated runs only download the pages that changed;
+unchanged pages do not count against the rate limit. Use --no-cache to
+bypass the cache.`,
+}
+
+var installationsListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List all installations of the app",
+	Example: `  gh app-token installations list --app-id 12345 --private-key app.pem`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		installations, err := listInstallations(cmd.Context(), appToken)
+		if err != nil {
+			return err
+		}
+		return writeInstallations(cmd.OutOrStdout(), installations)
+	},
+}
+
+var installationsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Find installations by account or repository name",
+	Long: `Find the installations whose account login or accessible repositories contain
+<query> (case-insensitive). Repository names are matched against owner/name.
+
+Listing the repositories mints a token for each installation, limited to
+metadata and revoked once the repositories are read, --concurrency
+installations at a time. An installation whose repositories cannot be listed
+is matched by account alone, unless --fail-fast is set. Use --accounts-only to
+match account logins alone, which only needs the app JWT.`,
+	Example: `  gh app-token installations search --app-id 12345 --private-key app.pem widgets`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+		if err := validatePoolFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		installations, err := listInstallations(cmd.Context(), appToken)
+		if err != nil {
+			return err
+		}
+
+		repos := make([][]*github.Repository, len(installations))
+		if !searchAccountsOnly {
+			errs := forEach(cmd.Context(), len(installations), func(ctx context.Context, i int) error {
+				var err error
+				repos[i], err = appToken.ListInstallationRepos(ctx, installations[i].GetID())
+				return err
+			})
+			for i, err := range errs {
+				if err == nil || errors.Is(err, errSkipped) {
+					continue
+				}
+				inst := installations[i]
+				if failFast {
+					return fmt.Errorf("failed to list the repositories of installation %d (%s): %w", inst.GetID(), inst.GetAccount().GetLogin(), err)
+				}
+				logf("warning: skipping repositories of installation %d (%s): %v", inst.GetID(), inst.GetAccount().GetLogin(), err)
+			}
+		}
+
+		var results []searchResult
+		for i, inst := range installations {
+			results = append(results, searchInstallation(args[0], inst, repos[i])...)
+		}
+
+		if len(results) == 0 {
+			return fmt.Errorf("no installation matches %q", args[0])
+		}
+		return writeSearchResults(cmd.OutOrStdout(), results)
+	},
+}
+
+var installationsMatrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Print the installations as a GitHub Actions job matrix",
+	Long: `Print {"include":[{"installation_id":...,"account":...}]} with an entry for
+every installation that is not suspended, for a workflow job to fan out over
+every account the app is installed on with strategy.matrix and fromJSON.`,
+	Example: `  echo "matrix=$(gh app-token installations matrix)" >> "$GITHUB_OUTPUT"`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		installations, err := listInstallations(cmd.Context(), appToken)
+		if err != nil {
+			return err
+		}
+
+		m := installationsMatrix(installations)
+		// An empty matrix fails the job with a less helpful error
+		if len(m.Include) == 0 {
+			return fmt.Errorf("the app has no installations that are not suspended")
+		}
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(m)
+	},
+}
+
+// matrix is a GitHub Actions job matrix of installations.
+type matrix struct {
+	Include []matrixEntry `json:"include"`
+}
+
+type matrixEntry struct {
+	InstallationID int64  `json:"installation_id"`
+	Account        string `json:"account"`
+}
+
+// installationsMatrix returns the matrix of the installations that are not
+// suspended, as no token can be minted for those.
+func installationsMatrix(installations []*github.Installation) *matrix {
+	m := &matrix{Include: []matrixEntry{}}
+	for _, inst := range installations {
+		if inst.SuspendedAt != nil {
+			continue
+		}
+		m.Include = append(m.Include, matrixEntry{InstallationID: inst.GetID(), Account: inst.GetAccount().GetLogin()})
+	}
+	return m
+}
+
+// installationsCachePath returns the cache file of the installations listing
+// of the app identified by issuer on host.
+func installationsCachePath(host, issuer string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-app-token", "installations", host+"-"+issuer+".json"), nil
+}
+
+// listInstallations lists every installation of the app, revalidating the
+// cached pages unless --no-cache is set. Cache problems are logged and never
+// fail the listing.
+func listInstallations(ctx context.Context, appToken *app.AppToken) ([]*github.Installation, error) {
+	if noInstallationsCache {
+		return appToken.ListInstallations(ctx)
+	}
+
+	cache := &app.InstallationPages{}
+	path, err := installationsCachePath(apiHost(), appIssuer())
+	if err == nil {
+		err = readJSONFile(path, cache)
+	}
+	if err != nil {
+		logf("warning: ignoring the installations cache: %v", err)
+	}
+
+	installations, unchanged, err := appToken.SyncInstallations(ctx, cache)
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		logf("installations: %d of %d pages unchanged", unchanged, len(cache.Pages))
+	}
+
+	if path != "" {
+		if err := writeJSONFile(path, cache); err != nil {
+			logf("warning: failed to update the installations cache: %v", err)
+		}
+	}
+	return installations, nil
+}
+
+// readJSONFile decodes the JSON file at path into v. A missing file leaves v
+// unchanged.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+func writeInstallations(w io.Writer, installations []*github.Installation) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTALLATION ID\tACCOUNT\tTYPE\tREPOSITORIES\tSUSPENDED")
+	for _, inst := range installations {
+		suspended := "no"
+		if inst.SuspendedAt != nil {
+			suspended = "yes"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", inst.GetID(), inst.GetAccount().GetLogin(),
+			strings.ToLower(inst.GetTargetType()), inst.GetRepositorySelection(), suspended)
+	}
+	return tw.Flush()
+}
+
+type searchResult struct {
+	installation *github.Installation
+	match        string
+}
+
+// searchInstallation returns a result for the installation's account and for
+// each of repos whose name contains query.
+func searchInstallation(query string, inst *github.Installation, repos []*github.Repository) []searchResult {
+	query = strings.ToLower(query)
+
+	var results []searchResult
+	if login := inst.GetAccount().GetLogin(); strings.Contains(strings.ToLower(login), query) {
+		results = append(results, searchResult{installation: inst, match: "account " + login})
+	}
+	for _, r := range repos {
+		if strings.Contains(strings.ToLower(r.GetFullName()), query) {
+			results = append(results, searchResult{installation: inst, match: "repository " + r.GetFullName()})
+		}
+	}
+	return results
+}
+
+func writeSearchResults(w io.Writer, results []searchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTALLATION ID\tACCOUNT\tMATCH")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", r.installation.GetID(), r.installation.GetAccount().GetLogin(), r.match)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	installationsSearchCmd.Flags().BoolVar(&searchAccountsOnly, "accounts-only", false, "Only match account logins, without listing repositories")
+	addPoolFlags(installationsSearchCmd)
+
+	installationsCmd.PersistentFlags().BoolVar(&noInstallationsCache, "no-cache", false, "Download every page of the installations listing instead of revalidating the cache")
+
+	installationsCmd.AddCommand(installationsListCmd, installationsSearchCmd, installationsMatrixCmd)
+	rootCmd.AddCommand(installationsCmd)
+}
@@ -0,0 +1,121 @@
+package root
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+)
+
+func TestSearchInstallation(t *testing.T) {
+	inst := &github.Installation{
+		ID:      github.Ptr(int64(42)),
+		Account: &github.User{Login: github.Ptr("Acme-Corp")},
+	}
+	repos := []*github.Repository{
+		{FullName: github.Ptr("Acme-Corp/widgets")},
+		{FullName: github.Ptr("Acme-Corp/gadgets")},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"account and repos", "acme", []string{"account Acme-Corp", "repository Acme-Corp/widgets", "repository Acme-Corp/gadgets"}},
+		{"repo only", "WIDGET", []string{"repository Acme-Corp/widgets"}},
+		{"owner/name", "corp/gad", []string{"repository Acme-Corp/gadgets"}},
+		{"no match", "other", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchInstallation(tt.query, inst, repos)
+			if len(got) != len(tt.want) {
+				t.Fatalf("searchInstallation() returned %d results, want %d", len(got), len(tt.want))
+			}
+			for i, r := range got {
+				if r.match != tt.want[i] {
+					t.Errorf("result[%d] = %q, want %q", i, r.match, tt.want[i])
+				}
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := writeSearchResults(&buf, searchInstallation("widgets", inst, repos)); err != nil {
+		t.Fatalf("writeSearchResults() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "42") || !strings.Contains(buf.String(), "repository Acme-Corp/widgets") {
+		t.Errorf("writeSearchResults() = %q", buf.String())
+	}
+}
+
+func TestInstallationsCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installations", "github.com-1.json")
+
+	cache := &app.InstallationPages{}
+	if err := readJSONFile(path, cache); err != nil || len(cache.Pages) != 0 {
+		t.Fatalf("readJSONFile() of a missing file = %+v, %v, want an empty cache", cache, err)
+	}
+
+	want := &app.InstallationPages{Pages: []app.InstallationPage{
+		{ETag: `"p1"`, Installations: []*github.Installation{{ID: github.Ptr(int64(42))}}},
+	}}
+	if err := writeJSONFile(path, want); err != nil {
+		t.Fatalf("writeJSONFile() error = %v", err)
+	}
+	if err := readJSONFile(path, cache); err != nil {
+		t.Fatalf("readJSONFile() error = %v", err)
+	}
+	if len(cache.Pages) != 1 || cache.Pages[0].ETag != `"p1"` || cache.Pages[0].Installations[0].GetID() != 42 {
+		t.Errorf("readJSONFile() = %+v, want %+v", cache, want)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := readJSONFile(path, cache); err == nil {
+		t.Error("readJSONFile() error = nil, want error for invalid JSON")
+	}
+}
+
+func TestWriteInstallations(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeInstallations(&buf, []*github.Installation{{
+		ID:                  github.Ptr(int64(42)),
+		Account:             &github.User{Login: github.Ptr("acme")},
+		TargetType:          github.Ptr("Organization"),
+		RepositorySelection: github.Ptr("all"),
+		SuspendedAt:         &github.Timestamp{},
+	}})
+	if err != nil {
+		t.Fatalf("writeInstallations() error = %v", err)
+	}
+	for _, want := range []string{"INSTALLATION ID", "42", "acme", "organization", "all", "yes"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("writeInstallations() = %q, want %q in it", buf.String(), want)
+		}
+	}
+}
+
+func TestInstallationsMatrix(t *testing.T) {
+	installations := []*github.Installation{
+		{ID: github.Ptr(int64(1)), Account: &github.User{Login: github.Ptr("acme")}},
+		{ID: github.Ptr(int64(2)), Account: &github.User{Login: github.Ptr("widgets")}, SuspendedAt: &github.Timestamp{}},
+		{ID: github.Ptr(int64(3)), Account: &github.User{Login: github.Ptr("octocat")}},
+	}
+
+	data, err := json.Marshal(installationsMatrix(installations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"include":[{"installation_id":1,"account":"acme"},{"installation_id":3,"account":"octocat"}]}`
+	if string(data) != want {
+		t.Errorf("installationsMatrix() = %s, want %s", data, want)
+	}
+}
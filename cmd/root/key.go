@@ -0,0 +1,318 @@
+package root
+
+import (
+	"fmt"
+	"net"
+	"os"
+	osuser "os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/buty4649/gh-app-token/pkg/auth/agent"
+	"github.com/buty4649/gh-app-token/pkg/auth/keyring"
+	"github.com/spf13/cobra"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage GitHub App private keys",
+}
+
+var (
+	convertTo   string
+	importName  string
+	serveSocket string
+	socketMode  string
+	socketGroup string
+)
+
+var keyConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert a private key to another format",
+	Long: `Convert the private key given by --private-key or --private-key-pem to another format and print it.
+
+Supported formats are pkcs1 (the PEM downloaded from GitHub), pkcs8 and jwk.`,
+	Example: `  gh app-token key convert --private-key app.pem --to pkcs8 > app.pkcs8.pem`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := auth.KeyFormat(strings.ToLower(convertTo))
+		if !slices.Contains(auth.KeyFormats, format) {
+			return fmt.Errorf("--to must be one of pkcs1, pkcs8 or jwk")
+		}
+		if err := validateKeyFlags(); err != nil {
+			return err
+		}
+
+		key, err := loadPrivateKey()
+		if err != nil {
+			return err
+		}
+
+		out, err := auth.EncodePrivateKey(key.Reveal(), format)
+		if err != nil {
+			return err
+		}
+
+		_, err = cmd.OutOrStdout().Write(out)
+		return err
+	},
+}
+
+var keyValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a private key offline",
+	Long: `Check the private key given by --private-key or --private-key-pem without
+contacting GitHub: the key must parse, be an RSA key of at least 2048 bits, and
+sign a test payload that verifies against its public key.
+
+Use it to catch truncated or mangled secrets before they reach a pipeline.`,
+	Example: `  gh app-token key validate --private-key app.pem`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateKeyFlags(); err != nil {
+			return err
+		}
+
+		key, err := loadPrivateKey()
+		if err != nil {
+			return err
+		}
+		if err := auth.ValidatePrivateKey(key.Reveal()); err != nil {
+			return err
+		}
+
+		fingerprint, err := auth.Fingerprint(key.Public())
+		if err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Fprintf(cmd.OutOrStdout(), "✓ valid %d-bit RSA key (%s)\n", key.Reveal().N.BitLen(), fingerprint)
+		}
+		return nil
+	},
+}
+
+var keyFingerprintCmd = &cobra.Command{
+	Use:   "fingerprint",
+	Short: "Print the SHA-256 fingerprint of a private key",
+	Long: `Print the SHA-256 fingerprint of the private key in the format shown on the
+GitHub App settings page, to confirm which key you are holding.
+
+Key URIs such as awskms:// are supported; only the public key is fetched.`,
+	Example: `  gh app-token key fingerprint --private-key app.pem`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateKeyFlags(); err != nil {
+			return err
+		}
+
+		signer, err := loadSigner(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		fingerprint, err := auth.Fingerprint(signer.Public())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), fingerprint)
+		return nil
+	},
+}
+
+var keyImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Store a private key in the OS keyring",
+	Long: `Store the private key given by --private-key or --private-key-pem in the OS
+keyring (macOS Keychain, Windows Credential Manager or the Secret Service on
+Linux) under --name. Use it afterwards with --private-key keyring://<name>.
+
+Encrypted keys are decrypted before they are stored, so later runs do not ask
+for the passphrase. The original key file can be deleted once imported.`,
+	Example: `  gh app-token key import --private-key app.pem --name myapp
+  gh app-token --app-id 12345 --private-key keyring://myapp --org my-org`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateKeyFlags(); err != nil {
+			return err
+		}
+
+		key, err := loadPrivateKey()
+		if err != nil {
+			return err
+		}
+		if err := keyring.Store(importName, key.Reveal()); err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ stored key in the OS keyring; use --private-key %s://%s\n", keyring.Scheme, importName)
+		}
+		return nil
+	},
+}
+
+var keyServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Hold the private key and sign JWTs for other processes over a unix socket",
+	Long: `Load the private key and answer JWT signing requests on a unix socket until
+interrupted. Other gh app-token processes use it with --private-key
+unix://<socket>, so they never have the key in memory and can run without
+access to it.
+
+The socket is created with mode 0600, so only the user running the agent can
+connect. To run the agent as a user that can read the key and the other
+commands as unprivileged users, give those users a common group and let it
+connect with --socket-mode 0660 --socket-group <group>. Modes that let other
+users connect are refused. A socket another agent still answers on is not
+replaced.`,
+	Example: `  gh app-token key serve --private-key app.pem --socket /run/gh-app-token/agent.sock
+  gh app-token --app-id 12345 --private-key unix:///run/gh-app-token/agent.sock --org my-org
+
+  # As a key-holding user, for the members of the gh-app-token group
+  gh app-token key serve --private-key /etc/gh-app-token/app.pem --socket /run/gh-app-token/agent.sock --socket-mode 0660 --socket-group gh-app-token`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateKeyFlags(); err != nil {
+			return err
+		}
+
+		mode, gid, err := socketPermissions()
+		if err != nil {
+			return err
+		}
+
+		signer, err := loadSigner(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		l, err := listenAgent(serveSocket, mode, gid)
+		if err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "serving on %s://%s\n", agent.Scheme, serveSocket)
+		}
+		return agent.Serve(cmd.Context(), l, signer)
+	},
+}
+
+// socketPermissions returns the mode of --socket-mode and the group ID of
+// --socket-group, or -1 to keep the group of the current user.
+func socketPermissions() (os.FileMode, int, error) {
+	mode, err := strconv.ParseUint(socketMode, 8, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --socket-mode %q: must be an octal mode such as 0660", socketMode)
+	}
+	if mode&^0o770 != 0 {
+		return 0, 0, fmt.Errorf("--socket-mode %s would let other users connect; use --socket-group instead", socketMode)
+	}
+	if socketGroup == "" {
+		return os.FileMode(mode), -1, nil
+	}
+
+	// A group name, or its numeric ID
+	if gid, err := strconv.Atoi(socketGroup); err == nil {
+		return os.FileMode(mode), gid, nil
+	}
+	group, err := osuser.LookupGroup(socketGroup)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --socket-group: %w", err)
+	}
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --socket-group: group %s has no numeric ID", socketGroup)
+	}
+	return os.FileMode(mode), gid, nil
+}
+
+// listenAgent listens on a unix socket at path that only the current user,
+// and the group gid unless it is -1, can connect to with mode, replacing a
+// stale socket left by a previous run. The socket is bound in a private
+// directory and restricted before it is moved to path, so it is never
+// reachable with the looser permissions of the umask.
+func listenAgent(path string, mode os.FileMode, gid int) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%s is in use by another agent", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	// MkdirTemp creates the directory with mode 0700
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".agent")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	tmp := filepath.Join(dir, "s")
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// The socket is removed from path by agentListener instead
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if gid != -1 {
+		if err := os.Chown(tmp, -1, gid); err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("failed to set the socket group: %w", err)
+		}
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return &agentListener{Listener: l, path: path}, nil
+}
+
+// agentListener removes its socket when closed, as the listener only knows
+// the path it was bound to.
+type agentListener struct {
+	net.Listener
+	path string
+}
+
+func (l *agentListener) Close() error {
+	err := l.Listener.Close()
+	_ = os.Remove(l.path)
+	return err
+}
+
+func init() {
+	keyConvertCmd.Flags().StringVar(&convertTo, "to", "", "Output format (pkcs1, pkcs8 or jwk)")
+	if err := keyConvertCmd.MarkFlagRequired("to"); err != nil {
+		panic(err)
+	}
+
+	keyImportCmd.Flags().StringVar(&importName, "name", "", "Name to store the key under")
+	if err := keyImportCmd.MarkFlagRequired("name"); err != nil {
+		panic(err)
+	}
+
+	keyServeCmd.Flags().StringVar(&serveSocket, "socket", "", "Path of the unix socket to listen on")
+	keyServeCmd.Flags().StringVar(&socketMode, "socket-mode", "0600", "Permissions of the socket, e.g. 0660 with --socket-group")
+	keyServeCmd.Flags().StringVar(&socketGroup, "socket-group", "", "Group name or ID that owns the socket, for unprivileged users to connect")
+	if err := keyServeCmd.MarkFlagRequired("socket"); err != nil {
+		panic(err)
+	}
+
+	keyCmd.AddCommand(keyConvertCmd, keyValidateCmd, keyFingerprintCmd, keyImportCmd, keyServeCmd)
+	rootCmd.AddCommand(keyCmd)
+}
@@ -0,0 +1,89 @@
+package root
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxKeyAge is the --max-key-age policy in days; zero disables the warning.
+var maxKeyAge int
+
+// keyAges records when each private key, by fingerprint, was first used on
+// this machine. GitHub does not expose when a key was generated, so this is
+// the closest local evidence of how long a key has been in service.
+type keyAges struct {
+	FirstSeen map[string]time.Time `json:"first_seen"`
+}
+
+func keyAgesPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-app-token", "keys.json"), nil
+}
+
+// firstSeen returns when the key with fingerprint was first used, recording
+// now for a key not seen before. The file is only written for new keys.
+func firstSeen(fingerprint string, now time.Time) (time.Time, error) {
+	path, err := keyAgesPath()
+	if err != nil {
+		return time.Time{}, err
+	}
+	ages := &keyAges{}
+	if err := readJSONFile(path, ages); err != nil {
+		return time.Time{}, err
+	}
+	if t, ok := ages.FirstSeen[fingerprint]; ok {
+		return t, nil
+	}
+
+	if ages.FirstSeen == nil {
+		ages.FirstSeen = map[string]time.Time{}
+	}
+	ages.FirstSeen[fingerprint] = now.UTC()
+	if err := writeJSONFile(path, ages); err != nil {
+		return time.Time{}, err
+	}
+	return now.UTC(), nil
+}
+
+// keyAgeDays returns the whole days between first and now.
+func keyAgeDays(first, now time.Time) int {
+	return int(now.Sub(first) / (24 * time.Hour))
+}
+
+// checkKeyAge records the key's first use and warns when it is older than
+// --max-key-age. With --record-stats, the key's age is recorded for the stats
+// command as well. Problems with the record never fail, and are only logged
+// with --max-key-age or --verbose.
+func checkKeyAge(fingerprint string) {
+	now := time.Now()
+	first, err := firstSeen(fingerprint, now)
+	if err != nil {
+		if maxKeyAge > 0 || verbose {
+			logf("warning: key age tracking disabled: %v", err)
+		}
+		return
+	}
+	if w := keyAgeWarning(fingerprint, first, now); w != "" {
+		logf("WARNING: %s", w)
+	}
+	recordKeyAge(fingerprint, first, now)
+}
+
+// keyAgeWarning describes a key past --max-key-age, or returns "".
+func keyAgeWarning(fingerprint string, first, now time.Time) string {
+	days := keyAgeDays(first, now)
+	if maxKeyAge <= 0 || days <= maxKeyAge {
+		return ""
+	}
+	return fmt.Sprintf("the private key %s has been in use since %s (%d days), longer than --max-key-age %d; rotate it",
+		fingerprint, first.Format(time.DateOnly), days, maxKeyAge)
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&maxKeyAge, "max-key-age", 0, "Warn when the private key was first used more than this many days ago (env: GH_APP_TOKEN_MAX_KEY_AGE)")
+}
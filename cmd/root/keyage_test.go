@@ -0,0 +1,121 @@
+package root
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFirstSeen(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	day1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	if got, err := firstSeen("SHA256:a", day1); err != nil || !got.Equal(day1) {
+		t.Fatalf("firstSeen() = %v, %v, want %v for a new key", got, err, day1)
+	}
+	if got, err := firstSeen("SHA256:a", day2); err != nil || !got.Equal(day1) {
+		t.Errorf("firstSeen() = %v, %v, want the recorded %v", got, err, day1)
+	}
+	if got, err := firstSeen("SHA256:b", day2); err != nil || !got.Equal(day2) {
+		t.Errorf("firstSeen() = %v, %v, want %v for another key", got, err, day2)
+	}
+}
+
+func TestKeyAgeWarning(t *testing.T) {
+	resetSettings(t)
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		maxAge  int
+		age     time.Duration
+		wantDue bool
+	}{
+		{name: "no policy", maxAge: 0, age: 1000 * 24 * time.Hour},
+		{name: "within policy", maxAge: 90, age: 90 * 24 * time.Hour},
+		{name: "past policy", maxAge: 90, age: 91 * 24 * time.Hour, wantDue: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxKeyAge = tt.maxAge
+			got := keyAgeWarning("SHA256:a", first, first.Add(tt.age))
+			if (got != "") != tt.wantDue {
+				t.Fatalf("keyAgeWarning() = %q, want a warning: %v", got, tt.wantDue)
+			}
+			if tt.wantDue && !strings.Contains(got, "since 2026-01-01 (91 days)") {
+				t.Errorf("keyAgeWarning() = %q, want the first use and age", got)
+			}
+		})
+	}
+}
+
+func TestCheckKeyAge_quietWithoutPolicy(t *testing.T) {
+	resetSettings(t)
+	// A cache directory that cannot be created
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", blocker)
+
+	stderr := func(f func()) string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig := os.Stderr
+		os.Stderr = w
+		f()
+		os.Stderr = orig
+		_ = w.Close()
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+
+	if got := stderr(func() { checkKeyAge("SHA256:a") }); got != "" {
+		t.Errorf("checkKeyAge() without --max-key-age logged %q, want nothing", got)
+	}
+	maxKeyAge = 90
+	if got := stderr(func() { checkKeyAge("SHA256:a") }); !strings.Contains(got, "key age tracking disabled") {
+		t.Errorf("checkKeyAge() with --max-key-age logged %q, want the warning", got)
+	}
+}
+
+func TestRecordKeyAge(t *testing.T) {
+	resetSettings(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	recordStats, maxKeyAge = true, 90
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := first.AddDate(0, 0, 100)
+
+	recordKeyAge("SHA256:old", first, now)
+	maxKeyAge = 0
+	recordKeyAge("SHA256:new", now.AddDate(0, 0, -10), now)
+
+	path, err := statsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &mintStats{}
+	if err := readJSONFile(path, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeKeyStats(&buf, stats.Keys, now, 7); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+KEY         FIRST SEEN  AGE   MAX AGE  STATUS
+SHA256:old  2026-01-01  100d  90d      rotate
+SHA256:new  2026-04-01  10d   -        -
+`
+	if buf.String() != want {
+		t.Errorf("key stats =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,263 @@
+package root
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	manifestFile      string
+	manifestOrg       string
+	manifestOutDir    string
+	manifestNoBrowser bool
+)
+
+var appCmd = &cobra.Command{
+	Use:   "app",
+	Short: "Create GitHub Apps",
+}
+
+var appCreateFromManifestCmd = &cobra.Command{
+	Use:   "create-from-manifest",
+	Short: "Register a new GitHub App from a manifest",
+	Long: `Register a new GitHub App with the App Manifest flow.
+
+A local server is started on 127.0.0.1 and the browser is opened on a page that
+submits --manifest (YAML or JSON) to GitHub. After you confirm the app name,
+GitHub redirects back to the local server and the returned code is exchanged
+for the app's credentials. Any redirect_url in the manifest is replaced.
+
+Two files are written to --out-dir, both with mode 0600:
+
+  <slug>.private-key.pem  the app's private key
+  <slug>.env              GH_APP_TOKEN_APP_ID, GH_APP_TOKEN_PRIVATE_KEY,
+                          WEBHOOK_SECRET, CLIENT_ID and CLIENT_SECRET`,
+	Example: `  gh app-token app create-from-manifest --manifest app.yml
+  gh app-token app create-from-manifest --manifest app.yml --org my-org --out-dir secrets`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(manifestFile)
+		if err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("failed to start callback server: %w", err)
+		}
+		local := "http://" + l.Addr().String()
+
+		manifest, err := manifestJSON(data, local+"/callback")
+		if err != nil {
+			return fmt.Errorf("failed to parse manifest %s: %w", manifestFile, err)
+		}
+
+		state, err := randomState()
+		if err != nil {
+			return err
+		}
+
+		flow := &manifestFlow{
+			action:   newAppURL(apiHost(), manifestOrg, state),
+			manifest: manifest,
+			state:    state,
+			codes:    make(chan string, 1),
+		}
+		srv := &http.Server{Handler: flow}
+		go func() { _ = srv.Serve(l) }()
+		defer func() { _ = srv.Close() }()
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Open %s in your browser to create the app.\n", local)
+		if !manifestNoBrowser {
+			if err := openBrowser(local); err != nil {
+				logf("warning: failed to open the browser: %v", err)
+			}
+		}
+
+		var code string
+		select {
+		case code = <-flow.codes:
+		case <-cmd.Context().Done():
+			return cmd.Context().Err()
+		}
+
+		var baseURL string
+		if host := apiHost(); host != defaultHost {
+			baseURL = fmt.Sprintf("https://%s/", host)
+		}
+		config, err := app.CompleteManifest(cmd.Context(), baseURL, code)
+		if err != nil {
+			return err
+		}
+
+		return writeAppConfig(cmd.OutOrStdout(), manifestOutDir, config)
+	},
+}
+
+// manifestJSON converts a YAML or JSON manifest to the JSON GitHub expects
+// and points its redirect_url at the local callback.
+func manifestJSON(data []byte, redirectURL string) (string, error) {
+	var manifest map[string]any
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return "", err
+	}
+	if manifest == nil {
+		return "", errors.New("manifest is empty")
+	}
+	if _, ok := manifest["url"]; !ok {
+		return "", errors.New("url is required")
+	}
+	manifest["redirect_url"] = redirectURL
+
+	out, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// newAppURL returns the page that accepts a manifest, for a personal app or
+// one owned by org.
+func newAppURL(host, org, state string) string {
+	path := "/settings/apps/new"
+	if org != "" {
+		path = "/organizations/" + url.PathEscape(org) + path
+	}
+	return (&url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     path,
+		RawQuery: url.Values{"state": {state}}.Encode(),
+	}).String()
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var manifestPage = template.Must(template.New("manifest").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form action="{{.Action}}" method="post">
+<input type="hidden" name="manifest" value="{{.Manifest}}">
+<noscript><button type="submit">Create GitHub App</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+// manifestFlow serves the page that posts the manifest to GitHub and
+// receives the code GitHub redirects back with.
+type manifestFlow struct {
+	action   string
+	manifest string
+	state    string
+	codes    chan string
+}
+
+func (f *manifestFlow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = manifestPage.Execute(w, struct{ Action, Manifest string }{f.action, f.manifest})
+	case "/callback":
+		q := r.URL.Query()
+		if q.Get("state") != f.state || q.Get("code") == "" {
+			http.Error(w, "invalid callback", http.StatusBadRequest)
+			return
+		}
+		select {
+		case f.codes <- q.Get("code"):
+			fmt.Fprintln(w, "The GitHub App was created. You can close this window.")
+		default:
+			http.Error(w, "the code was already received", http.StatusConflict)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// openBrowser opens u in the user's default browser.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// writeAppConfig saves the credentials of a new app to dir and prints where
+// they went.
+func writeAppConfig(w io.Writer, dir string, config *github.AppConfig) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	keyPath, err := filepath.Abs(filepath.Join(dir, config.GetSlug()+".private-key.pem"))
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(keyPath, []byte(config.GetPEM()), 0o600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	var env strings.Builder
+	fmt.Fprintf(&env, "GH_APP_TOKEN_APP_ID=%d\n", config.GetID())
+	fmt.Fprintf(&env, "GH_APP_TOKEN_PRIVATE_KEY=%s\n", keyPath)
+	fmt.Fprintf(&env, "WEBHOOK_SECRET=%s\n", config.GetWebhookSecret())
+	fmt.Fprintf(&env, "CLIENT_ID=%s\n", config.GetClientID())
+	fmt.Fprintf(&env, "CLIENT_SECRET=%s\n", config.GetClientSecret())
+	envPath := filepath.Join(dir, config.GetSlug()+".env")
+	if err := writeFileAtomic(envPath, []byte(env.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", envPath, err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "App ID:\t%d\n", config.GetID())
+	fmt.Fprintf(tw, "Slug:\t%s\n", config.GetSlug())
+	fmt.Fprintf(tw, "URL:\t%s\n", config.GetHTMLURL())
+	fmt.Fprintf(tw, "Private key:\t%s\n", keyPath)
+	fmt.Fprintf(tw, "Secrets:\t%s\n", envPath)
+	return tw.Flush()
+}
+
+func init() {
+	appCreateFromManifestCmd.Flags().StringVar(&manifestFile, "manifest", "", "App manifest file (YAML or JSON)")
+	appCreateFromManifestCmd.Flags().StringVar(&manifestOrg, "org", "", "Organization to own the app instead of your user account")
+	appCreateFromManifestCmd.Flags().StringVar(&manifestOutDir, "out-dir", ".", "Directory to write the private key and secrets to")
+	appCreateFromManifestCmd.Flags().BoolVar(&manifestNoBrowser, "no-browser", false, "Print the URL instead of opening the browser")
+	if err := appCreateFromManifestCmd.MarkFlagRequired("manifest"); err != nil {
+		panic(err)
+	}
+
+	appCmd.AddCommand(appCreateFromManifestCmd)
+	rootCmd.AddCommand(appCmd)
+}
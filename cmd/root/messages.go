@@ -0,0 +1,111 @@
+package root
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+const defaultLang = "en"
+
+var lang string
+
+// catalogs holds the user-facing messages by language: the hints printed
+// after an error and the errors of flag validation. Every key must exist
+// in every catalog and be passed to msg as a string literal, which
+// TestCatalogs checks.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"hint":                        "hint",
+		"hint.install_app":            "install the GitHub App on the account at %s",
+		"hint.bad_credentials":        "check that the app ID matches the private key and that the system clock is accurate",
+		"hint.app_not_installed":      "the GitHub App is not installed on the requested account",
+		"hint.installation_not_found": "check that the installation ID belongs to this GitHub App",
+		"hint.rate_limited":           "the GitHub API rate limit was exceeded; try again later",
+		"hint.maintenance":            "the GitHub Enterprise Server is in maintenance mode; try again when its administrators have finished",
+		"hint.incorrect_passphrase":   "check the passphrase given by --passphrase-file or GH_APP_TOKEN_PASSPHRASE",
+		"hint.invalid_key":            "the private key must be the PEM file downloaded from the GitHub App settings or an RSA JWK",
+		"hint.unreachable":            "check that %s can be reached from this machine, including any HTTPS_PROXY settings",
+		"hint.clock_skew":             "synchronize the system clock, e.g. with NTP; GitHub rejects app JWTs from clocks that are off by a minute or more",
+		"hint.key_age":                "generate a new private key in the app settings, deploy it, then delete the old one there",
+		"hint.suspended":              "unsuspend the installation with 'installation unsuspend' or in the account settings",
+
+		"error.app_required":             "app ID, client ID or slug is required (--app-id, --client-id, --app-slug or their GH_APP_TOKEN_* variables)",
+		"error.key_required":             "private key is required (--private-key, --private-key-pem, GH_APP_TOKEN_PRIVATE_KEY or GH_APP_TOKEN_PRIVATE_KEY_PEM)",
+		"error.key_conflict":             "--private-key and --private-key-pem cannot be used together",
+		"error.signer_conflict":          "--signer-cmd cannot be used with --private-key or --private-key-pem",
+		"error.target_required":          "--installation-id, --org, --repo, --user, or --enterprise is required",
+		"error.installation_id_conflict": "--installation-id and --org, --repo, --user, or --enterprise cannot be used together",
+		"error.target_conflict":          "--org, --repo, --user, or --enterprise cannot be used together",
+	},
+	"ja": {
+		"hint":                        "ヒント",
+		"hint.install_app":            "%s から GitHub App をアカウントにインストールしてください",
+		"hint.bad_credentials":        "App ID と秘密鍵の組み合わせが正しいこと、およびシステム時刻が正確であることを確認してください",
+		"hint.app_not_installed":      "指定されたアカウントに GitHub App がインストールされていません",
+		"hint.installation_not_found": "インストール ID がこの GitHub App のものであることを確認してください",
+		"hint.rate_limited":           "GitHub API のレート制限を超えました。しばらくしてから再試行してください",
+		"hint.maintenance":            "GitHub Enterprise Server がメンテナンスモードです。管理者の作業が終わってから再試行してください",
+		"hint.incorrect_passphrase":   "--passphrase-file または GH_APP_TOKEN_PASSPHRASE で指定したパスフレーズを確認してください",
+		"hint.invalid_key":            "秘密鍵には GitHub App の設定画面からダウンロードした PEM ファイルか RSA の JWK を指定してください",
+		"hint.unreachable":            "このマシンから %s に接続できること (HTTPS_PROXY の設定を含む) を確認してください",
+		"hint.clock_skew":             "NTP などでシステム時刻を同期してください。時刻が 1 分以上ずれていると GitHub は App の JWT を拒否します",
+		"hint.key_age":                "App の設定画面で新しい秘密鍵を生成して配布し、その後で古い鍵を削除してください",
+		"hint.suspended":              "'installation unsuspend' またはアカウントの設定からインストールの一時停止を解除してください",
+
+		"error.app_required":             "App ID、クライアント ID またはスラッグを指定してください (--app-id、--client-id、--app-slug または対応する GH_APP_TOKEN_* 環境変数)",
+		"error.key_required":             "秘密鍵を指定してください (--private-key、--private-key-pem、GH_APP_TOKEN_PRIVATE_KEY または GH_APP_TOKEN_PRIVATE_KEY_PEM)",
+		"error.key_conflict":             "--private-key と --private-key-pem は同時に指定できません",
+		"error.signer_conflict":          "--signer-cmd は --private-key や --private-key-pem と同時に指定できません",
+		"error.target_required":          "--installation-id、--org、--repo、--user、--enterprise のいずれかを指定してください",
+		"error.installation_id_conflict": "--installation-id は --org、--repo、--user、--enterprise と同時に指定できません",
+		"error.target_conflict":          "--org、--repo、--user、--enterprise は 1 つだけ指定してください",
+	},
+}
+
+func validateLangFlag() error {
+	if lang == "" {
+		return nil
+	}
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("--lang must be one of %s", strings.Join(slices.Sorted(maps.Keys(catalogs)), ", "))
+	}
+	return nil
+}
+
+// currentLang returns --lang, or the language of the first locale variable
+// that is set (LC_ALL, LC_MESSAGES, LANG). Unsupported languages fall back
+// to English.
+func currentLang() string {
+	if lang != "" {
+		return lang
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		code, _, _ := strings.Cut(strings.ToLower(locale), "_")
+		code, _, _ = strings.Cut(code, ".")
+		if _, ok := catalogs[code]; ok {
+			return code
+		}
+		break
+	}
+	return defaultLang
+}
+
+// msg returns the message for key in the current language, formatted with
+// args.
+func msg(key string, args ...any) string {
+	text, ok := catalogs[currentLang()][key]
+	if !ok {
+		text = catalogs[defaultLang][key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
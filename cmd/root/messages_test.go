@@ -0,0 +1,102 @@
+package root
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCatalogs fails when a catalog lacks a key of another catalog or when
+// the code passes msg a key, or anything but a string literal, that is not
+// in every catalog.
+func TestCatalogs(t *testing.T) {
+	want := slices.Sorted(maps.Keys(catalogs[defaultLang]))
+	for l, catalog := range catalogs {
+		if got := slices.Sorted(maps.Keys(catalog)); !slices.Equal(got, want) {
+			t.Errorf("catalog %q has keys %v, want %v", l, got, want)
+		}
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "msg" || len(call.Args) == 0 {
+					return true
+				}
+
+				pos := fset.Position(call.Pos())
+				lit, ok := call.Args[0].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					t.Errorf("%s: msg key must be a string literal", pos)
+					return true
+				}
+				key, _ := strconv.Unquote(lit.Value)
+				for l, catalog := range catalogs {
+					if _, ok := catalog[key]; !ok {
+						t.Errorf("%s: message %q is missing from catalog %q", pos, key, l)
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+func TestCurrentLang(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		lcAll      string
+		lcMessages string
+		lang       string
+		want       string
+	}{
+		{"default", "", "", "", "", "en"},
+		{"LANG", "", "", "", "ja_JP.UTF-8", "ja"},
+		{"LC_ALL wins", "", "C", "", "ja_JP.UTF-8", "en"},
+		{"LC_MESSAGES", "", "", "ja_JP", "en_US.UTF-8", "ja"},
+		{"unsupported", "", "", "", "fr_FR.UTF-8", "en"},
+		{"flag wins", "ja", "en_US.UTF-8", "", "", "ja"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_MESSAGES", tt.lcMessages)
+			t.Setenv("LANG", tt.lang)
+			lang = tt.flag
+			defer func() { lang = "" }()
+
+			if got := currentLang(); got != tt.want {
+				t.Errorf("currentLang() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsg(t *testing.T) {
+	lang = "ja"
+	defer func() { lang = "" }()
+
+	if got, want := msg("hint.install_app", "https://example.com"), "https://example.com から GitHub App をアカウントにインストールしてください"; got != want {
+		t.Errorf("msg() = %q, want %q", got, want)
+	}
+
+	resetSettings(t)
+	if err := validateAppFlags(); err == nil || !strings.HasPrefix(err.Error(), "App ID、クライアント ID") {
+		t.Errorf("validateAppFlags() error = %v, want it in Japanese", err)
+	}
+}
@@ -0,0 +1,88 @@
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// metadataTTL is --metadata-ttl; zero disables the metadata cache.
+var metadataTTL time.Duration
+
+// fileCache is an app.Cache kept in a JSON file, so that installation
+// lookups are shared by every run on the machine. Problems with the file are
+// logged and treated as a miss, never as a failure.
+type fileCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+type fileCacheEntry struct {
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func metadataCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-app-token", "metadata.json"), nil
+}
+
+func (c *fileCache) read() map[string]fileCacheEntry {
+	entries := map[string]fileCacheEntry{}
+	if err := readJSONFile(c.path, &entries); err != nil {
+		logf("warning: ignoring the metadata cache: %v", err)
+	}
+	return entries
+}
+
+// write saves entries without the expired ones.
+func (c *fileCache) write(entries map[string]fileCacheEntry) {
+	now := time.Now()
+	for key, e := range entries {
+		if !now.Before(e.ExpiresAt) {
+			delete(entries, key)
+		}
+	}
+	if err := writeJSONFile(c.path, entries); err != nil {
+		logf("warning: failed to update the metadata cache: %v", err)
+	}
+}
+
+func (c *fileCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.read()[key]
+	if !ok || !time.Now().Before(e.ExpiresAt) {
+		return nil, false
+	}
+	return e.Value, true
+}
+
+func (c *fileCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := c.read()
+	entries[key] = fileCacheEntry{Value: value, ExpiresAt: time.Now().Add(ttl)}
+	c.write(entries)
+}
+
+func (c *fileCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := c.read()
+	if _, ok := entries[key]; ok {
+		delete(entries, key)
+		c.write(entries)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&metadataTTL, "metadata-ttl", time.Hour, "How long installation lookups and the server version are cached in the user cache directory; 0 disables the cache")
+}
@@ -0,0 +1,30 @@
+package root
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	c := &fileCache{path: filepath.Join(t.TempDir(), "metadata.json")}
+	c.Set("a", []byte(`{"id":1}`), time.Hour)
+	c.Set("expired", []byte(`{"id":2}`), -time.Second)
+
+	// A new instance reads what the last run stored
+	c = &fileCache{path: c.path}
+	if v, ok := c.Get("a"); !ok || string(v) != `{"id":1}` {
+		t.Errorf("Get(a) = %s, %v, want the stored value", v, ok)
+	}
+	if _, ok := c.Get("expired"); ok {
+		t.Error("Get(expired) found an expired entry")
+	}
+	if entries := c.read(); len(entries) != 1 {
+		t.Errorf("file has %d entries, want expired ones dropped", len(entries))
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) found a deleted entry")
+	}
+}
@@ -0,0 +1,39 @@
+package root
+
+import (
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+var (
+	forceIPv4        bool
+	fallbackDelay    time.Duration
+	retries          int
+	retryDelay       time.Duration
+	maxRateLimitWait time.Duration
+)
+
+// applyDialFlags configures how connections to GitHub are dialed. Without
+// --force-ipv4 or --ipv4-fallback-delay, Go's defaults are kept.
+func applyDialFlags() {
+	if !forceIPv4 && fallbackDelay == 0 {
+		return
+	}
+	app.SetDialOptions(app.DialOptions{ForceIPv4: forceIPv4, FallbackDelay: fallbackDelay})
+}
+
+// retryPolicy returns the policy of --retries, --retry-delay and
+// --max-rate-limit-wait. Each wait is logged with --verbose.
+func retryPolicy() app.RetryPolicy {
+	return app.RetryPolicy{
+		Retries:          retries,
+		Delay:            retryDelay,
+		MaxRateLimitWait: maxRateLimitWait,
+		OnRetry: func(err error, wait time.Duration) {
+			if verbose {
+				logf("retrying in %s: %v", wait.Round(time.Millisecond), err)
+			}
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+)
+
+// Output formats for the minted token (--output).
+const (
+	outputText       = "text"
+	outputJSON       = "json"
+	outputAgeEncrypt = "age-encrypt"
+)
+
+var (
+	output     string
+	recipients []string
+)
+
+func validateOutputFlags() error {
+	switch output {
+	case "", outputText, outputJSON:
+		if len(recipients) > 0 {
+			return fmt.Errorf("--recipient requires --output %s", outputAgeEncrypt)
+		}
+	case outputAgeEncrypt:
+		if len(recipients) == 0 {
+			return fmt.Errorf("--output %s requires at least one --recipient", outputAgeEncrypt)
+		}
+	default:
+		return fmt.Errorf("--output must be %s, %s or %s", outputText, outputJSON, outputAgeEncrypt)
+	}
+	return nil
+}
+
+// tokenJSON is the document written by --output json and read back by
+// check-expiry.
+type tokenJSON struct {
+	Token               string                          `json:"token"`
+	ExpiresAt           time.Time                       `json:"expires_at"`
+	Permissions         *github.InstallationPermissions `json:"permissions,omitempty"`
+	RepositorySelection string                          `json:"repository_selection,omitempty"`
+	// Timings holds the duration of each step in milliseconds
+	Timings map[string]int64 `json:"timings,omitempty"`
+}
+
+// writeToken prints the token in the format selected by --output. timings
+// are only part of the JSON document.
+func writeToken(w io.Writer, token *app.Token, timings map[string]int64) error {
+	switch output {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tokenJSON{
+			Token:               token.Token,
+			ExpiresAt:           token.ExpiresAt,
+			Permissions:         token.Permissions,
+			RepositorySelection: token.RepositorySelection,
+			Timings:             timings,
+		})
+	case outputAgeEncrypt:
+		rs, err := parseRecipients(recipients)
+		if err != nil {
+			return err
+		}
+		return encryptToken(w, token.Token, rs)
+	default:
+		_, err := fmt.Fprintln(w, token.Token)
+		return err
+	}
+}
+
+// parseRecipients accepts age X25519 recipients (age1...) and SSH public
+// keys (ssh-ed25519, ssh-rsa).
+func parseRecipients(values []string) ([]age.Recipient, error) {
+	rs := make([]age.Recipient, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+
+		var r age.Recipient
+		var err error
+		if strings.HasPrefix(v, "ssh-") {
+			r, err = agessh.ParseRecipient(v)
+		} else {
+			r, err = age.ParseX25519Recipient(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", v, err)
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// encryptToken writes the token encrypted to recipients in ASCII armor, so
+// it can travel through logs and artifacts.
+func encryptToken(w io.Writer, token string, rs []age.Recipient) error {
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, rs...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	if _, err := io.WriteString(ew, token); err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	if err := ew.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	return nil
+}
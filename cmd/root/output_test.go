@@ -0,0 +1,102 @@
+package root
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func TestValidateOutputFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		recipients []string
+		wantErr    bool
+	}{
+		{"text", outputText, nil, false},
+		{"json", outputJSON, nil, false},
+		{"age-encrypt", outputAgeEncrypt, []string{"age1..."}, false},
+		{"age-encrypt without recipient", outputAgeEncrypt, nil, true},
+		{"recipient without age-encrypt", outputText, []string{"age1..."}, true},
+		{"unknown", "yaml", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output = tt.output
+			recipients = tt.recipients
+			defer func() { output, recipients = outputText, nil }()
+
+			if err := validateOutputFlags(); (err != nil) != tt.wantErr {
+				t.Errorf("validateOutputFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteToken_jsonTimings(t *testing.T) {
+	output = outputJSON
+	defer func() { output = outputText }()
+
+	var buf bytes.Buffer
+	if err := writeToken(&buf, &app.Token{Token: "ghs_secret"}, map[string]int64{"mint": 42}); err != nil {
+		t.Fatalf("writeToken() error = %v, want nil", err)
+	}
+	var got tokenJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if got.Timings["mint"] != 42 {
+		t.Errorf("timings = %v, want mint: 42", got.Timings)
+	}
+
+	buf.Reset()
+	if err := writeToken(&buf, &app.Token{Token: "ghs_secret"}, nil); err != nil {
+		t.Fatalf("writeToken() error = %v, want nil", err)
+	}
+	if strings.Contains(buf.String(), "timings") {
+		t.Errorf("output %q contains timings, want none", buf.String())
+	}
+}
+
+func TestWriteToken_ageEncrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	output = outputAgeEncrypt
+	recipients = []string{identity.Recipient().String()}
+	defer func() { output, recipients = outputText, nil }()
+
+	var buf bytes.Buffer
+	if err := writeToken(&buf, &app.Token{Token: "ghs_secret"}, nil); err != nil {
+		t.Fatalf("writeToken() error = %v, want nil", err)
+	}
+	if strings.Contains(buf.String(), "ghs_secret") {
+		t.Fatal("writeToken() output contains the plaintext token")
+	}
+
+	r, err := age.Decrypt(armor.NewReader(&buf), identity)
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read decrypted token: %v", err)
+	}
+	if string(got) != "ghs_secret" {
+		t.Errorf("decrypted token = %q, want %q", got, "ghs_secret")
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	if _, err := parseRecipients([]string{"not-a-recipient"}); err == nil {
+		t.Error("parseRecipients() error = nil, want error")
+	}
+}
@@ -0,0 +1,91 @@
+package root
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/perm"
+	"github.com/google/go-github/v72/github"
+)
+
+var (
+	permissions       string
+	tokenPermissions  perm.Set
+	ensureScopeSubset bool
+)
+
+// validatePermissionsFlags parses --permissions so that typos and invalid
+// levels fail before any request is made.
+func validatePermissionsFlags() error {
+	if permissions == "" {
+		if ensureScopeSubset {
+			return fmt.Errorf("--ensure-scope-subset requires --permissions")
+		}
+		return nil
+	}
+
+	set, err := perm.Parse(permissions)
+	if err != nil {
+		return fmt.Errorf("invalid --permissions: %w", err)
+	}
+	tokenPermissions = set
+	return nil
+}
+
+// checkServerPermissions rejects permissions the GitHub Enterprise Server
+// behind apiHost() does not know yet, which it would otherwise answer with a
+// bare 422. github.com accepts the whole catalog, so it is not asked.
+func checkServerPermissions(ctx context.Context, appToken *app.AppToken) error {
+	if tokenPermissions == nil || apiHost() == defaultHost {
+		return nil
+	}
+
+	version, err := appToken.ServerVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if err := tokenPermissions.Validate(version); err != nil {
+		return fmt.Errorf("invalid --permissions for %s: %w", apiHost(), err)
+	}
+	return nil
+}
+
+// checkScopeSubset fails with --ensure-scope-subset when --permissions asks
+// for more than inst was granted, which the token API would only answer with
+// a bare 422. The installation is fetched again for its permissions, as inst
+// may come from the metadata cache, where they can be out of date.
+func checkScopeSubset(ctx context.Context, appToken *app.AppToken, inst *github.Installation) error {
+	if !ensureScopeSubset || tokenPermissions == nil {
+		return nil
+	}
+
+	inst, err := appToken.GetInstallation(ctx, inst.GetID())
+	if err != nil {
+		return err
+	}
+	granted, err := perm.FromInstallation(inst.GetPermissions())
+	if err != nil {
+		return err
+	}
+	if missing := tokenPermissions.Missing(granted); len(missing) > 0 {
+		return fmt.Errorf("--permissions exceed those of installation %d (%s):\n  %s",
+			inst.GetID(), inst.GetAccount().GetLogin(), strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// tokenOptions returns the options to mint the token with, or nil for a
+// token with all of the installation's access.
+func tokenOptions() (*github.InstallationTokenOptions, error) {
+	if tokenPermissions == nil {
+		return nil, nil
+	}
+
+	p, err := tokenPermissions.InstallationPermissions()
+	if err != nil {
+		return nil, err
+	}
+	return &github.InstallationTokenOptions{Permissions: p}, nil
+}
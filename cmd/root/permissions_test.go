@@ -0,0 +1,96 @@
+package root
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+)
+
+func TestValidatePermissionsFlags(t *testing.T) {
+	t.Cleanup(func() { permissions, tokenPermissions = "", nil })
+
+	permissions = "contents:raed"
+	if err := validatePermissionsFlags(); err == nil {
+		t.Error("validatePermissionsFlags() error = nil, want error for an invalid level")
+	}
+
+	permissions = "Contents:read,pull-requests:write"
+	if err := validatePermissionsFlags(); err != nil {
+		t.Fatalf("validatePermissionsFlags() error = %v", err)
+	}
+
+	opts, err := tokenOptions()
+	if err != nil {
+		t.Fatalf("tokenOptions() error = %v", err)
+	}
+	if got := opts.Permissions.GetContents(); got != "read" {
+		t.Errorf("Permissions.Contents = %q, want read", got)
+	}
+	if got := opts.Permissions.GetPullRequests(); got != "write" {
+		t.Errorf("Permissions.PullRequests = %q, want write", got)
+	}
+	if opts.Permissions.Issues != nil {
+		t.Errorf("Permissions.Issues = %q, want unset", opts.Permissions.GetIssues())
+	}
+}
+
+func TestTokenOptions_unscoped(t *testing.T) {
+	opts, err := tokenOptions()
+	if err != nil || opts != nil {
+		t.Errorf("tokenOptions() = %v, %v, want nil, nil", opts, err)
+	}
+}
+
+func TestCheckScopeSubset(t *testing.T) {
+	t.Cleanup(func() { permissions, tokenPermissions, ensureScopeSubset = "", nil, false })
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/42", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":42,"account":{"login":"acme"},"permissions":{"contents":"read"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appToken, err := app.NewFromKey(12345, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appToken.WithEnterprise(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// As cached before the write permission was revoked
+	inst := &github.Installation{
+		ID:          github.Ptr(int64(42)),
+		Account:     &github.User{Login: github.Ptr("acme")},
+		Permissions: &github.InstallationPermissions{Contents: github.Ptr("write")},
+	}
+
+	ensureScopeSubset = true
+	if err := validatePermissionsFlags(); err == nil {
+		t.Error("validatePermissionsFlags() error = nil, want error without --permissions")
+	}
+
+	permissions = "contents:write"
+	if err := validatePermissionsFlags(); err != nil {
+		t.Fatal(err)
+	}
+	err = checkScopeSubset(t.Context(), appToken, inst)
+	if err == nil || !strings.Contains(err.Error(), "installation 42 (acme)") || !strings.Contains(err.Error(), "contents: requested write, granted read") {
+		t.Errorf("checkScopeSubset() error = %v, want the diff", err)
+	}
+
+	ensureScopeSubset = false
+	if err := checkScopeSubset(t.Context(), appToken, inst); err != nil {
+		t.Errorf("checkScopeSubset() error = %v, want nil without --ensure-scope-subset", err)
+	}
+}
@@ -0,0 +1,82 @@
+package root
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// errSkipped is recorded for the items --fail-fast did not get to.
+var errSkipped = errors.New("skipped after an earlier failure")
+
+var (
+	concurrency int
+	failFast    bool
+)
+
+// addPoolFlags registers --concurrency and --fail-fast on a command that
+// works through many targets with forEach.
+func addPoolFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&concurrency, "concurrency", 8, "Number of targets to work on at once")
+	cmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop at the first failed target instead of going on with the others")
+}
+
+func validatePoolFlags() error {
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	return nil
+}
+
+// forEach calls fn for the items 0 to n-1, --concurrency at a time, and
+// returns the error of each item, nil where it succeeded. With --fail-fast
+// the context of the calls in flight is cancelled on the first failure, and
+// both the items not started yet and those cancelled in flight fail with
+// errSkipped, so that only the failure that stopped the others is reported.
+func forEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) []error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range n {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			errs[i] = errSkipped
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(ctx, i)
+			if err != nil && errors.Is(context.Cause(ctx), errSkipped) && errors.Is(err, context.Canceled) {
+				err = errSkipped
+			}
+			if errs[i] = err; err != nil && err != errSkipped && failFast {
+				cancel(errSkipped)
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// countFailed returns how many of errs are failures, neither nil nor
+// errSkipped.
+func countFailed(errs []error) int {
+	n := 0
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, errSkipped) {
+			n++
+		}
+	}
+	return n
+}
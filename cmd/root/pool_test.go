@@ -0,0 +1,80 @@
+package root
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	errFailed := errors.New("failed")
+	defer func(c int, f bool) { concurrency, failFast = c, f }(concurrency, failFast)
+
+	t.Run("bounded", func(t *testing.T) {
+		concurrency, failFast = 2, false
+		var running, peak atomic.Int32
+		release := make(chan struct{})
+		go func() {
+			for range 5 {
+				release <- struct{}{}
+			}
+		}()
+		errs := forEach(t.Context(), 5, func(ctx context.Context, i int) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+			if i == 1 {
+				return errFailed
+			}
+			return nil
+		})
+		if p := peak.Load(); p > 2 {
+			t.Errorf("forEach() ran %d at once, want at most 2", p)
+		}
+		if countFailed(errs) != 1 || !errors.Is(errs[1], errFailed) {
+			t.Errorf("forEach() = %v, want only item 1 to fail", errs)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		concurrency, failFast = 1, true
+		errs := forEach(t.Context(), 3, func(ctx context.Context, i int) error {
+			if i == 0 {
+				return errFailed
+			}
+			return nil
+		})
+		if !errors.Is(errs[0], errFailed) || !errors.Is(errs[1], errSkipped) || !errors.Is(errs[2], errSkipped) {
+			t.Errorf("forEach() = %v, want the items after the failure skipped", errs)
+		}
+	})
+
+	t.Run("fail fast in flight", func(t *testing.T) {
+		concurrency, failFast = 3, true
+		var started sync.WaitGroup
+		started.Add(3)
+		errs := forEach(t.Context(), 3, func(ctx context.Context, i int) error {
+			started.Done()
+			started.Wait()
+			if i == 1 {
+				return errFailed
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if !errors.Is(errs[0], errSkipped) || !errors.Is(errs[1], errFailed) || !errors.Is(errs[2], errSkipped) {
+			t.Errorf("forEach() = %v, want the items in flight skipped", errs)
+		}
+		if n := countFailed(errs); n != 1 {
+			t.Errorf("countFailed() = %d, want 1", n)
+		}
+	})
+}
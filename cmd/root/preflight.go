@@ -0,0 +1,141 @@
+package root
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+// preflightCache remembers the app metadata and key fingerprint seen on
+// previous --preflight runs, keyed by "host/app-id".
+type preflightCache struct {
+	Apps map[string]appRecord `json:"apps"`
+}
+
+type appRecord struct {
+	Host        string    `json:"host"`
+	AppID       int64     `json:"app_id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Owner       string    `json:"owner"`
+	Fingerprint string    `json:"fingerprint"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+func (r appRecord) key() string {
+	return r.Host + "/" + strconv.FormatInt(r.AppID, 10)
+}
+
+func preflightCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-app-token", "preflight.json"), nil
+}
+
+func loadPreflightCache(path string) (*preflightCache, error) {
+	cache := &preflightCache{Apps: map[string]appRecord{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if cache.Apps == nil {
+		cache.Apps = map[string]appRecord{}
+	}
+	return cache, nil
+}
+
+func (c *preflightCache) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// check compares rec with what was pinned on earlier runs, returns a warning
+// for every unexpected change and records rec as the new state.
+func (c *preflightCache) check(rec appRecord) []string {
+	var warnings []string
+
+	if prev, ok := c.Apps[rec.key()]; ok {
+		if prev.Fingerprint != rec.Fingerprint {
+			warnings = append(warnings, fmt.Sprintf("the private key for app %d changed since %s (was %s, now %s)",
+				rec.AppID, prev.CheckedAt.Format(time.RFC3339), prev.Fingerprint, rec.Fingerprint))
+		}
+		if prev.Slug != rec.Slug || prev.Owner != rec.Owner {
+			warnings = append(warnings, fmt.Sprintf("app %d is now %s owned by %s (was %s owned by %s)",
+				rec.AppID, rec.Slug, rec.Owner, prev.Slug, prev.Owner))
+		}
+	}
+
+	for k, other := range c.Apps {
+		if k != rec.key() && other.Host == rec.Host && other.Fingerprint == rec.Fingerprint {
+			warnings = append(warnings, fmt.Sprintf("the private key %s was previously used with app %d (%s), now with app %d",
+				rec.Fingerprint, other.AppID, other.Slug, rec.AppID))
+			delete(c.Apps, k)
+		}
+	}
+
+	c.Apps[rec.key()] = rec
+	return warnings
+}
+
+// runPreflight verifies the credentials against GET /app before minting and
+// warns when the app metadata or key pairing differs from previous runs.
+func runPreflight(ctx context.Context, appToken *app.AppToken, host, fingerprint string) error {
+	ghApp, err := appToken.GetApp(ctx)
+	if err != nil {
+		return fmt.Errorf("preflight failed: %w", err)
+	}
+
+	rec := appRecord{
+		Host:        host,
+		AppID:       ghApp.GetID(),
+		Slug:        ghApp.GetSlug(),
+		Name:        ghApp.GetName(),
+		Owner:       ghApp.GetOwner().GetLogin(),
+		Fingerprint: fingerprint,
+		CheckedAt:   time.Now().UTC(),
+	}
+
+	path, err := preflightCachePath()
+	if err != nil {
+		logf("warning: preflight cache disabled: %v", err)
+		return nil
+	}
+	cache, err := loadPreflightCache(path)
+	if err != nil {
+		logf("warning: preflight cache disabled: %v", err)
+		return nil
+	}
+
+	for _, w := range cache.check(rec) {
+		logf("WARNING: %s; this usually means a misconfiguration or a compromised key", w)
+	}
+
+	if err := cache.save(path); err != nil {
+		logf("warning: failed to update preflight cache: %v", err)
+	}
+	return nil
+}
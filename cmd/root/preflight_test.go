@@ -0,0 +1,82 @@
+package root
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPreflightCache_check(t *testing.T) {
+	base := appRecord{Host: "github.com", AppID: 1, Slug: "bot", Owner: "acme", Fingerprint: "SHA256:aaa"}
+
+	tests := []struct {
+		name         string
+		pinned       []appRecord
+		rec          appRecord
+		wantWarnings int
+	}{
+		{"first run", nil, base, 0},
+		{"unchanged", []appRecord{base}, base, 0},
+		{
+			name:         "key changed",
+			pinned:       []appRecord{base},
+			rec:          appRecord{Host: "github.com", AppID: 1, Slug: "bot", Owner: "acme", Fingerprint: "SHA256:bbb"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "app renamed",
+			pinned:       []appRecord{base},
+			rec:          appRecord{Host: "github.com", AppID: 1, Slug: "other", Owner: "acme", Fingerprint: "SHA256:aaa"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "key reused for another app",
+			pinned:       []appRecord{base},
+			rec:          appRecord{Host: "github.com", AppID: 2, Slug: "bot2", Owner: "acme", Fingerprint: "SHA256:aaa"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "same key on another host",
+			pinned:       []appRecord{base},
+			rec:          appRecord{Host: "ghe.example.com", AppID: 2, Slug: "bot", Owner: "acme", Fingerprint: "SHA256:aaa"},
+			wantWarnings: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &preflightCache{Apps: map[string]appRecord{}}
+			for _, r := range tt.pinned {
+				cache.Apps[r.key()] = r
+			}
+
+			if got := cache.check(tt.rec); len(got) != tt.wantWarnings {
+				t.Errorf("check() = %v, want %d warnings", got, tt.wantWarnings)
+			}
+			if got := cache.check(tt.rec); len(got) != 0 {
+				t.Errorf("check() on second run = %v, want no warnings", got)
+			}
+		})
+	}
+}
+
+func TestPreflightCache_saveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-app-token", "preflight.json")
+
+	cache, err := loadPreflightCache(path)
+	if err != nil {
+		t.Fatalf("loadPreflightCache() error = %v, want nil", err)
+	}
+	rec := appRecord{Host: "github.com", AppID: 1, Slug: "bot", Fingerprint: "SHA256:aaa"}
+	cache.check(rec)
+	if err := cache.save(path); err != nil {
+		t.Fatalf("save() error = %v, want nil", err)
+	}
+
+	loaded, err := loadPreflightCache(path)
+	if err != nil {
+		t.Fatalf("loadPreflightCache() error = %v, want nil", err)
+	}
+	if got := loaded.Apps[rec.key()]; got.Fingerprint != rec.Fingerprint {
+		t.Errorf("loaded fingerprint = %v, want %v", got.Fingerprint, rec.Fingerprint)
+	}
+}
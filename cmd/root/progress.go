@@ -0,0 +1,108 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+var (
+	quiet   bool
+	verbose bool
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progress shows a spinner for each step on an interactive stderr and sums
+// up the step timings once done. Timings are recorded even when the display
+// is disabled, for --verbose and --output json.
+type progress struct {
+	w       io.Writer
+	enabled bool
+	timings []stepTiming
+}
+
+type stepTiming struct {
+	name    string
+	elapsed time.Duration
+}
+
+func newProgress() *progress {
+	return &progress{w: os.Stderr, enabled: !quiet && isTerminal(os.Stderr)}
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// step runs fn while showing name with a spinner and records its duration.
+func (p *progress) step(name string, fn func() error) error {
+	start := time.Now()
+	var err error
+	if p.enabled {
+		err = p.spin(name, fn)
+	} else {
+		err = fn()
+	}
+	elapsed := time.Since(start)
+
+	p.timings = append(p.timings, stepTiming{name: name, elapsed: elapsed})
+	if verbose {
+		logf("%s took %dms", name, elapsed.Milliseconds())
+	}
+	return err
+}
+
+func (p *progress) spin(name string, fn func() error) error {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(p.w, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], name)
+			select {
+			case <-done:
+				fmt.Fprint(p.w, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	err := fn()
+	close(done)
+	wg.Wait()
+	return err
+}
+
+// milliseconds returns the recorded step durations in milliseconds.
+func (p *progress) milliseconds() map[string]int64 {
+	if len(p.timings) == 0 {
+		return nil
+	}
+	ms := make(map[string]int64, len(p.timings))
+	for _, t := range p.timings {
+		ms[t.name] += t.elapsed.Milliseconds()
+	}
+	return ms
+}
+
+// finish prints the recorded step timings.
+func (p *progress) finish() {
+	if !p.enabled || len(p.timings) == 0 {
+		return
+	}
+	parts := make([]string, len(p.timings))
+	for i, t := range p.timings {
+		parts[i] = fmt.Sprintf("%s %dms", t.name, t.elapsed.Milliseconds())
+	}
+	fmt.Fprintf(p.w, "✓ %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,58 @@
+package root
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progress{w: &buf, enabled: true}
+
+	if err := p.step("discovery", func() error { return nil }); err != nil {
+		t.Fatalf("step() error = %v, want nil", err)
+	}
+	wantErr := errors.New("boom")
+	if err := p.step("token", func() error { return wantErr }); !errors.Is(err, wantErr) {
+		t.Fatalf("step() error = %v, want %v", err, wantErr)
+	}
+	p.finish()
+
+	out := buf.String()
+	for _, want := range []string{"discovery ", "token ", "ms"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+
+	buf.Reset()
+	disabled := &progress{w: &buf}
+	if err := disabled.step("discovery", func() error { return nil }); err != nil {
+		t.Fatalf("step() error = %v, want nil", err)
+	}
+	disabled.finish()
+	if buf.Len() != 0 {
+		t.Errorf("disabled progress wrote %q, want nothing", buf.String())
+	}
+}
+
+func TestProgress_Milliseconds(t *testing.T) {
+	p := &progress{w: &bytes.Buffer{}}
+	for _, name := range []string{"key_load", "sign", "mint"} {
+		if err := p.step(name, func() error { return nil }); err != nil {
+			t.Fatalf("step() error = %v, want nil", err)
+		}
+	}
+
+	got := p.milliseconds()
+	if len(got) != 3 {
+		t.Fatalf("milliseconds() = %v, want 3 entries", got)
+	}
+	for _, name := range []string{"key_load", "sign", "mint"} {
+		if ms, ok := got[name]; !ok || ms < 0 {
+			t.Errorf("milliseconds()[%q] = %d, %v, want a duration", name, ms, ok)
+		}
+	}
+}
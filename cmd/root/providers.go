@@ -0,0 +1,12 @@
+package root
+
+// Key sources available to --private-key in addition to plain key files.
+import (
+	_ "github.com/buty4649/gh-app-token/pkg/auth/agent"
+	_ "github.com/buty4649/gh-app-token/pkg/auth/awskms"
+	_ "github.com/buty4649/gh-app-token/pkg/auth/awssm"
+	_ "github.com/buty4649/gh-app-token/pkg/auth/gcpkms"
+	_ "github.com/buty4649/gh-app-token/pkg/auth/gcpsm"
+	_ "github.com/buty4649/gh-app-token/pkg/auth/keyring"
+	_ "github.com/buty4649/gh-app-token/pkg/auth/onepassword"
+)
@@ -0,0 +1,62 @@
+package root
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// redactedText replaces every match of a --redact pattern.
+const redactedText = "[REDACTED]"
+
+var (
+	redactPatterns []string
+	redactions     []*regexp.Regexp
+)
+
+// loadRedactions compiles --redact, falling back to GH_APP_TOKEN_REDACT
+// with one pattern per line.
+func loadRedactions() error {
+	patterns := redactPatterns
+	if len(patterns) == 0 {
+		for _, p := range strings.Split(os.Getenv("GH_APP_TOKEN_REDACT"), "\n") {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+
+	res, err := compileRedactions(patterns)
+	if err != nil {
+		return err
+	}
+	redactions = res
+	return nil
+}
+
+func compileRedactions(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --redact pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// redact masks every match of the configured patterns in s. It is applied to
+// error messages, hints and log lines, never to command output.
+func redact(s string) string {
+	for _, re := range redactions {
+		s = re.ReplaceAllLiteralString(s, redactedText)
+	}
+	return s
+}
+
+// logf writes a diagnostic line to stderr with --redact applied.
+func logf(format string, args ...any) {
+	fmt.Fprintln(os.Stderr, redact(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,36 @@
+package root
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	res, err := compileRedactions([]string{`ghe\.internal\.example`, `user-[a-z]+`})
+	if err != nil {
+		t.Fatalf("compileRedactions() error = %v", err)
+	}
+	redactions = res
+	defer func() { redactions = nil }()
+
+	got := redact("GET https://ghe.internal.example/api/v3 as user-alice: 401")
+	want := "GET https://[REDACTED]/api/v3 as [REDACTED]: 401"
+	if got != want {
+		t.Errorf("redact() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadRedactions_env(t *testing.T) {
+	t.Setenv("GH_APP_TOKEN_REDACT", "alpha\n\n  beta  \n")
+	defer func() { redactions = nil }()
+
+	if err := loadRedactions(); err != nil {
+		t.Fatalf("loadRedactions() error = %v", err)
+	}
+	if got := redact("alpha beta gamma"); got != "[REDACTED] [REDACTED] gamma" {
+		t.Errorf("redact() = %q", got)
+	}
+}
+
+func TestCompileRedactions_invalid(t *testing.T) {
+	if _, err := compileRedactions([]string{"("}); err == nil {
+		t.Error("compileRedactions() error = nil, want error")
+	}
+}
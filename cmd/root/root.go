@@ -2,175 +2,381 @@ package root
 
 import (
 	"context"
+	"crypto"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/google/go-github/v72/github"
 	"github.com/spf13/cobra"
 )
 
 const version = "1.1.0"
 
+// extensionName is how gh exposes the binary (`gh app-token`).
+const extensionName = "gh app-token"
+
+// buildVersion prefers the module version stamped by the Go toolchain when the
+// binary is built from a tagged checkout (as gh-extension-precompile does), so
+// `gh extension upgrade` and `--version` agree on the release tag.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		v := info.Main.Version
+		if v != "" && v != "(devel)" && !strings.Contains(v, "+dirty") {
+			return strings.TrimPrefix(v, "v")
+		}
+	}
+	return version
+}
+
 var (
 	appID          int64
+	clientID       string
+	appSlug        string
 	installationID int64
 	org            string
 	repo           string
 	user           string
+	enterprise     string
 	privateKeyPath string
+	privateKeyPEM  string
+	preflight      bool
 )
 
-func validateFlags() error {
-	// Validate required flags
-	if appID == 0 {
-		return fmt.Errorf("app ID is required (--app-id or GH_APP_TOKEN_APP_ID)")
+func validateKeyFlags() error {
+	if signerCmd != "" {
+		if privateKeyPath != "" || privateKeyPEM != "" {
+			return errors.New(msg("error.signer_conflict"))
+		}
+		return nil
 	}
-	if privateKeyPath == "" {
-		return fmt.Errorf("private key path is required (--private-key or GH_APP_TOKEN_PRIVATE_KEY)")
+	if privateKeyPath == "" && privateKeyPEM == "" {
+		return errors.New(msg("error.key_required"))
+	}
+	if privateKeyPath != "" && privateKeyPEM != "" {
+		return errors.New(msg("error.key_conflict"))
+	}
+	return nil
+}
+
+// validateAppFlags checks the flags needed to authenticate as the app.
+func validateAppFlags() error {
+	if appID == 0 && clientID == "" && appSlug == "" {
+		return errors.New(msg("error.app_required"))
 	}
+	return validateKeyFlags()
+}
 
-	// Validate installation ID flags
-	if installationID == 0 && org == "" && repo == "" && user == "" {
-		return fmt.Errorf("--installation-id, --org, --repo, or --user is required")
+// validateTargetFlags checks that exactly one installation target is given.
+// Without any, the repository is detected with detectRepo.
+func validateTargetFlags() error {
+	if installationID == 0 && org == "" && repo == "" && user == "" && enterprise == "" {
+		repo = detectRepo()
+	}
+	if installationID == 0 && org == "" && repo == "" && user == "" && enterprise == "" {
+		return errors.New(msg("error.target_required"))
+	}
+	if err := normalizeRepo(); err != nil {
+		return err
 	}
 
-	if installationID != 0 && (org != "" || repo != "" || user != "") {
-		return fmt.Errorf("--installation-id and --org, --repo, or --user cannot be used together")
+	if installationID != 0 && (org != "" || repo != "" || user != "" || enterprise != "") {
+		return errors.New(msg("error.installation_id_conflict"))
 	}
 
-	if org != "" && repo != "" || org != "" && user != "" || repo != "" && user != "" {
-		return fmt.Errorf("--org, --repo, or --user cannot be used together")
+	n := 0
+	for _, set := range []bool{org != "", repo != "", user != "", enterprise != ""} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New(msg("error.target_conflict"))
 	}
 
 	return nil
 }
 
+func validateFlags() error {
+	// Validate required flags
+	if err := validateAppFlags(); err != nil {
+		return err
+	}
+
+	// Validate installation ID flags
+	return validateTargetFlags()
+}
+
 var rootCmd = &cobra.Command{
-	Use:     "gh-app-token",
-	Short:   "GitHub App Authentication Tool",
-	Long:    `A tool to generate GitHub App installation tokens using JWT authentication.`,
-	Version: version,
+	Use:   "gh-app-token",
+	Short: "GitHub App Authentication Tool",
+	Long:  `A tool to generate GitHub App installation tokens using JWT authentication.`,
+	Example: `  gh app-token --app-id 12345 --private-key app.pem --installation-id 67890
+  gh app-token --app-id 12345 --private-key app.pem --org my-org
+  gh app-token --app-id 12345 --private-key app.pem --repo owner/repo`,
+	Version: buildVersion(),
+	Annotations: map[string]string{
+		cobra.CommandDisplayNameAnnotation: extensionName,
+	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Check for environment variables if flags are not set
-		if appID == 0 {
-			if envAppID := os.Getenv("GH_APP_TOKEN_APP_ID"); envAppID != "" {
-				var err error
-				appID, err = strconv.ParseInt(envAppID, 10, 64)
-				if err != nil {
-					return fmt.Errorf("invalid GH_APP_TOKEN_APP_ID: %w", err)
-				}
-			}
+		if err := loadRedactions(); err != nil {
+			return err
 		}
-		if privateKeyPath == "" {
-			if envPrivateKey := os.Getenv("GH_APP_TOKEN_PRIVATE_KEY"); envPrivateKey != "" {
-				privateKeyPath = envPrivateKey
-			}
+		if err := validateLangFlag(); err != nil {
+			return err
 		}
-		if installationID == 0 {
-			if envInstallationID := os.Getenv("GH_APP_TOKEN_INSTALLATION_ID"); envInstallationID != "" {
-				var err error
-				installationID, err = strconv.ParseInt(envInstallationID, 10, 64)
-				if err != nil {
-					return fmt.Errorf("invalid GH_APP_TOKEN_INSTALLATION_ID: %w", err)
-				}
-			}
+		if err := validateHostnameFlag(); err != nil {
+			return err
 		}
-		if org == "" {
-			org = os.Getenv("GH_APP_TOKEN_ORG")
+		applyDialFlags()
+		if retries < 0 || retryDelay < 0 || maxRateLimitWait < 0 {
+			return fmt.Errorf("--retries, --retry-delay and --max-rate-limit-wait must not be negative")
 		}
-		if repo == "" {
-			repo = os.Getenv("GH_APP_TOKEN_REPO")
+
+		if err := loadEnv(cmd); err != nil {
+			return err
 		}
-		if user == "" {
-			user = os.Getenv("GH_APP_TOKEN_USER")
+		// init edits the config file, possibly adding the selected profile
+		if cmd == initCmd {
+			return nil
 		}
-
-		return nil
+		// The config file only fills in what flags and environment left unset
+		return loadConfig(cmd)
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Validate all flags
 		if err := validateFlags(); err != nil {
 			return err
 		}
+		if err := validateOutputFlags(); err != nil {
+			return err
+		}
+		if err := validateTokenFileFlags(); err != nil {
+			return err
+		}
+		if err := validateDeliverFlags(); err != nil {
+			return err
+		}
+		if err := validatePermissionsFlags(); err != nil {
+			return err
+		}
+
+		p := newProgress()
+		var appToken *app.AppToken
+		var signer crypto.Signer
+		err := p.step("key_load", func() error {
+			var err error
+			appToken, signer, err = newAppToken(cmd.Context())
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := checkServerPermissions(cmd.Context(), appToken); err != nil {
+			return err
+		}
 
-		appToken, err := app.New(appID, privateKeyPath)
+		fingerprint, err := auth.Fingerprint(signer.Public())
 		if err != nil {
-			return fmt.Errorf("failed to create app token: %w", err)
+			return err
+		}
+		checkKeyAge(fingerprint)
+		if preflight {
+			if err := runPreflight(cmd.Context(), appToken, apiHost(), fingerprint); err != nil {
+				return err
+			}
 		}
 
-		host := os.Getenv("GH_HOST")
-		if host != "" {
-			baseURL := fmt.Sprintf("https://%s/", host)
-			if err := appToken.WithEnterprise(baseURL); err != nil {
-				return fmt.Errorf("failed to set enterprise base URL: %w", err)
+		var shadow *app.AppToken
+		if shadowHost != "" {
+			if installationID != 0 {
+				return fmt.Errorf("--shadow-host requires --org, --repo or --user")
+			}
+			shadow, err = newShadowAppToken(cmd.Context(), signer)
+			if err != nil {
+				return err
 			}
 		}
 
-		token, err := getToken(appToken)
+		start := time.Now()
+		token, err := getToken(cmd.Context(), p, appToken, shadow)
+		recordMint(statsTarget(), time.Since(start), err)
 		if err != nil {
-			return fmt.Errorf("failed to get token: %w", err)
+			return fmt.Errorf("failed to get token: %w", withInstallURL(cmd.Context(), appToken, err))
 		}
 
-		fmt.Println(token)
-		return nil
+		if err := writeEmitters(apiHost(), token.Token); err != nil {
+			return err
+		}
+		return emitToken(token, p.milliseconds())
 	},
 }
 
-func getToken(appToken *app.AppToken) (string, error) {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
-	defer stop()
+// getToken signs the app JWT, discovers the installation if needed and mints
+// a token for it, recording each step in p. When shadow is not nil,
+// discovery is mirrored to it and differences are reported without
+// affecting the result.
+func getToken(ctx context.Context, p *progress, appToken, shadow *app.AppToken) (*app.Token, error) {
+	if err := p.step("sign", func() error {
+		_, err := appToken.JWT()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	id := installationID
+	if id == 0 {
+		var mirror *shadowDiscovery
+		if shadow != nil {
+			mirror = startShadowDiscovery(ctx, shadow)
+		}
 
+		var installation *github.Installation
+		err := p.step("discovery", func() error {
+			var err error
+			installation, err = findInstallation(ctx, appToken)
+			return err
+		})
+		if mirror != nil {
+			mirror.report(installation, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkScopeSubset(ctx, appToken, installation); err != nil {
+			return nil, err
+		}
+		id = installation.GetID()
+	}
+
+	opts, err := tokenOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var token *app.Token
+	err = p.step("mint", func() error {
+		var err error
+		token, err = appToken.CreateTokenWithOptions(ctx, id, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.finish()
+	return token, nil
+}
+
+// resolveInstallationID looks up the installation for --org, --repo, --user
+// or --enterprise.
+func resolveInstallationID(ctx context.Context, appToken *app.AppToken) (int64, error) {
 	if installationID != 0 {
-		return appToken.GetToken(ctx, installationID)
+		return installationID, nil
 	}
 
-	if org != "" {
-		return appToken.GetTokenFromOrg(ctx, org)
+	installation, err := findInstallation(ctx, appToken)
+	if err != nil {
+		return 0, err
 	}
 
-	if repo != "" {
+	return installation.GetID(), nil
+}
+
+// findInstallation discovers the installation for --org, --repo, --user or
+// --enterprise.
+func findInstallation(ctx context.Context, appToken *app.AppToken) (*github.Installation, error) {
+	switch {
+	case org != "":
+		return appToken.FindOrgInstallation(ctx, org)
+	case repo != "":
 		parts := strings.Split(repo, "/")
 		if len(parts) != 2 {
-			return "", fmt.Errorf("repo must be in format 'owner/repo'")
+			return nil, fmt.Errorf("repo must be in format 'owner/repo'")
 		}
-		return appToken.GetTokenFromRepo(ctx, parts[0], parts[1])
+		return appToken.FindRepoInstallation(ctx, parts[0], parts[1])
+	case user != "":
+		return appToken.FindUserInstallation(ctx, user)
+	case enterprise != "":
+		return appToken.FindEnterpriseInstallation(ctx, enterprise)
+	default:
+		return nil, fmt.Errorf("no installation ID, org, repo, user, or enterprise provided")
 	}
-
-	if user != "" {
-		return appToken.GetTokenFromUser(ctx, user)
-	}
-
-	return "", fmt.Errorf("no installation ID, org, repo, or user provided")
 }
 
 func Execute() {
 	rootCmd.SilenceUsage = true
 	rootCmd.SilenceErrors = true
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		var exit *exitError
+		if errors.As(err, &exit) {
+			stop()
+			os.Exit(exit.code)
+		}
+
+		fmt.Fprintln(os.Stderr, redact(err.Error()))
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", msg("hint"), redact(hint))
+		}
+		stop()
 		os.Exit(1)
 	}
 }
 
 func init() {
-	// Required flags
-	rootCmd.Flags().Int64Var(&appID, "app-id", 0, "GitHub App ID (env: GH_APP_TOKEN_APP_ID)")
-	rootCmd.Flags().StringVar(&privateKeyPath, "private-key", "", "Path to private key file (env: GH_APP_TOKEN_PRIVATE_KEY)")
+	// Required flags, shared with subcommands
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Profile of the config file to use (env: GH_APP_TOKEN_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&hostname, "hostname", "", "GitHub host to use, e.g. a GitHub Enterprise Server (env: GH_HOST)")
+	rootCmd.PersistentFlags().Int64Var(&appID, "app-id", 0, "GitHub App ID (env: GH_APP_TOKEN_APP_ID)")
+	rootCmd.PersistentFlags().StringVar(&clientID, "client-id", "", "GitHub App client ID, instead of --app-id (env: GH_APP_TOKEN_CLIENT_ID)")
+	rootCmd.PersistentFlags().StringVar(&appSlug, "app-slug", "", "GitHub App slug as in github.com/apps/<slug>, to look up its ID (env: GH_APP_TOKEN_APP_SLUG)")
+	rootCmd.PersistentFlags().StringVar(&privateKeyPath, "private-key", "", "Path to private key file or key URI such as awskms://alias/my-key (env: GH_APP_TOKEN_PRIVATE_KEY)")
+	rootCmd.PersistentFlags().StringVar(&privateKeyPEM, "private-key-pem", "", "Private key content; escaped \\n newlines are accepted (env: GH_APP_TOKEN_PRIVATE_KEY_PEM)")
+	rootCmd.PersistentFlags().StringVar(&signerCmd, "signer-cmd", "", "Command that signs app JWTs instead of a private key; see README for the protocol (env: GH_APP_TOKEN_SIGNER_CMD)")
+	rootCmd.PersistentFlags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase of an encrypted private key (env: GH_APP_TOKEN_PASSPHRASE)")
+	rootCmd.PersistentFlags().BoolVar(&forceIPv4, "force-ipv4", false, "Only connect to GitHub over IPv4, for networks with broken IPv6 routes")
+	rootCmd.PersistentFlags().DurationVar(&fallbackDelay, "ipv4-fallback-delay", 0, "How long to wait for IPv6 before also trying IPv4; negative waits for IPv6 to fail (default 300ms)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "Retry installation lookups and token creation this many times after server or network errors")
+	rootCmd.PersistentFlags().DurationVar(&retryDelay, "retry-delay", time.Second, "Wait before the first retry; it doubles for each retry after it, plus random jitter")
+	rootCmd.PersistentFlags().DurationVar(&maxRateLimitWait, "max-rate-limit-wait", time.Minute, "Longest Retry-After of a secondary rate limit to wait out before retrying; 0 fails at once")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Do not show progress on an interactive terminal")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Log the duration of each step (key load, sign, discovery, mint) to stderr")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", "", "Language of hints and flag errors: en or ja (default: from LC_ALL, LC_MESSAGES or LANG)")
+	rootCmd.PersistentFlags().StringArrayVar(&redactPatterns, "redact", nil, "Regular expression to mask in errors and logs, e.g. internal hostnames (repeatable; env: GH_APP_TOKEN_REDACT, one per line)")
+
+	rootCmd.MarkFlagsMutuallyExclusive("app-id", "client-id", "app-slug")
 
 	// Installation ID flags (mutually exclusive)
-	installationFlags := rootCmd.Flags()
-	installationFlags.Int64Var(&installationID, "installation-id", 0, "GitHub App Installation ID (env: GH_APP_TOKEN_INSTALLATION_ID)")
-	installationFlags.StringVar(&org, "org", "", "Organization name to get installation ID (env: GH_APP_TOKEN_ORG)")
-	installationFlags.StringVar(&repo, "repo", "", "Repository name (owner/repo) to get installation ID (env: GH_APP_TOKEN_REPO)")
-	installationFlags.StringVar(&user, "user", "", "Username to get installation ID (env: GH_APP_TOKEN_USER)")
+	addTargetFlags(rootCmd)
+
+	rootCmd.Flags().BoolVar(&preflight, "preflight", false, "Verify the app ID and key with GET /app before minting and warn if they changed since the last run")
+
+	rootCmd.Flags().StringVar(&permissions, "permissions", "", "Narrow the token to these permissions, e.g. contents:read,issues:write; checked against the server version on GHES")
+	rootCmd.Flags().BoolVar(&ensureScopeSubset, "ensure-scope-subset", false, "Fail before minting if --permissions exceed those of the discovered installation")
+
+	rootCmd.Flags().StringVar(&shadowHost, "shadow-host", "", "Mirror installation discovery to this host and report differences, e.g. during a migration")
+
+	rootCmd.Flags().StringVar(&output, "output", outputText, "Output format: text, json or age-encrypt")
+	rootCmd.Flags().StringArrayVar(&recipients, "recipient", nil, "age (age1...) or SSH public key to encrypt the token to with --output age-encrypt (repeatable)")
 
-	// Make installation identification flags mutually exclusive
-	rootCmd.MarkFlagsMutuallyExclusive("installation-id", "org", "repo", "user")
+	rootCmd.Flags().StringVar(&tokenFile, "token-file", "", "Write the token to this file (mode 0600) instead of stdout")
+	rootCmd.Flags().StringVar(&deliver, "deliver", "", "Store the token in `target` (keyring:<name>) for other local processes instead of printing it; see the read command")
+	rootCmd.Flags().BoolVar(&crlf, "crlf", false, "Use CRLF line endings in --token-file")
+	rootCmd.Flags().StringVar(&encoding, "encoding", encodingUTF8, "Encoding of --token-file: utf8 or utf16le")
+	rootCmd.Flags().StringVar(&emitCurlrcPath, "emit-curlrc", "", "Also write a .curlrc that sends the token to this `path` (mode 0600), for curl; it applies to every URL curl fetches")
+	rootCmd.Flags().StringVar(&emitGhHostsPath, "emit-gh-hosts", "", "Also write a gh hosts.yml for the host with the token to this `path` (mode 0600)")
 
 	// Customize flag groups in usage
 	rootCmd.Flags().SortFlags = false
+	rootCmd.PersistentFlags().SortFlags = false
 }
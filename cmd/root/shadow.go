@@ -0,0 +1,104 @@
+package root
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+)
+
+// shadowTimeout bounds how long the primary result waits for the shadow host.
+const shadowTimeout = 10 * time.Second
+
+var shadowHost string
+
+// newShadowAppToken builds the AppToken for --shadow-host. The app must use
+// the same ID and private key on both hosts.
+func newShadowAppToken(ctx context.Context, signer crypto.Signer) (*app.AppToken, error) {
+	return newAppTokenForHost(ctx, signer, shadowHost)
+}
+
+// shadowDiscovery runs installation discovery against the shadow host in the
+// background.
+type shadowDiscovery struct {
+	cancel       context.CancelFunc
+	done         chan struct{}
+	installation *github.Installation
+	err          error
+}
+
+func startShadowDiscovery(ctx context.Context, shadow *app.AppToken) *shadowDiscovery {
+	ctx, cancel := context.WithTimeout(ctx, shadowTimeout)
+	s := &shadowDiscovery{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		s.installation, s.err = findInstallation(ctx, shadow)
+	}()
+	return s
+}
+
+// report waits for the shadow discovery and logs how it differs from the
+// primary one.
+func (s *shadowDiscovery) report(primary *github.Installation, primaryErr error) {
+	<-s.done
+	s.cancel()
+
+	diffs := compareInstallations(primary, primaryErr, s.installation, s.err)
+	for _, d := range diffs {
+		logf("shadow: %s differs: %s", shadowHost, d)
+	}
+	if len(diffs) == 0 && !quiet {
+		logf("shadow: %s matches", shadowHost)
+	}
+}
+
+// compareInstallations lists the differences between the discovery results
+// of the primary and shadow hosts. Installation IDs are host specific and are
+// not compared.
+func compareInstallations(primary *github.Installation, primaryErr error, shadow *github.Installation, shadowErr error) []string {
+	if primaryErr != nil || shadowErr != nil {
+		p, s := discoveryOutcome(primaryErr), discoveryOutcome(shadowErr)
+		if p == s {
+			return nil
+		}
+		return []string{fmt.Sprintf("primary %s, shadow %s", p, s)}
+	}
+
+	var diffs []string
+	diff := func(field, p, s string) {
+		if p != s {
+			diffs = append(diffs, fmt.Sprintf("%s: primary %s, shadow %s", field, orNone(p), orNone(s)))
+		}
+	}
+	diff("account", primary.GetAccount().GetLogin(), shadow.GetAccount().GetLogin())
+	diff("repository selection", primary.GetRepositorySelection(), shadow.GetRepositorySelection())
+	diff("permissions", installationPermissions(primary), installationPermissions(shadow))
+	diff("suspended", fmt.Sprint(primary.SuspendedAt != nil), fmt.Sprint(shadow.SuspendedAt != nil))
+	return diffs
+}
+
+// discoveryOutcome summarizes a discovery error for comparison, so that both
+// hosts reporting the app as not installed counts as a match.
+func discoveryOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "found the installation"
+	case errors.Is(err, app.ErrAppNotInstalled):
+		return "reports the app as not installed"
+	default:
+		return fmt.Sprintf("failed (%v)", err)
+	}
+}
+
+func installationPermissions(inst *github.Installation) string {
+	perms, err := appPermissions(inst.GetPermissions())
+	if err != nil {
+		return "(unknown)"
+	}
+	return strings.Join(perms, ", ")
+}
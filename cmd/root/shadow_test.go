@@ -0,0 +1,46 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/google/go-github/v72/github"
+)
+
+func TestCompareInstallations(t *testing.T) {
+	installation := func(login, selection string) *github.Installation {
+		return &github.Installation{
+			ID:                  github.Ptr(int64(1)),
+			Account:             &github.User{Login: github.Ptr(login)},
+			RepositorySelection: github.Ptr(selection),
+			Permissions:         &github.InstallationPermissions{Contents: github.Ptr("read")},
+		}
+	}
+	notInstalled := fmt.Errorf("failed to find organization installation: %w", app.ErrAppNotInstalled)
+
+	tests := []struct {
+		name       string
+		primary    *github.Installation
+		primaryErr error
+		shadow     *github.Installation
+		shadowErr  error
+		want       int
+	}{
+		{"same", installation("acme", "all"), nil, installation("acme", "all"), nil, 0},
+		{"different selection", installation("acme", "all"), nil, installation("acme", "selected"), nil, 1},
+		{"different account and selection", installation("acme", "all"), nil, installation("acme-emu", "selected"), nil, 2},
+		{"both not installed", nil, notInstalled, nil, notInstalled, 0},
+		{"missing on shadow", installation("acme", "all"), nil, nil, notInstalled, 1},
+		{"shadow failed", installation("acme", "all"), nil, nil, errors.New("connection refused"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareInstallations(tt.primary, tt.primaryErr, tt.shadow, tt.shadowErr)
+			if len(got) != tt.want {
+				t.Errorf("compareInstallations() = %q, want %d differences", got, tt.want)
+			}
+		})
+	}
+}
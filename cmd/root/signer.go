@@ -0,0 +1,92 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/buty4649/gh-app-token/pkg/auth/execsigner"
+	"golang.org/x/term"
+)
+
+var (
+	passphraseFile string
+	signerCmd      string
+)
+
+// loadSigner returns the signer for --signer-cmd, or for the key given by
+// --private-key-pem or --private-key.
+func loadSigner(ctx context.Context) (crypto.Signer, error) {
+	if signerCmd != "" {
+		return execsigner.New(ctx, signerCmd)
+	}
+	if privateKeyPEM == "" && strings.Contains(privateKeyPath, "://") {
+		return auth.LoadSigner(ctx, privateKeyPath)
+	}
+	return loadPrivateKey()
+}
+
+// loadPrivateKey is like loadSigner for commands that need the key material
+// itself, which rules out remote key sources. Encrypted keys are decrypted
+// with the passphrase from readPassphrase.
+func loadPrivateKey() (*auth.PrivateKey, error) {
+	if signerCmd != "" || privateKeyPEM == "" && strings.Contains(privateKeyPath, "://") {
+		return nil, fmt.Errorf("this command needs a local private key (--private-key <file> or --private-key-pem)")
+	}
+
+	data := []byte(privateKeyPEM)
+	if privateKeyPEM == "" {
+		var err error
+		data, err = os.ReadFile(privateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %w", err)
+		}
+	}
+
+	key, err := auth.ParsePrivateKey(data)
+	if errors.Is(err, auth.ErrPassphraseRequired) {
+		var passphrase []byte
+		if passphrase, err = readPassphrase(); err != nil {
+			return nil, err
+		}
+		key, err = auth.ParsePrivateKeyWithPassphrase(data, passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewPrivateKey(key), nil
+}
+
+// readPassphrase returns the key passphrase from --passphrase-file,
+// GH_APP_TOKEN_PASSPHRASE or, when stdin is a terminal, an interactive
+// prompt.
+func readPassphrase() ([]byte, error) {
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+
+	if env, ok := os.LookupEnv("GH_APP_TOKEN_PASSPHRASE"); ok {
+		return []byte(env), nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("%w (--passphrase-file or GH_APP_TOKEN_PASSPHRASE)", auth.ErrPassphraseRequired)
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase for private key: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return passphrase, nil
+}
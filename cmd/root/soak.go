@@ -0,0 +1,168 @@
+package root
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	soakDuration time.Duration
+	soakInterval time.Duration
+	soakRevoke   bool
+)
+
+var soakCmd = &cobra.Command{
+	Use:   "soak",
+	Short: "Repeatedly mint tokens to measure reliability",
+	Long: `Mint (and by default revoke) an installation token every --interval for
+--duration, then print latency and error statistics. Use it to check that a
+GitHub Enterprise Server or proxy is reliable before relying on it in
+production pipelines.
+
+Each attempt is logged to stderr unless --quiet is given. Press Ctrl-C to stop
+early; the statistics so far are still printed.`,
+	Example: `  gh app-token soak --app-id 12345 --private-key app.pem --org my-org --duration 2h --interval 1m`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFlags(); err != nil {
+			return err
+		}
+		if soakInterval <= 0 || soakDuration < soakInterval {
+			return fmt.Errorf("--interval must be positive and no longer than --duration")
+		}
+
+		appToken, _, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		id, err := resolveInstallationID(cmd.Context(), appToken)
+		if err != nil {
+			return fmt.Errorf("failed to get token: %w", withInstallURL(cmd.Context(), appToken, err))
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), soakDuration)
+		defer cancel()
+
+		var mint, revoke soakStats
+		ticker := time.NewTicker(soakInterval)
+		defer ticker.Stop()
+		for n := 1; ctx.Err() == nil; n++ {
+			var token string
+			soakAttempt(ctx, n, "mint", &mint, func() error {
+				t, err := appToken.CreateToken(ctx, id)
+				if err == nil {
+					token = t.Token
+				}
+				return err
+			})
+			if token != "" && soakRevoke {
+				soakAttempt(ctx, n, "revoke", &revoke, func() error {
+					_, err := appToken.RevokeToken(ctx, token)
+					return err
+				})
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+		}
+
+		if err := writeSoakStats(cmd.OutOrStdout(), map[string]*soakStats{"mint": &mint, "revoke": &revoke}); err != nil {
+			return err
+		}
+		if mint.errors > 0 || revoke.errors > 0 {
+			return fmt.Errorf("%d of %d attempts failed", mint.errors+revoke.errors, mint.count()+revoke.count())
+		}
+		return nil
+	},
+}
+
+// soakAttempt times fn and records the result in stats. Attempts cut short
+// by the end of the run are not counted.
+func soakAttempt(ctx context.Context, n int, op string, stats *soakStats, fn func() error) {
+	start := time.Now()
+	err := fn()
+	d := time.Since(start)
+	if ctx.Err() != nil {
+		return
+	}
+
+	stats.record(d, err)
+	if quiet {
+		return
+	}
+	if err != nil {
+		logf("#%d %s failed after %s: %v", n, op, d.Round(time.Millisecond), err)
+		return
+	}
+	logf("#%d %s %s", n, op, d.Round(time.Millisecond))
+}
+
+// soakStats collects the latencies of successful attempts and counts errors.
+type soakStats struct {
+	latencies []time.Duration
+	errors    int
+}
+
+func (s *soakStats) record(d time.Duration, err error) {
+	if err != nil {
+		s.errors++
+		return
+	}
+	s.latencies = append(s.latencies, d)
+}
+
+func (s *soakStats) count() int {
+	return len(s.latencies) + s.errors
+}
+
+// percentile returns the p-th percentile (0-100) of the recorded latencies
+// using the nearest-rank method.
+func (s *soakStats) percentile(p int) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(s.latencies)
+	slices.Sort(sorted)
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func writeSoakStats(w io.Writer, stats map[string]*soakStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OP\tATTEMPTS\tERRORS\tMIN\tP50\tP95\tMAX\t")
+	for _, op := range []string{"mint", "revoke"} {
+		s := stats[op]
+		if s == nil || s.count() == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", op, s.count(), s.errors,
+			s.percentile(0).Round(time.Millisecond),
+			s.percentile(50).Round(time.Millisecond),
+			s.percentile(95).Round(time.Millisecond),
+			s.percentile(100).Round(time.Millisecond),
+		)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	addTargetFlags(soakCmd)
+	soakCmd.Flags().DurationVar(&soakDuration, "duration", time.Hour, "How long to keep minting")
+	soakCmd.Flags().DurationVar(&soakInterval, "interval", time.Minute, "Time between attempts")
+	soakCmd.Flags().BoolVar(&soakRevoke, "revoke", true, "Revoke each token after minting it")
+	soakCmd.Flags().SortFlags = false
+
+	rootCmd.AddCommand(soakCmd)
+}
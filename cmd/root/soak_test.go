@@ -0,0 +1,59 @@
+package root
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSoakStats(t *testing.T) {
+	var s soakStats
+	for i := 1; i <= 20; i++ {
+		s.record(time.Duration(i)*time.Millisecond, nil)
+	}
+	s.record(time.Second, errors.New("boom"))
+
+	if got := s.count(); got != 21 {
+		t.Errorf("count() = %d, want 21", got)
+	}
+	if s.errors != 1 {
+		t.Errorf("errors = %d, want 1", s.errors)
+	}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{0, 1 * time.Millisecond},
+		{50, 10 * time.Millisecond},
+		{95, 19 * time.Millisecond},
+		{100, 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := s.percentile(tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	var empty soakStats
+	if got := empty.percentile(50); got != 0 {
+		t.Errorf("percentile() of no samples = %v, want 0", got)
+	}
+}
+
+func TestWriteSoakStats(t *testing.T) {
+	mint := &soakStats{latencies: []time.Duration{120 * time.Millisecond}}
+	var buf bytes.Buffer
+	if err := writeSoakStats(&buf, map[string]*soakStats{"mint": mint, "revoke": {}}); err != nil {
+		t.Fatalf("writeSoakStats() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "mint") || !strings.Contains(got, "120ms") {
+		t.Errorf("writeSoakStats() = %q, want a mint row", got)
+	}
+	if strings.Contains(got, "revoke") {
+		t.Errorf("writeSoakStats() = %q, want no revoke row without attempts", got)
+	}
+}
@@ -0,0 +1,359 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// statsRetention is how many days of statistics are kept.
+const statsRetention = 90
+
+var (
+	recordStats bool
+	statsDays   int
+	statsDaily  bool
+)
+
+// statsMu serializes the updates of the statistics file within the process,
+// as batch records its targets concurrently. lockFile serializes them across
+// processes.
+var statsMu sync.Mutex
+
+const (
+	// statsLockWait is how long an update waits for another process to
+	// release the lock of the statistics file.
+	statsLockWait = 5 * time.Second
+	// statsLockStale is the age at which a lock is taken to be left behind
+	// by a process that died while holding it.
+	statsLockStale = 30 * time.Second
+)
+
+// mintStats are the statistics recorded with --record-stats, by day
+// (YYYY-MM-DD, UTC) and then by target, e.g. "github.com org:acme", together
+// with the age of each private key used, by fingerprint.
+type mintStats struct {
+	Days map[string]map[string]*mintCount `json:"days"`
+	Keys map[string]*keyStat              `json:"keys,omitempty"`
+}
+
+// keyStat is the age of a private key as of its last use.
+type keyStat struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastUsed  time.Time `json:"last_used"`
+	// MaxAge is --max-key-age at the last use; zero without a policy
+	MaxAge int `json:"max_age,omitempty"`
+}
+
+// overAge reports whether the key was past its --max-key-age at now.
+func (k *keyStat) overAge(now time.Time) bool {
+	return k.MaxAge > 0 && keyAgeDays(k.FirstSeen, now) > k.MaxAge
+}
+
+// mintCount sums up the tokens minted for one target on one day.
+type mintCount struct {
+	Mints    int   `json:"mints"`
+	Failures int   `json:"failures"`
+	TotalMS  int64 `json:"total_ms"`
+	MaxMS    int64 `json:"max_ms"`
+}
+
+func (c *mintCount) add(o *mintCount) {
+	c.Mints += o.Mints
+	c.Failures += o.Failures
+	c.TotalMS += o.TotalMS
+	c.MaxMS = max(c.MaxMS, o.MaxMS)
+}
+
+func statsPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-app-token", "stats.json"), nil
+}
+
+// statsTarget returns the installation target of the flags, e.g.
+// org:acme, as it is recorded in the statistics.
+func statsTarget() string {
+	switch {
+	case org != "":
+		return batchTarget{"org", org}.String()
+	case repo != "":
+		return batchTarget{"repo", repo}.String()
+	case user != "":
+		return batchTarget{"user", user}.String()
+	case enterprise != "":
+		return batchTarget{"enterprise", enterprise}.String()
+	default:
+		return batchTarget{"installation", strconv.FormatInt(installationID, 10)}.String()
+	}
+}
+
+// recordMint adds a mint attempt for target that took elapsed and failed
+// when err is not nil. It does nothing without --record-stats; problems
+// with the file are logged and never fail.
+func recordMint(target string, elapsed time.Duration, err error) {
+	if !recordStats {
+		return
+	}
+	if err := addMintStats(apiHost()+" "+target, time.Now(), elapsed, err != nil); err != nil {
+		logf("warning: failed to record statistics: %v", err)
+	}
+}
+
+// recordKeyAge records the age of the key with fingerprint, first used at
+// first and used again at now, for the stats command. It does nothing
+// without --record-stats; problems with the file are logged and never fail.
+func recordKeyAge(fingerprint string, first, now time.Time) {
+	if !recordStats {
+		return
+	}
+	err := updateStats(now, func(stats *mintStats) {
+		stats.Keys[fingerprint] = &keyStat{FirstSeen: first.UTC(), LastUsed: now.UTC(), MaxAge: maxKeyAge}
+	})
+	if err != nil {
+		logf("warning: failed to record statistics: %v", err)
+	}
+}
+
+// addMintStats records a mint attempt for key on the day of now.
+func addMintStats(key string, now time.Time, elapsed time.Duration, failed bool) error {
+	return updateStats(now, func(stats *mintStats) {
+		day := now.UTC().Format(time.DateOnly)
+		if stats.Days[day] == nil {
+			stats.Days[day] = map[string]*mintCount{}
+		}
+		if stats.Days[day][key] == nil {
+			stats.Days[day][key] = &mintCount{}
+		}
+		c := &mintCount{Mints: 1, TotalMS: elapsed.Milliseconds(), MaxMS: elapsed.Milliseconds()}
+		if failed {
+			c.Failures = 1
+		}
+		stats.Days[day][key].add(c)
+	})
+}
+
+// updateStats applies update to the statistics file and drops the days and
+// keys older than statsRetention at now.
+func updateStats(now time.Time, update func(stats *mintStats)) error {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	path, err := statsPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path, statsLockWait, statsLockStale)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stats := &mintStats{}
+	if err := readJSONFile(path, stats); err != nil {
+		return err
+	}
+	if stats.Days == nil {
+		stats.Days = map[string]map[string]*mintCount{}
+	}
+	if stats.Keys == nil {
+		stats.Keys = map[string]*keyStat{}
+	}
+
+	update(stats)
+
+	oldest := now.UTC().AddDate(0, 0, -statsRetention)
+	for d := range stats.Days {
+		if d < oldest.Format(time.DateOnly) {
+			delete(stats.Days, d)
+		}
+	}
+	for fingerprint, k := range stats.Keys {
+		if k.LastUsed.Before(oldest) {
+			delete(stats.Keys, fingerprint)
+		}
+	}
+	return writeJSONFile(path, stats)
+}
+
+// lockFile takes an advisory lock on path, held by creating path.lock, so
+// that parallel runs do not overwrite each other's updates. It waits up to
+// wait for another holder, and breaks a lock older than stale.
+func lockFile(path string, wait, stale time.Duration) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > stale {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another process; remove it if no other run is recording", lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// statsRow is one line of the stats command; day is empty unless --daily.
+type statsRow struct {
+	day   string
+	key   string
+	count mintCount
+}
+
+// summarizeStats returns the rows of the last days days up to now, busiest
+// first, or by day and busiest first with daily.
+func summarizeStats(stats *mintStats, now time.Time, days int, daily bool) []statsRow {
+	oldest := now.UTC().AddDate(0, 0, 1-days).Format(time.DateOnly)
+
+	sums := map[[2]string]*mintCount{}
+	for day, targets := range stats.Days {
+		if day < oldest {
+			continue
+		}
+		if !daily {
+			day = ""
+		}
+		for key, c := range targets {
+			k := [2]string{day, key}
+			if sums[k] == nil {
+				sums[k] = &mintCount{}
+			}
+			sums[k].add(c)
+		}
+	}
+
+	rows := make([]statsRow, 0, len(sums))
+	for k, c := range sums {
+		rows = append(rows, statsRow{day: k[0], key: k[1], count: *c})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].day != rows[j].day {
+			return rows[i].day > rows[j].day
+		}
+		if rows[i].count.Mints != rows[j].count.Mints {
+			return rows[i].count.Mints > rows[j].count.Mints
+		}
+		return rows[i].key < rows[j].key
+	})
+	return rows
+}
+
+func writeStats(w io.Writer, rows []statsRow, daily bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if daily {
+		fmt.Fprint(tw, "DAY\t")
+	}
+	fmt.Fprintln(tw, "TARGET\tMINTS\tFAILURES\tAVG\tMAX")
+	for _, r := range rows {
+		if daily {
+			fmt.Fprintf(tw, "%s\t", r.day)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%dms\t%dms\n", r.key, r.count.Mints, r.count.Failures, r.count.TotalMS/int64(r.count.Mints), r.count.MaxMS)
+	}
+	return tw.Flush()
+}
+
+// writeKeyStats lists the private keys used in the last days days up to now,
+// oldest first, and flags those past the --max-key-age of their last use.
+func writeKeyStats(w io.Writer, keys map[string]*keyStat, now time.Time, days int) error {
+	oldest := now.UTC().AddDate(0, 0, -days)
+	fingerprints := make([]string, 0, len(keys))
+	for fingerprint, k := range keys {
+		if !k.LastUsed.Before(oldest) {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+	}
+	if len(fingerprints) == 0 {
+		return nil
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		return keys[fingerprints[i]].FirstSeen.Before(keys[fingerprints[j]].FirstSeen)
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nKEY\tFIRST SEEN\tAGE\tMAX AGE\tSTATUS")
+	for _, fingerprint := range fingerprints {
+		k := keys[fingerprint]
+		maxAge, status := "-", "-"
+		if k.MaxAge > 0 {
+			maxAge, status = fmt.Sprintf("%dd", k.MaxAge), "ok"
+			if k.overAge(now) {
+				status = "rotate"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%dd\t%s\t%s\n", fingerprint, k.FirstSeen.Format(time.DateOnly), keyAgeDays(k.FirstSeen, now), maxAge, status)
+	}
+	return tw.Flush()
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the tokens minted per target, recorded with --record-stats",
+	Long: `Show how many tokens were minted for each host and installation target, how
+many of them failed and how long they took, to spot the pipelines that burn
+the rate limit. The private keys used are listed with their age, and those
+older than the --max-key-age they were used with are marked for rotation.
+
+Statistics are only recorded on this machine, by runs with --record-stats
+(or GH_APP_TOKEN_RECORD_STATS=true, or record_stats in the config file), and
+kept for ` + strconv.Itoa(statsRetention) + ` days in the user cache directory.`,
+	Example: `  gh app-token stats --days 30
+  gh app-token stats --daily`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsDays < 1 {
+			return fmt.Errorf("--days must be at least 1")
+		}
+
+		path, err := statsPath()
+		if err != nil {
+			return err
+		}
+		stats := &mintStats{}
+		if err := readJSONFile(path, stats); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		rows := summarizeStats(stats, now, statsDays, statsDaily)
+		if len(rows) == 0 {
+			return fmt.Errorf("no statistics recorded in the last %d days; mint tokens with --record-stats", statsDays)
+		}
+		if err := writeStats(cmd.OutOrStdout(), rows, statsDaily); err != nil {
+			return err
+		}
+		return writeKeyStats(cmd.OutOrStdout(), stats.Keys, now, statsDays)
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&recordStats, "record-stats", false, "Record the count and duration of minted tokens per target for the stats command (env: GH_APP_TOKEN_RECORD_STATS)")
+
+	statsCmd.Flags().IntVar(&statsDays, "days", 7, "Number of days to show, including today")
+	statsCmd.Flags().BoolVar(&statsDaily, "daily", false, "Show a line per day and target instead of the total per target")
+	rootCmd.AddCommand(statsCmd)
+}
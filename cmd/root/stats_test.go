@@ -0,0 +1,97 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMintStats(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	day1 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	old := day1.AddDate(0, 0, -statsRetention-1)
+
+	for _, m := range []struct {
+		key     string
+		now     time.Time
+		elapsed time.Duration
+		failed  bool
+	}{
+		{"github.com org:old", old, time.Second, false},
+		{"github.com org:acme", day1, 100 * time.Millisecond, false},
+		{"github.com org:acme", day2, 300 * time.Millisecond, true},
+		{"github.com user:octocat", day2, 50 * time.Millisecond, false},
+	} {
+		if err := addMintStats(m.key, m.now, m.elapsed, m.failed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := statsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &mintStats{}
+	if err := readJSONFile(path, stats); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stats.Days[old.Format(time.DateOnly)]; ok {
+		t.Errorf("stats kept %s, want days past the retention dropped", old.Format(time.DateOnly))
+	}
+
+	var buf bytes.Buffer
+	if err := writeStats(&buf, summarizeStats(stats, day2, 7, false), false); err != nil {
+		t.Fatal(err)
+	}
+	want := `TARGET                   MINTS  FAILURES  AVG    MAX
+github.com org:acme      2      1         200ms  300ms
+github.com user:octocat  1      0         50ms   50ms
+`
+	if buf.String() != want {
+		t.Errorf("stats =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeStats(&buf, summarizeStats(stats, day2, 1, true), true); err != nil {
+		t.Fatal(err)
+	}
+	want = `DAY         TARGET                   MINTS  FAILURES  AVG    MAX
+2026-01-02  github.com org:acme      1      1         300ms  300ms
+2026-01-02  github.com user:octocat  1      0         50ms   50ms
+`
+	if buf.String() != want {
+		t.Errorf("daily stats =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+
+	unlock, err := lockFile(path, time.Second, time.Minute)
+	if err != nil {
+		t.Fatalf("lockFile() error = %v", err)
+	}
+	if _, err := lockFile(path, 50*time.Millisecond, time.Minute); err == nil {
+		t.Error("lockFile() succeeded while locked, want an error")
+	}
+	unlock()
+
+	unlock, err = lockFile(path, time.Second, time.Minute)
+	if err != nil {
+		t.Fatalf("lockFile() after unlock error = %v", err)
+	}
+	defer unlock()
+
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	relock, err := lockFile(path, 50*time.Millisecond, time.Minute)
+	if err != nil {
+		t.Fatalf("lockFile() of a stale lock error = %v", err)
+	}
+	relock()
+}
@@ -0,0 +1,219 @@
+package root
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusSHA         string
+	statusState       string
+	statusContext     string
+	statusDescription string
+	statusTargetURL   string
+
+	checkRunSHA        string
+	checkRunName       string
+	checkRunStatus     string
+	checkRunConclusion string
+	checkRunDetailsURL string
+	checkRunTitle      string
+	checkRunSummary    string
+)
+
+var (
+	statusStates        = []string{"error", "failure", "pending", "success"}
+	checkRunStatuses    = []string{"queued", "in_progress", "completed"}
+	checkRunConclusions = []string{"action_required", "cancelled", "failure", "neutral", "skipped", "success", "timed_out"}
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report commit statuses",
+}
+
+var statusSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set a commit status",
+	Long: `Set a commit status on --sha, e.g. from an external CI system. A token limited
+to statuses:write on --repo is minted for the request and revoked afterwards.`,
+	Example: `  gh app-token status set --app-id 12345 --private-key app.pem --repo owner/repo --sha "$SHA" \
+    --state success --context ci/build --target-url "$BUILD_URL"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(statusStates, statusState) {
+			return fmt.Errorf("invalid --state %q: must be %s", statusState, strings.Join(statusStates, ", "))
+		}
+
+		appToken, id, owner, name, err := newRepoAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		status := &github.RepoStatus{
+			State:       github.Ptr(statusState),
+			Context:     optional(statusContext),
+			Description: optional(statusDescription),
+			TargetURL:   optional(statusTargetURL),
+		}
+		if _, err := appToken.CreateStatus(cmd.Context(), id, owner, name, statusSHA, status); err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ set status %s on %s\n", statusState, statusSHA)
+		}
+		return nil
+	},
+}
+
+var checkRunCmd = &cobra.Command{
+	Use:   "check-run",
+	Short: "Report check runs",
+	Long: `Create and update check runs, e.g. from an external CI system. Each request
+uses a token limited to checks:write on --repo, revoked afterwards.`,
+}
+
+var checkRunCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a check run and print its ID",
+	Example: `  id=$(gh app-token check-run create --app-id 12345 --private-key app.pem --repo owner/repo \
+    --sha "$SHA" --name build --status in_progress)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateCheckRunFlags(); err != nil {
+			return err
+		}
+
+		appToken, id, owner, name, err := newRepoAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		run, err := appToken.CreateCheckRun(cmd.Context(), id, owner, name, github.CreateCheckRunOptions{
+			Name:       checkRunName,
+			HeadSHA:    checkRunSHA,
+			Status:     optional(checkRunStatus),
+			Conclusion: optional(checkRunConclusion),
+			DetailsURL: optional(checkRunDetailsURL),
+			Output:     checkRunOutput(),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), run.GetID())
+		return nil
+	},
+}
+
+var checkRunUpdateCmd = &cobra.Command{
+	Use:   "update <check-run-id>",
+	Short: "Update a check run",
+	Example: `  gh app-token check-run update "$id" --app-id 12345 --private-key app.pem --repo owner/repo \
+    --name build --conclusion success --title "All tests passed"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checkRunID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid check run ID %q", args[0])
+		}
+		if err := validateCheckRunFlags(); err != nil {
+			return err
+		}
+
+		appToken, id, owner, name, err := newRepoAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		run, err := appToken.UpdateCheckRun(cmd.Context(), id, owner, name, checkRunID, github.UpdateCheckRunOptions{
+			Name:       checkRunName,
+			Status:     optional(checkRunStatus),
+			Conclusion: optional(checkRunConclusion),
+			DetailsURL: optional(checkRunDetailsURL),
+			Output:     checkRunOutput(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ updated check run %d (%s)\n", run.GetID(), run.GetStatus())
+		}
+		return nil
+	},
+}
+
+func validateCheckRunFlags() error {
+	if checkRunStatus != "" && !slices.Contains(checkRunStatuses, checkRunStatus) {
+		return fmt.Errorf("invalid --status %q: must be %s", checkRunStatus, strings.Join(checkRunStatuses, ", "))
+	}
+	if checkRunConclusion != "" && !slices.Contains(checkRunConclusions, checkRunConclusion) {
+		return fmt.Errorf("invalid --conclusion %q: must be %s", checkRunConclusion, strings.Join(checkRunConclusions, ", "))
+	}
+	// GitHub completes the run when a conclusion is given
+	if checkRunConclusion != "" && checkRunStatus != "" && checkRunStatus != "completed" {
+		return fmt.Errorf("--conclusion requires --status completed")
+	}
+	if checkRunTitle == "" && checkRunSummary != "" || checkRunTitle != "" && checkRunSummary == "" {
+		return fmt.Errorf("--title and --summary must be given together")
+	}
+	return nil
+}
+
+// checkRunOutput returns the output for --title and --summary, if given.
+func checkRunOutput() *github.CheckRunOutput {
+	if checkRunTitle == "" {
+		return nil
+	}
+	return &github.CheckRunOutput{Title: github.Ptr(checkRunTitle), Summary: github.Ptr(checkRunSummary)}
+}
+
+// optional returns nil for an empty flag, so that it is left out of the
+// request.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func addCheckRunFlags(cmd *cobra.Command) {
+	addRepoFlag(cmd)
+	cmd.Flags().StringVar(&checkRunName, "name", "", "Name of the check")
+	cmd.Flags().StringVar(&checkRunStatus, "status", "", "Status: "+strings.Join(checkRunStatuses, ", "))
+	cmd.Flags().StringVar(&checkRunConclusion, "conclusion", "", "Conclusion of a completed run: "+strings.Join(checkRunConclusions, ", "))
+	cmd.Flags().StringVar(&checkRunDetailsURL, "details-url", "", "URL of the full details on the CI system")
+	cmd.Flags().StringVar(&checkRunTitle, "title", "", "Title of the check run output")
+	cmd.Flags().StringVar(&checkRunSummary, "summary", "", "Summary of the check run output (Markdown)")
+	cmd.Flags().SortFlags = false
+}
+
+func init() {
+	addRepoFlag(statusSetCmd)
+	statusSetCmd.Flags().StringVar(&statusSHA, "sha", "", "Commit SHA to set the status on")
+	statusSetCmd.Flags().StringVar(&statusState, "state", "", "State: "+strings.Join(statusStates, ", "))
+	statusSetCmd.Flags().StringVar(&statusContext, "context", "", "Label that tells this status apart from others (default: \"default\")")
+	statusSetCmd.Flags().StringVar(&statusDescription, "description", "", "Short description of the status")
+	statusSetCmd.Flags().StringVar(&statusTargetURL, "target-url", "", "URL of the build or report")
+	_ = statusSetCmd.MarkFlagRequired("sha")
+	_ = statusSetCmd.MarkFlagRequired("state")
+	statusSetCmd.Flags().SortFlags = false
+	statusCmd.AddCommand(statusSetCmd)
+
+	addCheckRunFlags(checkRunCreateCmd)
+	checkRunCreateCmd.Flags().StringVar(&checkRunSHA, "sha", "", "Commit SHA to create the check run for")
+	_ = checkRunCreateCmd.MarkFlagRequired("sha")
+	_ = checkRunCreateCmd.MarkFlagRequired("name")
+	addCheckRunFlags(checkRunUpdateCmd)
+	_ = checkRunUpdateCmd.MarkFlagRequired("name")
+	checkRunCmd.AddCommand(checkRunCreateCmd, checkRunUpdateCmd)
+
+	rootCmd.AddCommand(statusCmd, checkRunCmd)
+}
@@ -0,0 +1,29 @@
+package root
+
+import "testing"
+
+func TestValidateCheckRunFlags(t *testing.T) {
+	tests := []struct {
+		name                               string
+		status, conclusion, title, summary string
+		wantErr                            bool
+	}{
+		{name: "in progress", status: "in_progress"},
+		{name: "completed", status: "completed", conclusion: "success", title: "ok", summary: "All tests passed"},
+		{name: "conclusion only", conclusion: "failure"},
+		{name: "unknown status", status: "running", wantErr: true},
+		{name: "unknown conclusion", conclusion: "passed", wantErr: true},
+		{name: "conclusion while in progress", status: "in_progress", conclusion: "success", wantErr: true},
+		{name: "title without summary", title: "ok", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkRunStatus, checkRunConclusion, checkRunTitle, checkRunSummary = tt.status, tt.conclusion, tt.title, tt.summary
+			t.Cleanup(func() { checkRunStatus, checkRunConclusion, checkRunTitle, checkRunSummary = "", "", "", "" })
+
+			if err := validateCheckRunFlags(); (err != nil) != tt.wantErr {
+				t.Errorf("validateCheckRunFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
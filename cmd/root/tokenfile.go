@@ -0,0 +1,106 @@
+package root
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+// Encodings for --encoding.
+const (
+	encodingUTF8    = "utf8"
+	encodingUTF16LE = "utf16le"
+)
+
+var (
+	tokenFile string
+	crlf      bool
+	encoding  string
+)
+
+func validateTokenFileFlags() error {
+	switch encoding {
+	case "", encodingUTF8, encodingUTF16LE:
+	default:
+		return fmt.Errorf("--encoding must be %s or %s", encodingUTF8, encodingUTF16LE)
+	}
+
+	if tokenFile == "" && (crlf || encoding == encodingUTF16LE) {
+		return fmt.Errorf("--crlf and --encoding require --token-file")
+	}
+	return nil
+}
+
+// emitToken writes the token to --deliver or --token-file, or to stdout when
+// neither is set. timings are included in JSON output.
+func emitToken(token *app.Token, timings map[string]int64) error {
+	if deliver != "" {
+		return deliverToken(token)
+	}
+	if tokenFile == "" {
+		return writeToken(os.Stdout, token, timings)
+	}
+
+	var buf bytes.Buffer
+	if err := writeToken(&buf, token, timings); err != nil {
+		return err
+	}
+
+	data := encodeTokenFile(buf.Bytes(), crlf, encoding)
+	if err := writeFileAtomic(tokenFile, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+// encodeTokenFile applies --crlf and --encoding. UTF-16LE output starts with
+// a byte order mark, which legacy Windows tools expect.
+func encodeTokenFile(data []byte, crlf bool, encoding string) []byte {
+	if crlf {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	}
+
+	if encoding != encodingUTF16LE {
+		return data
+	}
+
+	units := utf16.Encode([]rune(string(data)))
+	out := make([]byte, 2, 2+2*len(units))
+	binary.LittleEndian.PutUint16(out, 0xfeff)
+	for _, u := range units {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+// writeFileAtomic replaces path with data so readers never see a partially
+// written token.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
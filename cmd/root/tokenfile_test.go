@@ -0,0 +1,83 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEncodeTokenFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		crlf     bool
+		encoding string
+		want     []byte
+	}{
+		{"utf8", false, encodingUTF8, []byte("ab\n")},
+		{"crlf", true, encodingUTF8, []byte("ab\r\n")},
+		{"utf16le", false, encodingUTF16LE, []byte{0xff, 0xfe, 'a', 0, 'b', 0, '\n', 0}},
+		{"utf16le crlf", true, encodingUTF16LE, []byte{0xff, 0xfe, 'a', 0, 'b', 0, '\r', 0, '\n', 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeTokenFile([]byte("ab\n"), tt.crlf, tt.encoding); !bytes.Equal(got, tt.want) {
+				t.Errorf("encodeTokenFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenFileFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenFile string
+		crlf      bool
+		encoding  string
+		wantErr   bool
+	}{
+		{"defaults", "", false, encodingUTF8, false},
+		{"token file with options", "token.txt", true, encodingUTF16LE, false},
+		{"crlf without token file", "", true, encodingUTF8, true},
+		{"utf16le without token file", "", false, encodingUTF16LE, true},
+		{"unknown encoding", "token.txt", false, "latin1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenFile, crlf, encoding = tt.tokenFile, tt.crlf, tt.encoding
+			defer func() { tokenFile, crlf, encoding = "", false, encodingUTF8 }()
+
+			if err := validateTokenFileFlags(); (err != nil) != tt.wantErr {
+				t.Errorf("validateTokenFileFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.txt")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 0o600); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v, want nil", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(got) != "new" {
+		t.Errorf("file content = %q, want %q", got, "new")
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", fi.Mode().Perm())
+	}
+}
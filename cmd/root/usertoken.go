@@ -0,0 +1,135 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	userClientSecret string
+	userRefreshToken string
+)
+
+var userTokenCmd = &cobra.Command{
+	Use:   "user-token",
+	Short: "Get a user access token with the device flow",
+	Long: `Get a user access token for the GitHub App with the OAuth device flow, for
+automations that must act as a user rather than as an installation.
+
+A one-time code is printed to stderr; enter it at the printed URL while signed
+in as the user. Once authorized, the token, the refresh token and their expiry
+times are printed to stdout as JSON.
+
+Only the app's client ID is needed, shown on the app settings page. Enable
+"Device Flow" in the app settings first.`,
+	Example: `  gh app-token user-token --client-id Iv1.0123456789abcdef > user-token.json`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ua, err := newUserAuth()
+		if err != nil {
+			return err
+		}
+
+		da, err := ua.DeviceCode(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "! First copy your one-time code: %s\n", da.UserCode)
+		fmt.Fprintf(cmd.ErrOrStderr(), "Then open %s and enter it to authorize the app.\n", da.VerificationURI)
+
+		token, err := ua.DeviceToken(cmd.Context(), da)
+		if err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintln(cmd.ErrOrStderr(), "✓ authorized")
+		}
+		return writeUserToken(cmd.OutOrStdout(), token)
+	},
+}
+
+var userTokenRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Exchange a refresh token for a new user access token",
+	Long: `Exchange a refresh token from user-token for a new user access token. The new
+token, refresh token and expiry times are printed as JSON in the same format
+as user-token.
+
+A refresh token can be used only once; store the new one it returns. GitHub
+requires the app's client secret for refreshing.`,
+	Example: `  gh app-token user-token refresh --client-id Iv1.0123456789abcdef --client-secret "$CLIENT_SECRET" \
+    --refresh-token "$(jq -r .refresh_token user-token.json)" > user-token.json.new`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if userRefreshToken == "" {
+			userRefreshToken = os.Getenv("GH_APP_TOKEN_REFRESH_TOKEN")
+		}
+		if userRefreshToken == "" {
+			return fmt.Errorf("refresh token is required (--refresh-token or GH_APP_TOKEN_REFRESH_TOKEN)")
+		}
+
+		ua, err := newUserAuth()
+		if err != nil {
+			return err
+		}
+		if userClientSecret == "" {
+			userClientSecret = os.Getenv("GH_APP_TOKEN_CLIENT_SECRET")
+		}
+		ua.WithClientSecret(userClientSecret)
+
+		token, err := ua.Refresh(cmd.Context(), userRefreshToken)
+		if err != nil {
+			return err
+		}
+		return writeUserToken(cmd.OutOrStdout(), token)
+	},
+}
+
+// newUserAuth returns a UserAuth for --client-id on apiHost().
+func newUserAuth() (*app.UserAuth, error) {
+	if clientID == "" {
+		clientID = os.Getenv("GH_APP_TOKEN_CLIENT_ID")
+	}
+	if clientID == "" {
+		return nil, fmt.Errorf("client ID is required (--client-id or GH_APP_TOKEN_CLIENT_ID)")
+	}
+	return app.NewUserAuth(clientID, fmt.Sprintf("https://%s/", apiHost())), nil
+}
+
+// userTokenJSON is the document user-token prints.
+type userTokenJSON struct {
+	Token                 string     `json:"token"`
+	ExpiresAt             *time.Time `json:"expires_at,omitempty"`
+	RefreshToken          string     `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at,omitempty"`
+}
+
+func writeUserToken(w io.Writer, token *app.UserToken) error {
+	doc := userTokenJSON{Token: token.Token, RefreshToken: token.RefreshToken}
+	if !token.ExpiresAt.IsZero() {
+		doc.ExpiresAt = &token.ExpiresAt
+	}
+	if !token.RefreshTokenExpiresAt.IsZero() {
+		doc.RefreshTokenExpiresAt = &token.RefreshTokenExpiresAt
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func init() {
+	userTokenRefreshCmd.Flags().StringVar(&userClientSecret, "client-secret", "", "Client secret of the GitHub App (env: GH_APP_TOKEN_CLIENT_SECRET)")
+	userTokenRefreshCmd.Flags().StringVar(&userRefreshToken, "refresh-token", "", "Refresh token printed by user-token (env: GH_APP_TOKEN_REFRESH_TOKEN)")
+
+	userTokenCmd.AddCommand(userTokenRefreshCmd)
+	rootCmd.AddCommand(userTokenCmd)
+}
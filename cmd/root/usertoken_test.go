@@ -0,0 +1,54 @@
+package root
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func TestWriteUserToken(t *testing.T) {
+	expiresAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		token *app.UserToken
+		want  string
+	}{
+		{
+			name: "expiring",
+			token: &app.UserToken{
+				Token:                 "ghu_user",
+				ExpiresAt:             expiresAt,
+				RefreshToken:          "ghr_refresh",
+				RefreshTokenExpiresAt: expiresAt,
+			},
+			want: `{
+  "token": "ghu_user",
+  "expires_at": "2025-01-02T03:04:05Z",
+  "refresh_token": "ghr_refresh",
+  "refresh_token_expires_at": "2025-01-02T03:04:05Z"
+}
+`,
+		},
+		{
+			name:  "non-expiring",
+			token: &app.UserToken{Token: "ghu_user"},
+			want: `{
+  "token": "ghu_user"
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeUserToken(&buf, tt.token); err != nil {
+				t.Fatalf("writeUserToken() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeUserToken() = %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package root
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/google/go-github/v72/github"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that the private key belongs to the app ID",
+	Long: `Sign an app JWT with the private key and call GET /app to check that the key
+belongs to --app-id. On success the app's name, owner and permissions are
+printed; on failure the cause is reported instead of a bare 401.`,
+	Example: `  gh app-token verify --app-id 12345 --private-key app.pem`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateAppFlags(); err != nil {
+			return err
+		}
+
+		appToken, signer, err := newAppToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+		fingerprint, err := auth.Fingerprint(signer.Public())
+		if err != nil {
+			return err
+		}
+
+		ghApp, err := appToken.GetApp(cmd.Context())
+		if errors.Is(err, app.ErrBadCredentials) {
+			return fmt.Errorf("private key %s was rejected for app %s on %s: %w", fingerprint, appIssuer(), apiHost(), err)
+		}
+		if err != nil {
+			return err
+		}
+
+		return writeAppInfo(cmd.OutOrStdout(), ghApp, fingerprint)
+	},
+}
+
+func writeAppInfo(w io.Writer, ghApp *github.App, fingerprint string) error {
+	fmt.Fprintf(w, "✓ private key matches app ID %d\n", ghApp.GetID())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "  Name:\t%s (%s)\n", ghApp.GetName(), ghApp.GetSlug())
+	fmt.Fprintf(tw, "  Owner:\t%s\n", ghApp.GetOwner().GetLogin())
+	fmt.Fprintf(tw, "  Key:\t%s\n", fingerprint)
+
+	perms, err := appPermissions(ghApp.GetPermissions())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tw, "  Permissions:\t%s\n", orNone(strings.Join(perms, ", ")))
+	fmt.Fprintf(tw, "  Events:\t%s\n", orNone(strings.Join(ghApp.Events, ", ")))
+	return tw.Flush()
+}
+
+// appPermissions formats the permissions the app requests as sorted
+// "name:level" pairs.
+func appPermissions(p *github.InstallationPermissions) ([]string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	perms := make([]string, 0, len(m))
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		perms = append(perms, name+":"+m[name])
+	}
+	return perms, nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
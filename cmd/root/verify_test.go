@@ -0,0 +1,52 @@
+package root
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestWriteAppInfo(t *testing.T) {
+	ghApp := &github.App{
+		ID:    github.Ptr(int64(12345)),
+		Slug:  github.Ptr("test-app"),
+		Name:  github.Ptr("Test App"),
+		Owner: &github.User{Login: github.Ptr("testorg")},
+		Permissions: &github.InstallationPermissions{
+			Metadata: github.Ptr("read"),
+			Contents: github.Ptr("write"),
+		},
+		Events: []string{"push"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeAppInfo(&buf, ghApp, "SHA256:abc"); err != nil {
+		t.Fatalf("writeAppInfo() error = %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"matches app ID 12345",
+		"Test App (test-app)",
+		"testorg",
+		"SHA256:abc",
+		"contents:write, metadata:read",
+		"push",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeAppInfo() output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestAppPermissions_none(t *testing.T) {
+	perms, err := appPermissions(nil)
+	if err != nil {
+		t.Fatalf("appPermissions() error = %v", err)
+	}
+	if len(perms) != 0 {
+		t.Errorf("appPermissions(nil) = %v, want none", perms)
+	}
+}
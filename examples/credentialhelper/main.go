@@ -0,0 +1,68 @@
+// Command credentialhelper is a git credential helper that answers with an
+// installation token limited to the repository being fetched or pushed.
+// Build it and wire it into git; credential.useHttpPath makes git pass the
+// repository path the token is scoped to:
+//
+//	go build -o /usr/local/bin/git-credential-myapp ./examples/credentialhelper
+//	git config --global credential.https://github.com.helper myapp
+//	git config --global credential.https://github.com.useHttpPath true
+//
+// The helper reads APP_ID and PRIVATE_KEY from the environment git runs it
+// with. gh app-token clone sets up the same thing with its git-credential
+// command.
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func main() {
+	// git also calls "store" and "erase"; tokens expire on their own
+	if len(os.Args) != 2 || os.Args[1] != "get" {
+		return
+	}
+
+	req := map[string]string{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			break
+		}
+		req[key] = value
+	}
+
+	owner, repo, ok := strings.Cut(strings.TrimSuffix(req["path"], ".git"), "/")
+	if req["protocol"] != "https" || !ok {
+		// Not ours to answer; git tries the next helper
+		return
+	}
+
+	appID, err := strconv.ParseInt(os.Getenv("APP_ID"), 10, 64)
+	if err != nil {
+		log.Fatalf("APP_ID: %v", err)
+	}
+	appToken, err := app.New(appID, os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if req["host"] != "github.com" {
+		if err := appToken.WithEnterprise(req["host"]); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	token, err := appToken.CreateTokenFromRepo(context.Background(), owner, repo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("username=x-access-token\npassword=%s\npassword_expiry_utc=%d\n", token.Token, token.ExpiresAt.Unix())
+}
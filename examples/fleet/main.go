@@ -0,0 +1,80 @@
+// Command fleet mints a read-only token for every installation of an app,
+// a few at a time, and prints when each expires. It is the starting point
+// for jobs that fan out over all the organizations an app is installed on.
+//
+//	APP_ID=12345 PRIVATE_KEY=app.pem go run ./examples/fleet
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+	"github.com/buty4649/gh-app-token/pkg/perm"
+	"github.com/google/go-github/v72/github"
+)
+
+// concurrency bounds the tokens minted at once, to stay clear of secondary
+// rate limits.
+const concurrency = 4
+
+func main() {
+	ctx := context.Background()
+
+	appID, err := strconv.ParseInt(os.Getenv("APP_ID"), 10, 64)
+	if err != nil {
+		log.Fatalf("APP_ID: %v", err)
+	}
+	appToken, err := app.New(appID, os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	set, err := perm.Permissions(perm.Metadata(perm.Read), perm.Contents(perm.Read))
+	if err != nil {
+		log.Fatal(err)
+	}
+	permissions, err := set.InstallationPermissions()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	installations, err := appToken.ListInstallations(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results := make([]string, len(installations))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, installation := range installations {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			account := installation.GetAccount().GetLogin()
+			token, err := appToken.CreateTokenWithOptions(ctx, installation.GetID(), &github.InstallationTokenOptions{
+				Permissions: permissions,
+			})
+			if err != nil {
+				results[i] = fmt.Sprintf("%s\terror: %v", account, err)
+				return
+			}
+			// token.Token is what a real job would use; printing token itself
+			// is safe, as it redacts the secret
+			results[i] = fmt.Sprintf("%s\t%v\texpires %s", account, token, token.ExpiresAt.Format(time.RFC3339))
+		}()
+	}
+	wg.Wait()
+
+	for _, line := range results {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,63 @@
+// Command webservice shows how a long-running service calls the GitHub API
+// as an app installation. The TokenSource mints a token on the first request
+// and reuses it until shortly before it expires, so requests never wait for
+// minting except once an hour.
+//
+//	APP_ID=12345 PRIVATE_KEY=app.pem INSTALLATION_ID=67890 go run ./examples/webservice
+//	curl localhost:8080/repos
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/buty4649/gh-app-token/pkg/app"
+)
+
+func main() {
+	appID, err := strconv.ParseInt(os.Getenv("APP_ID"), 10, 64)
+	if err != nil {
+		log.Fatalf("APP_ID: %v", err)
+	}
+	installationID, err := strconv.ParseInt(os.Getenv("INSTALLATION_ID"), 10, 64)
+	if err != nil {
+		log.Fatalf("INSTALLATION_ID: %v", err)
+	}
+
+	appToken, err := app.New(appID, os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Every request through client carries a current installation token
+	client := app.Wrap(nil, appToken.TokenSource(installationID))
+	api := appToken.BaseURL()
+
+	http.HandleFunc("/repos", func(w http.ResponseWriter, r *http.Request) {
+		resp, err := client.Get(api.JoinPath("installation/repositories").String())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		var body struct {
+			Repositories []struct {
+				FullName string `json:"full_name"`
+			} `json:"repositories"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		names := make([]string, 0, len(body.Repositories))
+		for _, repo := range body.Repositories {
+			names = append(names, repo.FullName)
+		}
+		_ = json.NewEncoder(w).Encode(names)
+	})
+
+	log.Fatal(http.ListenAndServe("localhost:8080", nil))
+}
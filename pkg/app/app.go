@@ -2,106 +2,541 @@ package app
 
 import (
 	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/buty4649/gh-app-token/pkg/auth"
 	"github.com/google/go-github/v72/github"
 )
 
+// AppToken mints installation tokens for a GitHub App. Each instance talks
+// to a single host, set with WithEnterprise, and does not consult the
+// environment, so instances for GHES and github.com can be used side by side.
 type AppToken struct {
-	client *github.Client
+	client    *github.Client
+	transport *jwtTransport
+
+	cache       Cache
+	cacheTTL    time.Duration
+	retryPolicy RetryPolicy
+
+	mu               sync.Mutex
+	installationKeys map[int64]string
 }
 
 func New(appID int64, privateKeyFile string) (*AppToken, error) {
-	jwt, err := generateJWT(appID, privateKeyFile)
+	privateKey, err := auth.LoadPrivateKey(privateKeyFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	client := github.NewClient(nil).WithAuthToken(jwt)
+	return NewFromKey(appID, privateKey)
+}
 
-	return &AppToken{
-		client: client,
-	}, nil
+// NewFromKey creates an AppToken from an already parsed private key.
+func NewFromKey(appID int64, privateKey *rsa.PrivateKey) (*AppToken, error) {
+	if privateKey == nil {
+		return nil, fmt.Errorf("failed to create client: private key is required")
+	}
+
+	return NewFromSigner(appID, privateKey)
 }
 
-func generateJWT(appID int64, privateKeyFile string) (string, error) {
-	keyBytes, err := os.ReadFile(privateKeyFile)
+// NewFromSigner creates an AppToken whose JWT is signed by signer, e.g. a key
+// held in an HSM or KMS that cannot be exported.
+func NewFromSigner(appID int64, signer crypto.Signer) (*AppToken, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("failed to create client: signer is required")
+	}
+
+	return newAppToken(strconv.FormatInt(appID, 10), signer)
+}
+
+// NewFromSignerWithClientID is like NewFromSigner but identifies the app by
+// its client ID (Iv1.... or Iv23...) instead of its numeric ID, as GitHub
+// recommends for the iss claim of new integrations.
+func NewFromSignerWithClientID(clientID string, signer crypto.Signer) (*AppToken, error) {
+	if clientID == "" {
+		return nil, fmt.Errorf("failed to create client: client ID is required")
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("failed to create client: signer is required")
+	}
+
+	return newAppToken(clientID, signer)
+}
+
+// NewFromPEM creates an AppToken from private key material held in memory,
+// e.g. fetched from a secrets manager. Any format accepted by
+// auth.ParsePrivateKey can be used.
+func NewFromPEM(appID int64, privateKey []byte) (*AppToken, error) {
+	key, err := auth.ParsePrivateKey(privateKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to read private key file: %w", err)
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyBytes)
+	return NewFromKey(appID, key)
+}
+
+// NewFromReader creates an AppToken from private key material read from r.
+func NewFromReader(appID int64, r io.Reader) (*AppToken, error) {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return "", fmt.Errorf("failed to load private key: %w", err)
+		return nil, fmt.Errorf("failed to read private key: %w", err)
 	}
 
-	now := time.Now().Add(-1 * time.Minute)
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
-		Issuer:    strconv.FormatInt(appID, 10),
-		IssuedAt:  jwt.NewNumericDate(now),
-		ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
-	})
-	return token.SignedString(privateKey)
+	return NewFromPEM(appID, data)
+}
+
+func newAppToken(issuer string, signer crypto.Signer) (*AppToken, error) {
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("failed to create client: %w: GitHub Apps require an RSA key", auth.ErrInvalidKey)
+	}
+
+	// Keep raw keys out of anything that might print the transport
+	transport, err := newJWTTransport(sharedTransport, issuer, auth.RedactSigner(signer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	client := github.NewClient(&http.Client{Transport: transport})
+	transport.host = client.BaseURL.Host
+	return &AppToken{
+		client:    client,
+		transport: transport,
+	}, nil
 }
 
+// WithEnterprise points the AppToken at a GitHub Enterprise Server. baseURL
+// may be a bare hostname (ghe.example.com, as gh --hostname takes), the
+// server URL or its REST API root ending in /api/v3; the API and upload URLs
+// are derived from it.
 func (a *AppToken) WithEnterprise(baseURL string) error {
-	client, err := a.client.WithEnterpriseURLs(baseURL, baseURL)
+	client, err := enterpriseClient(a.client, baseURL)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
 	a.client = client
+	a.transport.host = client.BaseURL.Host
 	return nil
 }
 
+// enterpriseClient returns a copy of client pointed at the server given as
+// for WithEnterprise.
+func enterpriseClient(client *github.Client, baseURL string) (*github.Client, error) {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid GitHub Enterprise Server URL %q", baseURL)
+	}
+
+	root := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v3")
+	apiURL, uploadURL := *u, *u
+	apiURL.Path = root + "/api/v3/"
+	uploadURL.Path = root + "/api/uploads/"
+	return client.WithEnterpriseURLs(apiURL.String(), uploadURL.String())
+}
+
+// BaseURL returns the REST API endpoint the AppToken talks to.
+func (a *AppToken) BaseURL() *url.URL {
+	u := *a.client.BaseURL
+	return &u
+}
+
+// Client returns an HTTP client that authenticates every request with the app
+// JWT, for app-level endpoints such as /app/hook/deliveries that do not accept
+// installation tokens.
+func (a *AppToken) Client() *http.Client {
+	return a.client.Client()
+}
+
+// TokenClient returns an HTTP client that authenticates requests to the
+// AppToken's API host with tokens from src, over the same pooled connections
+// as the AppToken. Requests to other hosts are sent without them.
+func (a *AppToken) TokenClient(src TokenSource) *http.Client {
+	return wrap(&http.Client{Transport: a.transport.base}, src, a.client.BaseURL.Host)
+}
+
 func (a *AppToken) GetToken(ctx context.Context, installationID int64) (string, error) {
-	t, _, err := a.client.Apps.CreateInstallationToken(ctx, installationID, nil)
+	t, err := a.CreateToken(ctx, installationID)
 	if err != nil {
-		return "", fmt.Errorf("failed to create installation token: %w", err)
+		return "", err
 	}
 
-	return t.GetToken(), nil
+	return t.Token, nil
 }
 
 func (a *AppToken) GetTokenFromOrg(ctx context.Context, org string) (string, error) {
+	t, err := a.CreateTokenFromOrg(ctx, org)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Token, nil
+}
+
+func (a *AppToken) GetTokenFromRepo(ctx context.Context, owner, repo string) (string, error) {
+	t, err := a.CreateTokenFromRepo(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Token, nil
+}
+
+func (a *AppToken) GetTokenFromUser(ctx context.Context, user string) (string, error) {
+	t, err := a.CreateTokenFromUser(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Token, nil
+}
+
+// JWT returns the app JWT for the configured host, signing a new one unless
+// a cached JWT is still valid. Requests reuse it, so calling JWT first
+// separates the signing time from the request latency. The JWT prints
+// redacted; use its Reveal method for the encoded token.
+func (a *AppToken) JWT() (auth.JWT, error) {
+	token, err := a.transport.jwt(a.client.BaseURL.Host)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app JWT: %w", err)
+	}
+	return token, nil
+}
+
+// CreateToken mints an installation token and returns it with its expiry,
+// permissions and repository selection.
+func (a *AppToken) CreateToken(ctx context.Context, installationID int64) (*Token, error) {
+	return a.CreateTokenWithOptions(ctx, installationID, nil)
+}
+
+// CreateTokenWithOptions is like CreateToken but narrows the token to the
+// repositories and permissions in opts. A nil opts mints a token with all of
+// the installation's access.
+func (a *AppToken) CreateTokenWithOptions(ctx context.Context, installationID int64, opts *github.InstallationTokenOptions) (*Token, error) {
+	u := fmt.Sprintf("app/installations/%v/access_tokens", installationID)
+	t := new(installationToken)
+	err := a.retry(ctx, func() error {
+		// The body is consumed by each attempt
+		req, err := a.client.NewRequest("POST", u, opts)
+		if err != nil {
+			return err
+		}
+		_, err = a.client.Do(ctx, req, t)
+		return classifyError(err, ErrInstallationNotFound)
+	})
+	if err != nil {
+		if errors.Is(err, ErrInstallationNotFound) {
+			a.forgetInstallation(installationID)
+		}
+		return nil, fmt.Errorf("failed to create installation token: %w", err)
+	}
+
+	return newToken(t), nil
+}
+
+// RevokeToken revokes an installation token before it expires and reports
+// whether it was still live. A token GitHub no longer accepts, because it has
+// expired or was revoked already, is not an error, so cleanup code can call
+// RevokeToken unconditionally. Failures are retried as set by
+// WithRetryPolicy.
+func (a *AppToken) RevokeToken(ctx context.Context, token string) (revoked bool, err error) {
+	client := github.NewClient(&http.Client{Transport: sharedTransport}).WithAuthToken(token)
+	client.BaseURL = a.client.BaseURL
+
+	err = a.retry(ctx, func() error {
+		_, err := client.Apps.RevokeInstallationToken(ctx)
+		return classifyError(err, nil)
+	})
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrBadCredentials):
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to revoke installation token: %w", err)
+}
+
+func (a *AppToken) CreateTokenFromOrg(ctx context.Context, org string) (*Token, error) {
+	installation, err := a.FindOrgInstallation(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.CreateToken(ctx, installation.GetID())
+}
+
+// FindOrgInstallation looks up the app's installation on the organization.
+func (a *AppToken) FindOrgInstallation(ctx context.Context, org string) (*github.Installation, error) {
 	if org == "" {
-		return "", fmt.Errorf("org name is required")
+		return nil, fmt.Errorf("org name is required")
 	}
 
-	installation, _, err := a.client.Apps.FindOrganizationInstallation(ctx, org)
+	return a.cachedInstallation("org/"+org, func() (*github.Installation, error) {
+		var installation *github.Installation
+		err := a.retry(ctx, func() error {
+			var err error
+			installation, _, err = a.client.Apps.FindOrganizationInstallation(ctx, org)
+			return classifyError(err, ErrAppNotInstalled)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find organization installation: %w", err)
+		}
+		return installation, nil
+	})
+}
+
+func (a *AppToken) CreateTokenFromRepo(ctx context.Context, owner, repo string) (*Token, error) {
+	installation, err := a.FindRepoInstallation(ctx, owner, repo)
 	if err != nil {
-		return "", fmt.Errorf("failed to find organization installation: %w", err)
+		return nil, err
 	}
 
-	return a.GetToken(ctx, installation.GetID())
+	return a.CreateToken(ctx, installation.GetID())
 }
 
-func (a *AppToken) GetTokenFromRepo(ctx context.Context, owner, repo string) (string, error) {
+// FindRepoInstallation looks up the app's installation on the repository.
+func (a *AppToken) FindRepoInstallation(ctx context.Context, owner, repo string) (*github.Installation, error) {
 	if owner == "" || repo == "" {
-		return "", fmt.Errorf("owner and repo name are required")
+		return nil, fmt.Errorf("owner and repo name are required")
 	}
 
-	installation, _, err := a.client.Apps.FindRepositoryInstallation(ctx, owner, repo)
+	return a.cachedInstallation("repo/"+owner+"/"+repo, func() (*github.Installation, error) {
+		var installation *github.Installation
+		err := a.retry(ctx, func() error {
+			var err error
+			installation, _, err = a.client.Apps.FindRepositoryInstallation(ctx, owner, repo)
+			return classifyError(err, ErrAppNotInstalled)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find repository installation: %w", err)
+		}
+		return installation, nil
+	})
+}
+
+func (a *AppToken) CreateTokenFromUser(ctx context.Context, user string) (*Token, error) {
+	installation, err := a.FindUserInstallation(ctx, user)
 	if err != nil {
-		return "", fmt.Errorf("failed to find repository installation: %w", err)
+		return nil, err
 	}
 
-	return a.GetToken(ctx, installation.GetID())
+	return a.CreateToken(ctx, installation.GetID())
 }
 
-func (a *AppToken) GetTokenFromUser(ctx context.Context, user string) (string, error) {
+// FindUserInstallation looks up the app's installation on the user account.
+func (a *AppToken) FindUserInstallation(ctx context.Context, user string) (*github.Installation, error) {
 	if user == "" {
-		return "", fmt.Errorf("user name is required")
+		return nil, fmt.Errorf("user name is required")
+	}
+
+	return a.cachedInstallation("user/"+user, func() (*github.Installation, error) {
+		var installation *github.Installation
+		err := a.retry(ctx, func() error {
+			var err error
+			installation, _, err = a.client.Apps.FindUserInstallation(ctx, user)
+			return classifyError(err, ErrAppNotInstalled)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user installation: %w", err)
+		}
+		return installation, nil
+	})
+}
+
+func (a *AppToken) GetTokenFromEnterprise(ctx context.Context, enterprise string) (string, error) {
+	t, err := a.CreateTokenFromEnterprise(ctx, enterprise)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Token, nil
+}
+
+func (a *AppToken) CreateTokenFromEnterprise(ctx context.Context, enterprise string) (*Token, error) {
+	installation, err := a.FindEnterpriseInstallation(ctx, enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.CreateToken(ctx, installation.GetID())
+}
+
+// FindEnterpriseInstallation looks up the app's installation on the
+// enterprise account with the given slug. There is no endpoint for it like
+// for organizations, so the app's installations are searched.
+func (a *AppToken) FindEnterpriseInstallation(ctx context.Context, enterprise string) (*github.Installation, error) {
+	if enterprise == "" {
+		return nil, fmt.Errorf("enterprise slug is required")
+	}
+
+	// Slugs are case-insensitive
+	return a.cachedInstallation("enterprise/"+strings.ToLower(enterprise), func() (*github.Installation, error) {
+		for page := 1; page != 0; {
+			u := fmt.Sprintf("app/installations?per_page=%d&page=%d", maxPerPage, page)
+			req, err := a.client.NewRequest("GET", u, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find enterprise installation: %w", err)
+			}
+			var body json.RawMessage
+			var resp *github.Response
+			err = a.retry(ctx, func() error {
+				var err error
+				resp, err = a.client.Do(ctx, req, &body)
+				return classifyError(err, nil)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to find enterprise installation: %w", err)
+			}
+
+			// go-github decodes accounts as users, which have no slug
+			var installations []*github.Installation
+			var accounts []struct {
+				Account struct {
+					Slug string `json:"slug"`
+				} `json:"account"`
+			}
+			if err := json.Unmarshal(body, &installations); err != nil {
+				return nil, fmt.Errorf("failed to find enterprise installation: %w", err)
+			}
+			if err := json.Unmarshal(body, &accounts); err != nil {
+				return nil, fmt.Errorf("failed to find enterprise installation: %w", err)
+			}
+			for i, installation := range installations {
+				if installation.GetTargetType() == "Enterprise" && strings.EqualFold(accounts[i].Account.Slug, enterprise) {
+					return installation, nil
+				}
+			}
+			page = resp.NextPage
+		}
+
+		return nil, fmt.Errorf("failed to find enterprise installation: %w on enterprise %s", ErrAppNotInstalled, enterprise)
+	})
+}
+
+// GetApp returns the metadata of the authenticated app (GET /app). It is also
+// a cheap way to check that the app ID and private key belong together.
+func (a *AppToken) GetApp(ctx context.Context) (*github.App, error) {
+	app, _, err := a.client.Apps.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app: %w", classifyError(err, nil))
+	}
+
+	return app, nil
+}
+
+// AppIdentity is what identifies an app as the issuer of its JWTs.
+// go-github's App lacks the client ID.
+type AppIdentity struct {
+	ID       int64  `json:"id"`
+	ClientID string `json:"client_id"`
+	Slug     string `json:"slug"`
+}
+
+// FindAppBySlug looks up an app by the slug in its URL (GET /apps/{slug}),
+// to learn its ID and client ID before any JWT can be signed. baseURL is
+// given as for WithEnterprise, or "" for github.com. Public apps need no
+// token; private ones need a token of a user or installation that can see
+// them.
+func FindAppBySlug(ctx context.Context, baseURL, slug, token string) (*AppIdentity, error) {
+	if slug == "" {
+		return nil, fmt.Errorf("app slug is required")
+	}
+
+	client := github.NewClient(&http.Client{Transport: sharedTransport})
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	if baseURL != "" {
+		var err error
+		if client, err = enterpriseClient(client, baseURL); err != nil {
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
+	}
+
+	req, err := client.NewRequest("GET", "apps/"+url.PathEscape(slug), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find app %s: %w", slug, err)
+	}
+	app := new(AppIdentity)
+	if _, err := client.Do(ctx, req, app); err != nil {
+		return nil, fmt.Errorf("failed to find app %s: %w", slug, classifyError(err, ErrAppNotFound))
+	}
+
+	return app, nil
+}
+
+// InstallationURL returns the page where the app can be installed on a new
+// account (https://github.com/apps/<slug>/installations/new, or the GHES
+// equivalent).
+func (a *AppToken) InstallationURL(ctx context.Context) (string, error) {
+	app, err := a.GetApp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(app.GetHTMLURL(), "/") + "/installations/new", nil
+}
+
+// ServerVersion returns the GitHub Enterprise Server release the client
+// talks to, such as "3.12.4", or an empty string for github.com.
+func (a *AppToken) ServerVersion(ctx context.Context) (string, error) {
+	return cached(a, "version", func() (string, error) {
+		var meta struct {
+			InstalledVersion string `json:"installed_version"`
+		}
+		if _, err := a.getMeta(ctx, &meta); err != nil {
+			return "", fmt.Errorf("failed to get server version: %w", err)
+		}
+		return meta.InstalledVersion, nil
+	})
+}
+
+// ServerTime returns the time in the Date header of a response from the
+// host, to compare the local clock against the one JWTs are checked with.
+func (a *AppToken) ServerTime(ctx context.Context) (time.Time, error) {
+	resp, err := a.getMeta(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get server time: %w", err)
 	}
 
-	installation, _, err := a.client.Apps.FindUserInstallation(ctx, user)
+	t, err := http.ParseTime(resp.Header.Get("Date"))
 	if err != nil {
-		return "", fmt.Errorf("failed to find user installation: %w", err)
+		return time.Time{}, fmt.Errorf("failed to get server time: invalid Date header: %w", err)
 	}
+	return t, nil
+}
+
+// getMeta calls GET /meta. The endpoint needs no authentication, so the
+// request is sent without a JWT and works even when the key is wrong.
+func (a *AppToken) getMeta(ctx context.Context, v any) (*github.Response, error) {
+	client := github.NewClient(&http.Client{Transport: sharedTransport})
+	client.BaseURL = a.client.BaseURL
 
-	return a.GetToken(ctx, installation.GetID())
+	req, err := client.NewRequest("GET", "meta", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(ctx, req, v)
+	if err != nil {
+		return nil, classifyError(err, nil)
+	}
+	return resp, nil
 }
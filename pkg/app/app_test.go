@@ -1,16 +1,23 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
 )
 
 type mockServer struct {
@@ -30,7 +37,7 @@ func setupMockServer() *mockServer {
 
 	mux.HandleFunc("/app/installations/123/access_tokens", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
-		if _, err := w.Write([]byte(`{"token":"mocked_token"}`)); err != nil {
+		if _, err := w.Write([]byte(`{"token":"mocked_token","expires_at":"2030-01-01T00:00:00Z","permissions":{"contents":"read"},"repository_selection":"all"}`)); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
@@ -56,6 +63,30 @@ func setupMockServer() *mockServer {
 		}
 	})
 
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(`{"id":12345,"slug":"test-app","name":"Test App","owner":{"login":"testorg"},"html_url":"https://github.com/apps/test-app"}`)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/app/installations/401/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		if _, err := w.Write([]byte(`{"message":"Bad credentials"}`)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/orgs/ratelimited/installation", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1")
+		w.WriteHeader(http.StatusForbidden)
+		if _, err := w.Write([]byte(`{"message":"API rate limit exceeded"}`)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
 	return &mockServer{
 		Server: httptest.NewServer(mux),
 	}
@@ -68,6 +99,27 @@ func setMockServerURL(t *testing.T, app *AppToken) {
 		t.Fatalf("Failed to parse server URL: %v", err)
 	}
 	app.client.BaseURL = baseURL
+	app.transport.host = baseURL.Host
+}
+
+func newTestApp(t *testing.T, handler http.Handler) *AppToken {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	app, err := NewFromKey(12345, privateKey)
+	if err != nil {
+		t.Fatalf("NewFromKey() error: %v", err)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	if err := app.WithEnterprise(srv.URL + "/"); err != nil {
+		t.Fatalf("WithEnterprise() error: %v", err)
+	}
+	return app
 }
 
 func setupTestPrivateKey(t *testing.T) (*rsa.PrivateKey, string) {
@@ -105,7 +157,7 @@ func setupTestPrivateKey(t *testing.T) (*rsa.PrivateKey, string) {
 	return privateKey, tmpFile.Name()
 }
 
-func Test_generateJWT(t *testing.T) {
+func TestNew(t *testing.T) {
 	_, keyPath := setupTestPrivateKey(t)
 	defer func() {
 		if err := os.Remove(keyPath); err != nil {
@@ -113,44 +165,91 @@ func Test_generateJWT(t *testing.T) {
 		}
 	}()
 
-	tests := []struct {
-		name    string
-		appID   int64
-		keyPath string
-		wantErr bool
-	}{
-		{"valid", 12345, keyPath, false},
-		{"invalid file", 12345, "notfound.pem", true},
+	_, err := New(12345, keyPath)
+	if err != nil {
+		t.Errorf("New() error = %v, want nil", err)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			token, err := generateJWT(tt.appID, tt.keyPath)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("generateJWT() error = %v, wantErr %v", err, tt.wantErr)
-			}
-			if !tt.wantErr && token == "" {
-				t.Error("generateJWT() returned empty token")
-			}
-		})
+
+	_, err = New(12345, "notfound.pem")
+	if err == nil {
+		t.Error("New() error = nil, want error for missing key file")
 	}
 }
 
-func TestNew(t *testing.T) {
-	_, keyPath := setupTestPrivateKey(t)
+func TestNewFromKey(t *testing.T) {
+	privateKey, keyPath := setupTestPrivateKey(t)
 	defer func() {
 		if err := os.Remove(keyPath); err != nil {
 			t.Errorf("Failed to remove key file: %v", err)
 		}
 	}()
 
-	_, err := New(12345, keyPath)
+	if _, err := NewFromKey(12345, privateKey); err != nil {
+		t.Errorf("NewFromKey() error = %v, want nil", err)
+	}
+	if _, err := NewFromKey(12345, nil); err == nil {
+		t.Error("NewFromKey() error = nil, want error for nil key")
+	}
+	if _, err := NewFromSigner(12345, privateKey); err != nil {
+		t.Errorf("NewFromSigner() error = %v, want nil", err)
+	}
+	if _, err := NewFromSigner(12345, nil); err == nil {
+		t.Error("NewFromSigner() error = nil, want error for nil signer")
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
 	if err != nil {
-		t.Errorf("New() error = %v, want nil", err)
+		t.Fatalf("Failed to read key file: %v", err)
+	}
+	if _, err := NewFromPEM(12345, keyPEM); err != nil {
+		t.Errorf("NewFromPEM() error = %v, want nil", err)
 	}
+	if _, err := NewFromPEM(12345, []byte("not a key")); err == nil {
+		t.Error("NewFromPEM() error = nil, want error for invalid key")
+	}
+	if _, err := NewFromReader(12345, bytes.NewReader(keyPEM)); err != nil {
+		t.Errorf("NewFromReader() error = %v, want nil", err)
+	}
+}
 
-	_, err = New(12345, "notfound.pem")
-	if err == nil {
-		t.Error("New() error = nil, want error for missing key file")
+func TestAppToken_WithEnterprise(t *testing.T) {
+	privateKey, keyPath := setupTestPrivateKey(t)
+	defer os.Remove(keyPath)
+
+	tests := []struct {
+		baseURL    string
+		wantAPI    string
+		wantUpload string
+	}{
+		{"ghe.example.com", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		{"https://ghe.example.com", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		{"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		{"http://localhost:8080/api/v3", "http://localhost:8080/api/v3/", "http://localhost:8080/api/uploads/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			app, err := NewFromKey(12345, privateKey)
+			if err != nil {
+				t.Fatalf("NewFromKey() error = %v", err)
+			}
+			if err := app.WithEnterprise(tt.baseURL); err != nil {
+				t.Fatalf("WithEnterprise() error = %v", err)
+			}
+			if got := app.client.BaseURL.String(); got != tt.wantAPI {
+				t.Errorf("BaseURL = %s, want %s", got, tt.wantAPI)
+			}
+			if got := app.client.UploadURL.String(); got != tt.wantUpload {
+				t.Errorf("UploadURL = %s, want %s", got, tt.wantUpload)
+			}
+		})
+	}
+
+	app, err := NewFromKey(12345, privateKey)
+	if err != nil {
+		t.Fatalf("NewFromKey() error = %v", err)
+	}
+	if err := app.WithEnterprise("https:///api/v3"); err == nil {
+		t.Error("WithEnterprise() error = nil, want error for a URL without host")
 	}
 }
 
@@ -352,6 +451,42 @@ func TestAppToken_GetTokenFromUser(t *testing.T) {
 	}
 }
 
+func TestAppToken_GetTokenFromEnterprise(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("Link", `<`+r.URL.Path+`?per_page=100&page=2>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"id":1,"target_type":"Organization","account":{"login":"acme"}}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"id":2,"target_type":"Organization","account":{"login":"other"}},
+			{"id":7,"target_type":"Enterprise","account":{"slug":"acme","name":"Acme Inc."}}
+		]`))
+	})
+	mux.HandleFunc("/api/v3/app/installations/7/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_enterprise","expires_at":"2030-01-01T00:00:00Z"}`))
+	})
+	app := newTestApp(t, mux)
+	ctx := context.Background()
+
+	got, err := app.GetTokenFromEnterprise(ctx, "ACME")
+	if err != nil {
+		t.Fatalf("GetTokenFromEnterprise() error = %v", err)
+	}
+	if got != "ghs_enterprise" {
+		t.Errorf("GetTokenFromEnterprise() = %q, want the token of installation 7", got)
+	}
+
+	if _, err := app.GetTokenFromEnterprise(ctx, "other"); !errors.Is(err, ErrAppNotInstalled) {
+		t.Errorf("GetTokenFromEnterprise() error = %v, want ErrAppNotInstalled for an organization of the same name", err)
+	}
+	if _, err := app.GetTokenFromEnterprise(ctx, ""); err == nil {
+		t.Error("GetTokenFromEnterprise() error = nil, want error for an empty slug")
+	}
+}
+
 func TestAppToken_GetToken(t *testing.T) {
 	_, keyPath := setupTestPrivateKey(t)
 	defer func() {
@@ -400,3 +535,272 @@ func TestAppToken_GetToken(t *testing.T) {
 		})
 	}
 }
+
+func TestAppToken_CreateToken(t *testing.T) {
+	_, keyPath := setupTestPrivateKey(t)
+	defer func() {
+		if err := os.Remove(keyPath); err != nil {
+			t.Errorf("Failed to remove key file: %v", err)
+		}
+	}()
+	app, err := New(12345, keyPath)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	setMockServerURL(t, app)
+
+	got, err := app.CreateToken(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v, want nil", err)
+	}
+	if got.Token != "mocked_token" {
+		t.Errorf("CreateToken().Token = %v, want %v", got.Token, "mocked_token")
+	}
+	if want := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC); !got.ExpiresAt.Equal(want) {
+		t.Errorf("CreateToken().ExpiresAt = %v, want %v", got.ExpiresAt, want)
+	}
+	if got.Permissions.GetContents() != "read" {
+		t.Errorf("CreateToken().Permissions.Contents = %v, want %v", got.Permissions.GetContents(), "read")
+	}
+	if got.RepositorySelection != "all" {
+		t.Errorf("CreateToken().RepositorySelection = %v, want %v", got.RepositorySelection, "all")
+	}
+
+	if _, err := app.CreateToken(context.Background(), 321); err == nil {
+		t.Error("CreateToken() error = nil, want error for unknown installation")
+	}
+}
+
+func TestAppToken_GetApp(t *testing.T) {
+	_, keyPath := setupTestPrivateKey(t)
+	defer func() {
+		if err := os.Remove(keyPath); err != nil {
+			t.Errorf("Failed to remove key file: %v", err)
+		}
+	}()
+	app, err := New(12345, keyPath)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	setMockServerURL(t, app)
+
+	got, err := app.GetApp(context.Background())
+	if err != nil {
+		t.Fatalf("GetApp() error = %v, want nil", err)
+	}
+	if got.GetSlug() != "test-app" {
+		t.Errorf("GetApp().Slug = %v, want %v", got.GetSlug(), "test-app")
+	}
+}
+
+func TestAppToken_InstallationURL(t *testing.T) {
+	_, keyPath := setupTestPrivateKey(t)
+	defer func() {
+		if err := os.Remove(keyPath); err != nil {
+			t.Errorf("Failed to remove key file: %v", err)
+		}
+	}()
+	app, err := New(12345, keyPath)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	setMockServerURL(t, app)
+
+	got, err := app.InstallationURL(context.Background())
+	if err != nil {
+		t.Fatalf("InstallationURL() error = %v, want nil", err)
+	}
+	if want := "https://github.com/apps/test-app/installations/new"; got != want {
+		t.Errorf("InstallationURL() = %v, want %v", got, want)
+	}
+}
+
+func TestAppToken_Client(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	app, err := NewFromKey(12345, privateKey)
+	if err != nil {
+		t.Fatalf("NewFromKey() error: %v", err)
+	}
+
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	if err := app.WithEnterprise(srv.URL + "/"); err != nil {
+		t.Fatalf("WithEnterprise() error: %v", err)
+	}
+	if got, want := app.BaseURL().String(), srv.URL+"/api/v3/"; got != want {
+		t.Errorf("BaseURL() = %v, want %v", got, want)
+	}
+
+	resp, err := app.Client().Get(app.BaseURL().String() + "app/hook/deliveries")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if !strings.HasPrefix(gotAuth, "Bearer ey") {
+		t.Errorf("Authorization = %q, want an app JWT", gotAuth)
+	}
+}
+
+func TestAppToken_RevokeToken(t *testing.T) {
+	var flaky int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Method = %s, want DELETE", r.Method)
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer ghs_valid":
+			w.WriteHeader(http.StatusNoContent)
+		case "Bearer ghs_flaky":
+			if flaky++; flaky < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "Bearer ghs_broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message":"boom"}`))
+		case "Bearer ghs_forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Forbidden"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		}
+	})
+	app := newTestApp(t, mux)
+	app.WithRetryPolicy(RetryPolicy{Retries: 2, Delay: time.Millisecond})
+
+	tests := []struct {
+		token       string
+		wantRevoked bool
+		wantErr     bool
+	}{
+		{token: "ghs_valid", wantRevoked: true},
+		{token: "ghs_expired", wantRevoked: false},
+		{token: "ghs_flaky", wantRevoked: true},
+		{token: "ghs_broken", wantErr: true},
+		{token: "ghs_forbidden", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			revoked, err := app.RevokeToken(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RevokeToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if revoked != tt.wantRevoked {
+				t.Errorf("RevokeToken() revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+	if flaky != 3 {
+		t.Errorf("flaky token took %d attempts, want 3", flaky)
+	}
+}
+
+func TestAppToken_CreateTokenWithOptions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/123/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := string(bytes.TrimSpace(body)), `{"permissions":{"contents":"read"}}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_scoped","expires_at":"2030-01-01T00:00:00Z","permissions":{"contents":"read"}}`))
+	})
+	app := newTestApp(t, mux)
+
+	opts := &github.InstallationTokenOptions{Permissions: &github.InstallationPermissions{Contents: github.Ptr("read")}}
+	got, err := app.CreateTokenWithOptions(context.Background(), 123, opts)
+	if err != nil {
+		t.Fatalf("CreateTokenWithOptions() error = %v", err)
+	}
+	if got.Token != "ghs_scoped" {
+		t.Errorf("CreateTokenWithOptions().Token = %v, want ghs_scoped", got.Token)
+	}
+}
+
+func TestAppToken_ServerVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "ghes", body: `{"verifiable_password_authentication":true,"installed_version":"3.12.4"}`, want: "3.12.4"},
+		{name: "github.com", body: `{"verifiable_password_authentication":true}`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v3/meta", func(w http.ResponseWriter, r *http.Request) {
+				if auth := r.Header.Get("Authorization"); auth != "" {
+					t.Errorf("Authorization = %q, want none", auth)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			})
+			app := newTestApp(t, mux)
+
+			got, err := app.ServerVersion(context.Background())
+			if err != nil {
+				t.Fatalf("ServerVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ServerVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppToken_ServerTime(t *testing.T) {
+	want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/meta", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", want.Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{}`))
+	})
+	app := newTestApp(t, mux)
+
+	got, err := app.ServerTime(context.Background())
+	if err != nil {
+		t.Fatalf("ServerTime() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("ServerTime() = %v, want %v", got, want)
+	}
+}
+
+func TestFindAppBySlug(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/apps/my-bot", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_visible" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":42,"slug":"my-bot","client_id":"Iv23liBot"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	got, err := FindAppBySlug(context.Background(), srv.URL, "my-bot", "gho_visible")
+	if err != nil {
+		t.Fatalf("FindAppBySlug() error = %v", err)
+	}
+	if got.ID != 42 || got.ClientID != "Iv23liBot" {
+		t.Errorf("FindAppBySlug() = %+v, want ID 42 and client ID Iv23liBot", got)
+	}
+
+	if _, err := FindAppBySlug(context.Background(), srv.URL, "my-bot", ""); !errors.Is(err, ErrAppNotFound) {
+		t.Errorf("FindAppBySlug() error = %v, want ErrAppNotFound for a private app without a token", err)
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// Cache stores metadata an AppToken looks up, such as which installation
+// belongs to an organization, so that it is not fetched again on every call.
+// Tokens are never stored in it. Implementations must be safe for concurrent
+// use; a CLI can keep one in a file to share it between runs.
+type Cache interface {
+	// Get returns the value stored for key unless it has expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value for key until ttl has passed.
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes key, e.g. when its value turned out to be stale.
+	Delete(key string)
+}
+
+// MemoryCache is a Cache held in memory, for long-running processes.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]memoryEntry{}}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// WithCache keeps the installations found by the Find*Installation methods
+// and the server version in c for ttl. Entries are keyed by API host and
+// app, so one Cache can be shared by several AppTokens. GetApp is never
+// cached, as it doubles as a check of the app ID and key. An installation
+// whose token cannot be minted because it no longer exists is dropped from c.
+// Other fields of a cached installation, such as its permissions, can be out
+// of date by up to ttl; GetInstallation always fetches them.
+func (a *AppToken) WithCache(c Cache, ttl time.Duration) {
+	a.cache = c
+	a.cacheTTL = ttl
+}
+
+// cacheKey returns the key of name in the cache, e.g. "org/acme".
+func (a *AppToken) cacheKey(name string) string {
+	return a.client.BaseURL.String() + " " + a.transport.issuer + " " + name
+}
+
+// cached returns the value of name in the cache of a, or calls fetch and
+// stores its result. Errors are not cached, nor are values that cannot be
+// decoded again.
+func cached[T any](a *AppToken, name string, fetch func() (T, error)) (T, error) {
+	if a.cache == nil {
+		return fetch()
+	}
+
+	key := a.cacheKey(name)
+	if data, ok := a.cache.Get(key); ok {
+		var v T
+		if err := json.Unmarshal(data, &v); err == nil {
+			return v, nil
+		}
+	}
+
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		a.cache.Set(key, data, a.cacheTTL)
+	}
+	return v, nil
+}
+
+// cachedInstallation is cached for installation lookups. The key is
+// remembered by installation ID, so forgetInstallation can drop it.
+func (a *AppToken) cachedInstallation(name string, fetch func() (*github.Installation, error)) (*github.Installation, error) {
+	installation, err := cached(a, "installation/"+name, fetch)
+	if err != nil || a.cache == nil {
+		return installation, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.installationKeys == nil {
+		a.installationKeys = map[int64]string{}
+	}
+	a.installationKeys[installation.GetID()] = a.cacheKey("installation/" + name)
+	return installation, nil
+}
+
+// forgetInstallation drops the cached lookup that returned id, if any.
+func (a *AppToken) forgetInstallation(id int64) {
+	if a.cache == nil {
+		return
+	}
+
+	a.mu.Lock()
+	key, ok := a.installationKeys[id]
+	delete(a.installationKeys, id)
+	a.mu.Unlock()
+	if ok {
+		a.cache.Delete(key)
+	}
+}
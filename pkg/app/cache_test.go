@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache()
+	c.Set("a", []byte("1"), time.Hour)
+	c.Set("expired", []byte("2"), -time.Second)
+
+	if v, ok := c.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Get(a) = %q, %v, want 1, true", v, ok)
+	}
+	if _, ok := c.Get("expired"); ok {
+		t.Error("Get(expired) found an expired entry")
+	}
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) found a deleted entry")
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	var lookups, mints atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/acme/installation", func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		_, _ = w.Write([]byte(`{"id":1,"account":{"login":"acme"}}`))
+	})
+	mux.HandleFunc("/api/v3/orgs/missing/installation", func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		mints.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	app := newTestApp(t, mux)
+	cache := NewMemoryCache()
+	app.WithCache(cache, time.Hour)
+
+	for range 2 {
+		installation, err := app.FindOrgInstallation(t.Context(), "acme")
+		if err != nil || installation.GetID() != 1 || installation.GetAccount().GetLogin() != "acme" {
+			t.Fatalf("FindOrgInstallation() = %v, %v, want installation 1", installation, err)
+		}
+	}
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("looked up %d times, want once", n)
+	}
+
+	for range 2 {
+		if _, err := app.FindOrgInstallation(t.Context(), "missing"); !errors.Is(err, ErrAppNotInstalled) {
+			t.Fatalf("FindOrgInstallation() error = %v, want ErrAppNotInstalled", err)
+		}
+	}
+	if n := lookups.Load(); n != 3 {
+		t.Errorf("looked up %d times, want errors not cached", n)
+	}
+
+	// An installation that is gone is looked up again afterwards
+	if _, err := app.CreateToken(t.Context(), 1); !errors.Is(err, ErrInstallationNotFound) {
+		t.Fatalf("CreateToken() error = %v, want ErrInstallationNotFound", err)
+	}
+	if _, err := app.FindOrgInstallation(t.Context(), "acme"); err != nil || lookups.Load() != 4 {
+		t.Errorf("FindOrgInstallation() error = %v after %d lookups, want a fresh lookup", err, lookups.Load())
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// CreateComment posts a comment on the issue or pull request number with a
+// token that can only write issues on owner/repo. The token is revoked
+// afterwards.
+func (a *AppToken) CreateComment(ctx context.Context, installationID int64, owner, repo string, number int, body string) (*github.IssueComment, error) {
+	client, revoke, err := a.repoClient(ctx, installationID, repo, &github.InstallationPermissions{Issues: github.Ptr("write")})
+	if err != nil {
+		return nil, err
+	}
+	defer revoke()
+
+	comment, _, err := client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: github.Ptr(body)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", classifyError(err, nil))
+	}
+	return comment, nil
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAppToken_CreateComment(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", scopedTokenHandler(t, "repo", "issues", "write"))
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/issues/42/comments", func(w http.ResponseWriter, r *http.Request) {
+		var c struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Body != "LGTM" {
+			t.Errorf("body = %q, want LGTM", c.Body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":9,"html_url":"https://github.com/owner/repo/pull/42#issuecomment-9"}`))
+	})
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	c, err := app.CreateComment(context.Background(), 1, "owner", "repo", 42, "LGTM")
+	if err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	if c.GetID() != 9 {
+		t.Errorf("CreateComment().ID = %d, want 9", c.GetID())
+	}
+}
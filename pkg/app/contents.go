@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// FetchContent downloads the file at path of owner/repo with a token that can
+// only read contents of the repository, which is revoked afterwards. An empty
+// ref reads from the default branch. Files larger than the 1 MB limit of the
+// contents API are supported.
+func (a *AppToken) FetchContent(ctx context.Context, installationID int64, owner, repo, path, ref string) ([]byte, error) {
+	client, revoke, err := a.repoClient(ctx, installationID, repo, &github.InstallationPermissions{Contents: github.Ptr("read")})
+	if err != nil {
+		return nil, err
+	}
+	defer revoke()
+
+	rc, _, err := client.Repositories.DownloadContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, classifyError(err, nil))
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAppToken_FetchContent(t *testing.T) {
+	var srvURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", scopedTokenHandler(t, "repo", "contents", "read"))
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/contents/scripts", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ref"); got != "main" {
+			t.Errorf("ref = %q, want main", got)
+		}
+		_, _ = w.Write([]byte(`[{"type":"file","name":"bootstrap.sh","path":"scripts/bootstrap.sh","download_url":"` + srvURL + `/raw/bootstrap.sh"}]`))
+	})
+	mux.HandleFunc("GET /raw/bootstrap.sh", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#!/bin/sh\necho hi\n"))
+	})
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+	srvURL = app.BaseURL().Scheme + "://" + app.BaseURL().Host
+
+	got, err := app.FetchContent(context.Background(), 1, "owner", "repo", "scripts/bootstrap.sh", "main")
+	if err != nil {
+		t.Fatalf("FetchContent() error = %v", err)
+	}
+	if string(got) != "#!/bin/sh\necho hi\n" {
+		t.Errorf("FetchContent() = %q", got)
+	}
+
+	if _, err := app.FetchContent(context.Background(), 1, "owner", "repo", "scripts/missing.sh", "main"); err == nil {
+		t.Error("FetchContent() error = nil, want error for a missing file")
+	}
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// DispatchWorkflow mints a token that can only write Actions on owner/repo
+// and uses it to trigger a workflow_dispatch event. workflow is the file name
+// of the workflow (deploy.yml) or its numeric ID. An empty ref dispatches on
+// the repository's default branch. The token is revoked afterwards.
+func (a *AppToken) DispatchWorkflow(ctx context.Context, installationID int64, owner, repo, workflow, ref string, inputs map[string]any) error {
+	client, revoke, err := a.repoClient(ctx, installationID, repo, &github.InstallationPermissions{Actions: github.Ptr("write")})
+	if err != nil {
+		return err
+	}
+	defer revoke()
+
+	if ref == "" {
+		r, _, err := client.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			return fmt.Errorf("failed to get default branch: %w", classifyError(err, nil))
+		}
+		ref = r.GetDefaultBranch()
+	}
+
+	event := github.CreateWorkflowDispatchEventRequest{Ref: ref, Inputs: inputs}
+	if id, perr := strconv.ParseInt(workflow, 10, 64); perr == nil {
+		_, err = client.Actions.CreateWorkflowDispatchEventByID(ctx, owner, repo, id, event)
+	} else {
+		_, err = client.Actions.CreateWorkflowDispatchEventByFileName(ctx, owner, repo, workflow, event)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to dispatch workflow: %w", classifyError(err, nil))
+	}
+	return nil
+}
+
+// repoClient mints a token limited to permissions on the single repository
+// and returns a client that sends it. revoke revokes the token; it ignores
+// failures, since the token expires within the hour anyway.
+func (a *AppToken) repoClient(ctx context.Context, installationID int64, repo string, permissions *github.InstallationPermissions) (client *github.Client, revoke func(), err error) {
+	t, err := a.CreateTokenWithOptions(ctx, installationID, &github.InstallationTokenOptions{
+		Repositories: []string{repo},
+		Permissions:  permissions,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client = github.NewClient(&http.Client{Transport: sharedTransport}).WithAuthToken(t.Token)
+	client.BaseURL = a.client.BaseURL
+	revoke = func() { _, _ = a.RevokeToken(context.WithoutCancel(ctx), t.Token) }
+	return client, revoke, nil
+}
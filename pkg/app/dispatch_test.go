@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAppToken_DispatchWorkflow(t *testing.T) {
+	var dispatched map[string]any
+	var revoked bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", scopedTokenHandler(t, "repo", "actions", "write"))
+	mux.HandleFunc("GET /api/v3/repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"default_branch":"trunk"}`))
+	})
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/actions/workflows/deploy.yml/dispatches", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer ghs_scoped" {
+			t.Errorf("Authorization = %q, want the minted token", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dispatched); err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		revoked = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	err := app.DispatchWorkflow(context.Background(), 1, "owner", "repo", "deploy.yml", "", map[string]any{"env": "prod"})
+	if err != nil {
+		t.Fatalf("DispatchWorkflow() error = %v", err)
+	}
+	if dispatched["ref"] != "trunk" {
+		t.Errorf("ref = %v, want the default branch", dispatched["ref"])
+	}
+	if inputs, _ := dispatched["inputs"].(map[string]any); inputs["env"] != "prod" {
+		t.Errorf("inputs = %v, want env=prod", dispatched["inputs"])
+	}
+	if !revoked {
+		t.Error("token was not revoked")
+	}
+}
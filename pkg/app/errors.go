@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// Sentinel errors describing common failure classes. Every error AppToken
+// returns for a failed API call wraps the underlying go-github error, so
+// errors.As(err, **github.ErrorResponse) recovers the status code and message;
+// errors of a known class also wrap one of these for errors.Is.
+var (
+	// ErrInstallationNotFound means the installation ID does not exist or
+	// does not belong to the app.
+	ErrInstallationNotFound = errors.New("installation not found")
+	// ErrAppNotFound means no app has the requested slug, or it is private
+	// and the request could not see it.
+	ErrAppNotFound = errors.New("app not found")
+	// ErrAppNotInstalled means the app is not installed on the requested
+	// organization, repository or user.
+	ErrAppNotInstalled = errors.New("app is not installed")
+	// ErrBadCredentials means GitHub rejected the app JWT, usually because
+	// the app ID and private key do not match.
+	ErrBadCredentials = errors.New("bad credentials")
+	// ErrDeliveryNotFound means the webhook delivery does not exist or has
+	// aged out of the delivery log.
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrRateLimited means the request hit a primary or secondary rate limit.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrMaintenance means a GitHub Enterprise Server answered with its
+	// maintenance page. It lasts until an administrator ends the
+	// maintenance window, so retrying soon is pointless.
+	ErrMaintenance = errors.New("server is in maintenance mode")
+)
+
+// classifyError wraps err with the sentinel matching its failure class.
+// notFound is the sentinel to use for a 404 response.
+func classifyError(err error, notFound error) error {
+	var rateLimitErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateLimitErr) || errors.As(err, &abuseErr) {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return err
+	}
+
+	switch respErr.Response.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %w", ErrBadCredentials, err)
+	case http.StatusForbidden:
+		if secondaryRateLimitMessage(respErr.Message) {
+			return fmt.Errorf("%w: %w", ErrRateLimited, err)
+		}
+	case http.StatusNotFound:
+		if notFound != nil {
+			return fmt.Errorf("%w: %w", notFound, err)
+		}
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	case http.StatusServiceUnavailable:
+		// API errors are JSON; the maintenance page is the only HTML 503
+		if strings.HasPrefix(respErr.Response.Header.Get("Content-Type"), "text/html") {
+			return fmt.Errorf("%w: %w", ErrMaintenance, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestErrorClassification(t *testing.T) {
+	_, keyPath := setupTestPrivateKey(t)
+	defer func() {
+		if err := os.Remove(keyPath); err != nil {
+			t.Errorf("Failed to remove key file: %v", err)
+		}
+	}()
+	app, err := New(12345, keyPath)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	setMockServerURL(t, app)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{
+			name: "unknown installation",
+			call: func() error { _, err := app.CreateToken(ctx, 321); return err },
+			want: ErrInstallationNotFound,
+		},
+		{
+			name: "bad credentials",
+			call: func() error { _, err := app.CreateToken(ctx, 401); return err },
+			want: ErrBadCredentials,
+		},
+		{
+			name: "org without installation",
+			call: func() error { _, err := app.CreateTokenFromOrg(ctx, "notfound"); return err },
+			want: ErrAppNotInstalled,
+		},
+		{
+			name: "rate limited",
+			call: func() error { _, err := app.CreateTokenFromOrg(ctx, "ratelimited"); return err },
+			want: ErrRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+
+			var respErr *github.ErrorResponse
+			var rateLimitErr *github.RateLimitError
+			if !errors.As(err, &respErr) && !errors.As(err, &rateLimitErr) {
+				t.Errorf("error = %v, want it to wrap the go-github error", err)
+			}
+		})
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	app := newTestApp(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message":"boom"}`))
+	}))
+
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"CreateToken":          func() error { _, err := app.CreateToken(ctx, 1); return err },
+		"RevokeToken":          func() error { _, err := app.RevokeToken(ctx, "ghs_token"); return err },
+		"FindOrgInstallation":  func() error { _, err := app.FindOrgInstallation(ctx, "org"); return err },
+		"FindRepoInstallation": func() error { _, err := app.FindRepoInstallation(ctx, "owner", "repo"); return err },
+		"FindUserInstallation": func() error { _, err := app.FindUserInstallation(ctx, "user"); return err },
+		"FindEnterpriseInstallation": func() error {
+			_, err := app.FindEnterpriseInstallation(ctx, "enterprise")
+			return err
+		},
+		"GetApp":              func() error { _, err := app.GetApp(ctx); return err },
+		"InstallationURL":     func() error { _, err := app.InstallationURL(ctx); return err },
+		"GetInstallation":     func() error { _, err := app.GetInstallation(ctx, 1); return err },
+		"SuspendInstallation": func() error { return app.SuspendInstallation(ctx, 1) },
+		"DeleteInstallation":  func() error { return app.DeleteInstallation(ctx, 1) },
+		"ListInstallations":   func() error { _, err := app.ListInstallations(ctx); return err },
+		"ListInstallationRepos": func() error {
+			_, err := app.ListInstallationRepos(ctx, 1)
+			return err
+		},
+		"ListHookDeliveries":    func() error { _, err := app.ListHookDeliveries(ctx, 10); return err },
+		"RedeliverHookDelivery": func() error { return app.RedeliverHookDelivery(ctx, 1) },
+		"GetHookConfig":         func() error { _, err := app.GetHookConfig(ctx); return err },
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			var respErr *github.ErrorResponse
+			if err := call(); !errors.As(err, &respErr) {
+				t.Fatalf("error = %v, want it to wrap *github.ErrorResponse", err)
+			}
+			if respErr.Response.StatusCode != http.StatusInternalServerError || respErr.Message != "boom" {
+				t.Errorf("ErrorResponse = %d %q, want 500 \"boom\"", respErr.Response.StatusCode, respErr.Message)
+			}
+		})
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/maintenance/installation", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html><body><h1>This GitHub instance is under maintenance</h1></body></html>"))
+	})
+	mux.HandleFunc("/api/v3/orgs/unavailable/installation", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"message":"Service Unavailable"}`))
+	})
+	app := newTestApp(t, mux)
+	ctx := context.Background()
+
+	if _, err := app.FindOrgInstallation(ctx, "maintenance"); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("error = %v, want ErrMaintenance for the HTML maintenance page", err)
+	}
+	if _, err := app.FindOrgInstallation(ctx, "unavailable"); errors.Is(err, ErrMaintenance) {
+		t.Errorf("error = %v, want a JSON 503 not to count as maintenance", err)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// ListHookDeliveries returns up to limit of the most recent deliveries of the
+// app webhook, newest first, following the cursor pagination of the API.
+func (a *AppToken) ListHookDeliveries(ctx context.Context, limit int) ([]*github.HookDelivery, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	var deliveries []*github.HookDelivery
+	opts := &github.ListCursorOptions{PerPage: min(limit, maxPerPage)}
+	for len(deliveries) < limit {
+		page, resp, err := a.client.Apps.ListHookDeliveries(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hook deliveries: %w", classifyError(err, nil))
+		}
+		deliveries = append(deliveries, page...)
+		if resp.Cursor == "" || len(page) == 0 {
+			break
+		}
+		opts.Cursor = resp.Cursor
+	}
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// RedeliverHookDelivery asks GitHub to send a webhook delivery again. The
+// redelivery is queued asynchronously and shows up as a new delivery.
+func (a *AppToken) RedeliverHookDelivery(ctx context.Context, deliveryID int64) error {
+	_, _, err := a.client.Apps.RedeliverHookDelivery(ctx, deliveryID)
+	var accepted *github.AcceptedError
+	if err != nil && !errors.As(err, &accepted) {
+		return fmt.Errorf("failed to redeliver hook delivery %d: %w", deliveryID, classifyError(err, ErrDeliveryNotFound))
+	}
+
+	return nil
+}
+
+// GetHookConfig returns the app webhook configuration. GitHub never returns
+// the secret in clear text.
+func (a *AppToken) GetHookConfig(ctx context.Context) (*github.HookConfig, error) {
+	config, _, err := a.client.Apps.GetHookConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hook config: %w", classifyError(err, nil))
+	}
+
+	return config, nil
+}
+
+// UpdateHookConfig changes the fields of the app webhook configuration that
+// are set in config and returns the resulting configuration.
+func (a *AppToken) UpdateHookConfig(ctx context.Context, config *github.HookConfig) (*github.HookConfig, error) {
+	updated, _, err := a.client.Apps.UpdateHookConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update hook config: %w", classifyError(err, nil))
+	}
+
+	return updated, nil
+}
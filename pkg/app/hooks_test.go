@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestAppToken_ListHookDeliveries(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/hook/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v3/app/hook/deliveries?cursor=v1_2>; rel="next"`, r.Host))
+			_, _ = w.Write([]byte(`[{"id":3},{"id":2}]`))
+		case "v1_2":
+			_, _ = w.Write([]byte(`[{"id":1}]`))
+		}
+	})
+	app := newTestApp(t, mux)
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []int64
+	}{
+		{"all pages", 10, []int64{3, 2, 1}},
+		{"truncated", 2, []int64{3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := app.ListHookDeliveries(context.Background(), tt.limit)
+			if err != nil {
+				t.Fatalf("ListHookDeliveries() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListHookDeliveries() returned %d deliveries, want %d", len(got), len(tt.want))
+			}
+			for i, d := range got {
+				if d.GetID() != tt.want[i] {
+					t.Errorf("delivery[%d].ID = %d, want %d", i, d.GetID(), tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := app.ListHookDeliveries(context.Background(), 0); err == nil {
+		t.Error("ListHookDeliveries(0) error = nil, want error")
+	}
+}
+
+func TestAppToken_RedeliverHookDelivery(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/hook/deliveries/1/attempts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Method = %s, want POST", r.Method)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/api/v3/app/hook/deliveries/2/attempts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	app := newTestApp(t, mux)
+
+	if err := app.RedeliverHookDelivery(context.Background(), 1); err != nil {
+		t.Errorf("RedeliverHookDelivery() error = %v, want nil", err)
+	}
+	if err := app.RedeliverHookDelivery(context.Background(), 2); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("RedeliverHookDelivery() error = %v, want ErrDeliveryNotFound", err)
+	}
+}
+
+func TestAppToken_HookConfig(t *testing.T) {
+	var patched map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/hook/config", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			if err := json.NewDecoder(r.Body).Decode(&patched); err != nil {
+				t.Errorf("Failed to decode request: %v", err)
+			}
+		}
+		_, _ = w.Write([]byte(`{"url":"https://example.com/hook","content_type":"json","insecure_ssl":"0","secret":"********"}`))
+	})
+	app := newTestApp(t, mux)
+
+	config, err := app.GetHookConfig(context.Background())
+	if err != nil {
+		t.Fatalf("GetHookConfig() error = %v", err)
+	}
+	if config.GetURL() != "https://example.com/hook" {
+		t.Errorf("URL = %q, want https://example.com/hook", config.GetURL())
+	}
+
+	if _, err := app.UpdateHookConfig(context.Background(), &github.HookConfig{URL: github.Ptr("https://example.com/new")}); err != nil {
+		t.Fatalf("UpdateHookConfig() error = %v", err)
+	}
+	if want := map[string]any{"url": "https://example.com/new"}; fmt.Sprint(patched) != fmt.Sprint(want) {
+		t.Errorf("PATCH body = %v, want %v", patched, want)
+	}
+}
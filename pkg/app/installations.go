@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// maxPerPage is the largest page size the REST API accepts.
+const maxPerPage = 100
+
+// GetInstallation returns the installation with the given ID.
+func (a *AppToken) GetInstallation(ctx context.Context, installationID int64) (*github.Installation, error) {
+	installation, _, err := a.client.Apps.GetInstallation(ctx, installationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installation: %w", classifyError(err, ErrInstallationNotFound))
+	}
+
+	return installation, nil
+}
+
+// SuspendInstallation blocks the installation from accessing the account's
+// resources until it is unsuspended.
+func (a *AppToken) SuspendInstallation(ctx context.Context, installationID int64) error {
+	if _, err := a.client.Apps.SuspendInstallation(ctx, installationID); err != nil {
+		return fmt.Errorf("failed to suspend installation: %w", classifyError(err, ErrInstallationNotFound))
+	}
+	return nil
+}
+
+// UnsuspendInstallation lifts a suspension set by SuspendInstallation.
+func (a *AppToken) UnsuspendInstallation(ctx context.Context, installationID int64) error {
+	if _, err := a.client.Apps.UnsuspendInstallation(ctx, installationID); err != nil {
+		return fmt.Errorf("failed to unsuspend installation: %w", classifyError(err, ErrInstallationNotFound))
+	}
+	return nil
+}
+
+// DeleteInstallation uninstalls the app from the installation's account.
+// Tokens minted for it stop working immediately.
+func (a *AppToken) DeleteInstallation(ctx context.Context, installationID int64) error {
+	if _, err := a.client.Apps.DeleteInstallation(ctx, installationID); err != nil {
+		return fmt.Errorf("failed to delete installation: %w", classifyError(err, ErrInstallationNotFound))
+	}
+	return nil
+}
+
+// ListInstallations returns every installation of the app. Pages are
+// fetched, and retried, like SyncInstallations does without a cache.
+func (a *AppToken) ListInstallations(ctx context.Context) ([]*github.Installation, error) {
+	var installations []*github.Installation
+	for n := 1; ; {
+		page, _, err := a.installationsPage(ctx, n, nil)
+		if err != nil {
+			return nil, err
+		}
+		installations = append(installations, page.Installations...)
+		if page.NextPage == 0 {
+			return installations, nil
+		}
+		n = page.NextPage
+	}
+}
+
+// InstallationPages caches the pages of the installations listing together
+// with their ETags. It is meant to be stored between runs and passed to
+// SyncInstallations.
+type InstallationPages struct {
+	Pages []InstallationPage `json:"pages"`
+}
+
+// InstallationPage is one page of the installations listing.
+type InstallationPage struct {
+	ETag          string                 `json:"etag"`
+	Installations []*github.Installation `json:"installations"`
+	// NextPage is 0 on the last page
+	NextPage int `json:"next_page"`
+}
+
+// SyncInstallations returns every installation of the app like
+// ListInstallations, but sends the ETag of each page in cache as
+// If-None-Match. Unchanged pages are answered with 304 Not Modified, which
+// does not count against the rate limit, and taken from cache. cache is
+// updated in place; the number of unchanged pages is returned.
+func (a *AppToken) SyncInstallations(ctx context.Context, cache *InstallationPages) ([]*github.Installation, int, error) {
+	var installations []*github.Installation
+	var pages []InstallationPage
+	unchanged := 0
+	for n := 1; ; {
+		var cached *InstallationPage
+		if n <= len(cache.Pages) {
+			cached = &cache.Pages[n-1]
+		}
+
+		page, notModified, err := a.installationsPage(ctx, n, cached)
+		if err != nil {
+			return nil, unchanged, err
+		}
+		if notModified {
+			unchanged++
+		}
+		pages = append(pages, page)
+		installations = append(installations, page.Installations...)
+
+		if page.NextPage == 0 {
+			// Pages past the end are dropped, e.g. after uninstalls
+			cache.Pages = pages
+			return installations, unchanged, nil
+		}
+		n = page.NextPage
+	}
+}
+
+// installationsPage fetches page n of the installations listing, or returns
+// cached if the server reports it unchanged. The next page of an unchanged
+// page is taken from the Link header of the 304 response if it has one.
+func (a *AppToken) installationsPage(ctx context.Context, n int, cached *InstallationPage) (InstallationPage, bool, error) {
+	req, err := a.client.NewRequest(http.MethodGet, fmt.Sprintf("app/installations?per_page=%d&page=%d", maxPerPage, n), nil)
+	if err != nil {
+		return InstallationPage{}, false, err
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	var installations []*github.Installation
+	var resp *github.Response
+	err = a.retry(ctx, func() error {
+		var err error
+		resp, err = a.client.Do(ctx, req, &installations)
+		if resp != nil && resp.StatusCode == http.StatusNotModified && cached != nil {
+			return nil
+		}
+		return classifyError(err, nil)
+	})
+	if err != nil {
+		return InstallationPage{}, false, fmt.Errorf("failed to list installations: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		page := *cached
+		switch {
+		case resp.Header.Get("Link") != "":
+			page.NextPage = resp.NextPage
+		case page.NextPage == 0 && len(page.Installations) >= maxPerPage:
+			// An unchanged full last page may be followed by a new page,
+			// which only a request for it tells
+			page.NextPage = n + 1
+		}
+		return page, true, nil
+	}
+	return InstallationPage{
+		ETag:          resp.Header.Get("ETag"),
+		Installations: installations,
+		NextPage:      resp.NextPage,
+	}, false, nil
+}
+
+// ListInstallationRepos returns the repositories the installation can
+// access. It mints an installation token limited to metadata to do so, and
+// revokes it once the repositories are listed.
+func (a *AppToken) ListInstallationRepos(ctx context.Context, installationID int64) ([]*github.Repository, error) {
+	opts := &github.InstallationTokenOptions{Permissions: &github.InstallationPermissions{Metadata: github.Ptr("read")}}
+	token, err := a.CreateTokenWithOptions(ctx, installationID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of installation %d: %w", installationID, err)
+	}
+	// A token left behind by a failed revocation expires within the hour
+	defer func() { _, _ = a.RevokeToken(context.WithoutCancel(ctx), token.Token) }()
+
+	client := github.NewClient(&http.Client{Transport: sharedTransport}).WithAuthToken(token.Token)
+	client.BaseURL = a.client.BaseURL
+
+	var repos []*github.Repository
+	listOpts := &github.ListOptions{PerPage: maxPerPage}
+	for {
+		page, resp, err := client.Apps.ListRepos(ctx, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of installation %d: %w", installationID, classifyError(err, ErrInstallationNotFound))
+		}
+		repos = append(repos, page.Repositories...)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
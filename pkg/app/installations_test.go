@@ -0,0 +1,244 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAppToken_ListInstallations(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`[{"id":3}]`))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v3/app/installations?page=2>; rel="next"`, r.Host))
+		_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+	})
+	app := newTestApp(t, mux)
+
+	got, err := app.ListInstallations(context.Background())
+	if err != nil {
+		t.Fatalf("ListInstallations() error = %v", err)
+	}
+	if len(got) != 3 || got[2].GetID() != 3 {
+		t.Errorf("ListInstallations() = %v, want installations 1, 2 and 3", got)
+	}
+}
+
+func TestAppToken_SyncInstallations(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requests = append(requests, page+":"+r.Header.Get("If-None-Match"))
+		switch page {
+		case "1":
+			if r.Header.Get("If-None-Match") == `"p1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"p1"`)
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v3/app/installations?page=2>; rel="next"`, r.Host))
+			_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "2":
+			w.Header().Set("ETag", `"p2-`+strconv.Itoa(len(requests))+`"`)
+			_, _ = w.Write([]byte(`[{"id":3}]`))
+		}
+	})
+	app := newTestApp(t, mux)
+
+	cache := &InstallationPages{}
+	got, unchanged, err := app.SyncInstallations(context.Background(), cache)
+	if err != nil {
+		t.Fatalf("SyncInstallations() error = %v", err)
+	}
+	if len(got) != 3 || unchanged != 0 {
+		t.Fatalf("SyncInstallations() = %d installations, %d unchanged, want 3 and 0", len(got), unchanged)
+	}
+	if len(cache.Pages) != 2 || cache.Pages[0].ETag != `"p1"` || cache.Pages[0].NextPage != 2 {
+		t.Fatalf("cache = %+v, want two pages with ETags", cache.Pages)
+	}
+
+	got, unchanged, err = app.SyncInstallations(context.Background(), cache)
+	if err != nil {
+		t.Fatalf("SyncInstallations() error = %v", err)
+	}
+	if len(got) != 3 || got[0].GetID() != 1 || unchanged != 1 {
+		t.Errorf("SyncInstallations() = %d installations, %d unchanged, want 3 and 1", len(got), unchanged)
+	}
+	want := []string{"1:", "2:", `1:"p1"`, `2:"p2-2"`}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+
+	// Cached pages past the last one are dropped
+	cache.Pages[1].NextPage = 3
+	cache.Pages = append(cache.Pages, InstallationPage{ETag: `"p3"`})
+	if _, _, err := app.SyncInstallations(context.Background(), cache); err != nil {
+		t.Fatalf("SyncInstallations() error = %v", err)
+	}
+	if len(cache.Pages) != 2 {
+		t.Errorf("cache has %d pages, want 2", len(cache.Pages))
+	}
+}
+
+func TestAppToken_SyncInstallations_newPage(t *testing.T) {
+	var full strings.Builder
+	for i := range maxPerPage {
+		if i > 0 {
+			full.WriteString(",")
+		}
+		fmt.Fprintf(&full, `{"id":%d}`, i+1)
+	}
+
+	installed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			// Unchanged, and without a Link header, as GitHub answers 304s
+			if r.Header.Get("If-None-Match") == `"p1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"p1"`)
+			if installed {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v3/app/installations?page=2>; rel="next"`, r.Host))
+			}
+			_, _ = w.Write([]byte("[" + full.String() + "]"))
+		case "2":
+			if installed {
+				_, _ = w.Write([]byte(`[{"id":101}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		}
+	})
+	app := newTestApp(t, mux)
+
+	cache := &InstallationPages{}
+	got, _, err := app.SyncInstallations(context.Background(), cache)
+	if err != nil {
+		t.Fatalf("SyncInstallations() error = %v", err)
+	}
+	if len(got) != maxPerPage {
+		t.Fatalf("SyncInstallations() = %d installations, want %d", len(got), maxPerPage)
+	}
+
+	installed = true
+	got, unchanged, err := app.SyncInstallations(context.Background(), cache)
+	if err != nil {
+		t.Fatalf("SyncInstallations() error = %v", err)
+	}
+	if len(got) != maxPerPage+1 || got[maxPerPage].GetID() != 101 || unchanged != 1 {
+		t.Errorf("SyncInstallations() = %d installations, %d unchanged, want the new installation on page 2 and 1 unchanged", len(got), unchanged)
+	}
+}
+
+func TestAppToken_ListInstallationRepos(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/123/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := string(bytes.TrimSpace(body)), `{"permissions":{"metadata":"read"}}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_installation","expires_at":"2030-01-01T00:00:00Z"}`))
+	})
+	mux.HandleFunc("/api/v3/installation/repositories", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer ghs_installation" {
+			t.Errorf("Authorization = %q, want the installation token", got)
+		}
+		_, _ = w.Write([]byte(`{"total_count":1,"repositories":[{"full_name":"acme/widgets"}]}`))
+	})
+	revoked := false
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		revoked = r.Header.Get("Authorization") == "Bearer ghs_installation"
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	got, err := app.ListInstallationRepos(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("ListInstallationRepos() error = %v", err)
+	}
+	if len(got) != 1 || got[0].GetFullName() != "acme/widgets" {
+		t.Errorf("ListInstallationRepos() = %v, want acme/widgets", got)
+	}
+	if !revoked {
+		t.Error("ListInstallationRepos() did not revoke its token")
+	}
+}
+
+func TestAppToken_GetInstallation(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/123", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":123,"account":{"login":"acme"},"repository_selection":"selected"}`))
+	})
+	app := newTestApp(t, mux)
+
+	got, err := app.GetInstallation(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("GetInstallation() error = %v", err)
+	}
+	if got.GetAccount().GetLogin() != "acme" {
+		t.Errorf("GetInstallation().Account = %v, want acme", got.GetAccount().GetLogin())
+	}
+
+	if _, err := app.GetInstallation(context.Background(), 404); !errors.Is(err, ErrInstallationNotFound) {
+		t.Errorf("GetInstallation() error = %v, want ErrInstallationNotFound", err)
+	}
+}
+
+func TestAppToken_SuspendInstallation(t *testing.T) {
+	var methods []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/123/suspended", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	if err := app.SuspendInstallation(context.Background(), 123); err != nil {
+		t.Errorf("SuspendInstallation() error = %v", err)
+	}
+	if err := app.UnsuspendInstallation(context.Background(), 123); err != nil {
+		t.Errorf("UnsuspendInstallation() error = %v", err)
+	}
+	if got := fmt.Sprint(methods); got != "[PUT DELETE]" {
+		t.Errorf("methods = %v, want [PUT DELETE]", got)
+	}
+
+	if err := app.SuspendInstallation(context.Background(), 404); !errors.Is(err, ErrInstallationNotFound) {
+		t.Errorf("SuspendInstallation() error = %v, want ErrInstallationNotFound", err)
+	}
+}
+
+func TestAppToken_DeleteInstallation(t *testing.T) {
+	var method string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/123", func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	if err := app.DeleteInstallation(context.Background(), 123); err != nil {
+		t.Errorf("DeleteInstallation() error = %v", err)
+	}
+	if method != http.MethodDelete {
+		t.Errorf("method = %s, want DELETE", method)
+	}
+
+	if err := app.DeleteInstallation(context.Background(), 404); !errors.Is(err, ErrInstallationNotFound) {
+		t.Errorf("DeleteInstallation() error = %v, want ErrInstallationNotFound", err)
+	}
+}
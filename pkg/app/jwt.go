@@ -0,0 +1,110 @@
+package app
+
+import (
+	"crypto"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// jwtTTL is how long a signed JWT is reused. It stays well inside
+// auth.JWTLifetime so a cached JWT never expires in flight.
+const jwtTTL = auth.JWTLifetime - 3*time.Minute
+
+// cachedJWT is the JWT of one cache key. Its mutex is held while signing,
+// so that callers of the same key wait for one signature instead of each
+// signing their own, without holding up the other keys.
+type cachedJWT struct {
+	mu        sync.Mutex
+	token     auth.JWT
+	expiresAt time.Time
+}
+
+// jwtCache holds app JWTs keyed by API host, issuer and key fingerprint. It
+// is shared by every AppToken in the process, so instances for the same app
+// reuse signatures (which matters for KMS-backed signers) while instances
+// for different hosts, e.g. GHES and github.com, never mix credentials.
+type jwtCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedJWT
+}
+
+var sharedJWTCache = &jwtCache{entries: map[string]*cachedJWT{}}
+
+func (c *jwtCache) get(key string, sign func() (auth.JWT, error)) (auth.JWT, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &cachedJWT{}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token != "" && time.Now().Before(e.expiresAt) {
+		return e.token, nil
+	}
+
+	token, err := sign()
+	if err != nil {
+		return "", err
+	}
+	e.token, e.expiresAt = token, time.Now().Add(jwtTTL)
+	return token, nil
+}
+
+// jwtTransport authenticates each request to host with an app JWT, signing a
+// new one when the cached JWT is about to expire. Requests to other hosts,
+// such as redirects or absolute URLs, are sent without credentials.
+type jwtTransport struct {
+	base        http.RoundTripper
+	host        string
+	issuer      string
+	signer      crypto.Signer
+	fingerprint string
+	cache       *jwtCache
+}
+
+func newJWTTransport(base http.RoundTripper, issuer string, signer crypto.Signer) (*jwtTransport, error) {
+	fingerprint, err := auth.Fingerprint(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &jwtTransport{
+		base:        base,
+		issuer:      issuer,
+		signer:      signer,
+		fingerprint: fingerprint,
+		cache:       sharedJWTCache,
+	}, nil
+}
+
+func (t *jwtTransport) jwt(host string) (auth.JWT, error) {
+	key := host + "\x00" + t.issuer + "\x00" + t.fingerprint
+	return t.cache.get(key, func() (auth.JWT, error) {
+		return auth.SignJWT(t.signer, t.issuer)
+	})
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+
+	jwt, err := t.jwt(req.URL.Host)
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %w", errSignJWT, err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+jwt.Reveal())
+	return t.base.RoundTrip(req)
+}
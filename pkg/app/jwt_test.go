@@ -0,0 +1,197 @@
+package app
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type countingSigner struct {
+	*rsa.PrivateKey
+	signs atomic.Int32
+}
+
+func (s *countingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.signs.Add(1)
+	return s.PrivateKey.Sign(rand, digest, opts)
+}
+
+func Test_jwtTransport(t *testing.T) {
+	privateKey, _ := setupTestPrivateKey(t)
+	signer := &countingSigner{PrivateKey: privateKey}
+
+	var issuers []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := &jwt.RegisteredClaims{}
+		raw := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if _, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) {
+			return &privateKey.PublicKey, nil
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		issuers = append(issuers, claims.Issuer)
+	})
+	dotcom := httptest.NewServer(handler)
+	defer dotcom.Close()
+	ghes := httptest.NewServer(handler)
+	defer ghes.Close()
+
+	cache := &jwtCache{entries: map[string]*cachedJWT{}}
+	client := func(srv *httptest.Server) *http.Client {
+		transport, err := newJWTTransport(http.DefaultTransport, "12345", signer)
+		if err != nil {
+			t.Fatalf("newJWTTransport() error = %v, want nil", err)
+		}
+		transport.host = strings.TrimPrefix(srv.URL, "http://")
+		transport.cache = cache
+		return &http.Client{Transport: transport}
+	}
+	get := func(client *http.Client, u string) int {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatalf("Get() error = %v, want nil", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	dotcomClient, ghesClient := client(dotcom), client(ghes)
+	for _, c := range []struct {
+		client *http.Client
+		u      string
+	}{{dotcomClient, dotcom.URL}, {dotcomClient, dotcom.URL}, {ghesClient, ghes.URL}, {ghesClient, ghes.URL}} {
+		if status := get(c.client, c.u); status != http.StatusOK {
+			t.Fatalf("Get() status = %v, want 200", status)
+		}
+	}
+
+	if len(issuers) != 4 || issuers[0] != "12345" {
+		t.Errorf("issuers = %v, want four requests issued by 12345", issuers)
+	}
+	if got := signer.signs.Load(); got != 2 {
+		t.Errorf("signed %d JWTs, want one per host", got)
+	}
+
+	// No JWT is signed for, nor sent to, another host
+	if status := get(dotcomClient, ghes.URL); status != http.StatusUnauthorized {
+		t.Errorf("Get() status = %v for another host, want 401 without a JWT", status)
+	}
+	if got := signer.signs.Load(); got != 2 {
+		t.Errorf("signed %d JWTs, want none for another host", got)
+	}
+}
+
+func Test_jwtCache(t *testing.T) {
+	cache := &jwtCache{entries: map[string]*cachedJWT{}}
+
+	// A slow signature of one key does not hold up the others
+	signing, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_, _ = cache.get("slow", func() (auth.JWT, error) {
+			close(signing)
+			<-release
+			return "slow", nil
+		})
+	}()
+	<-signing
+	if got, err := cache.get("fast", func() (auth.JWT, error) { return "fast", nil }); err != nil || got != "fast" {
+		t.Errorf("get() = %q, %v while another key is signing, want fast", got, err)
+	}
+	close(release)
+
+	// Callers of the same key share one signature
+	var signs atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = cache.get("shared", func() (auth.JWT, error) {
+				signs.Add(1)
+				return "shared", nil
+			})
+		}()
+	}
+	wg.Wait()
+	if got := signs.Load(); got != 1 {
+		t.Errorf("signed %d JWTs for one key, want 1", got)
+	}
+}
+
+func TestAppToken_JWT(t *testing.T) {
+	privateKey, _ := setupTestPrivateKey(t)
+	signer := &countingSigner{PrivateKey: privateKey}
+
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	app, err := NewFromSigner(12345, signer)
+	if err != nil {
+		t.Fatalf("NewFromSigner() error = %v", err)
+	}
+	app.transport.cache = &jwtCache{entries: map[string]*cachedJWT{}}
+	if err := app.WithEnterprise(srv.URL + "/"); err != nil {
+		t.Fatalf("WithEnterprise() error = %v", err)
+	}
+
+	token, err := app.JWT()
+	if err != nil {
+		t.Fatalf("JWT() error = %v", err)
+	}
+	resp, err := app.Client().Get(srv.URL + "/api/v3/app")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if auth != "Bearer "+token.Reveal() {
+		t.Errorf("request was not sent with the JWT returned by JWT()")
+	}
+	if got := signer.signs.Load(); got != 1 {
+		t.Errorf("signed %d JWTs, want 1", got)
+	}
+}
+
+func TestNewFromSignerWithClientID(t *testing.T) {
+	privateKey, _ := setupTestPrivateKey(t)
+
+	app, err := NewFromSignerWithClientID("Iv23liExample", privateKey)
+	if err != nil {
+		t.Fatalf("NewFromSignerWithClientID() error = %v", err)
+	}
+	app.transport.cache = &jwtCache{entries: map[string]*cachedJWT{}}
+	token, err := app.JWT()
+	if err != nil {
+		t.Fatalf("JWT() error = %v", err)
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	if _, err := jwt.ParseWithClaims(token.Reveal(), claims, func(*jwt.Token) (any, error) {
+		return &privateKey.PublicKey, nil
+	}); err != nil {
+		t.Fatalf("ParseWithClaims() error = %v", err)
+	}
+	if claims.Issuer != "Iv23liExample" {
+		t.Errorf("iss = %q, want the client ID", claims.Issuer)
+	}
+
+	if _, err := NewFromSignerWithClientID("", privateKey); err == nil {
+		t.Error("NewFromSignerWithClientID() error = nil, want error for an empty client ID")
+	}
+	if _, err := NewFromSignerWithClientID("Iv23liExample", nil); err == nil {
+		t.Error("NewFromSignerWithClientID() error = nil, want error for nil signer")
+	}
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// CompleteManifest exchanges the code GitHub returns at the end of the App
+// Manifest flow for the new app's ID, private key and secrets. The code is
+// valid for one hour and can be exchanged only once.
+//
+// baseURL is the GitHub Enterprise Server URL, or empty for github.com. The
+// request is unauthenticated, so no AppToken is needed.
+func CompleteManifest(ctx context.Context, baseURL, code string) (*github.AppConfig, error) {
+	client := github.NewClient(&http.Client{Transport: sharedTransport})
+	if baseURL != "" {
+		var err error
+		client, err = enterpriseClient(client, baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
+	}
+
+	config, _, err := client.Apps.CompleteAppManifest(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete app manifest: %w", classifyError(err, nil))
+	}
+
+	return config, nil
+}
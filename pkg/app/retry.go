@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// errSignJWT marks requests that failed before being sent because the app
+// JWT could not be signed, which retrying does not fix.
+var errSignJWT = errors.New("failed to sign app JWT")
+
+// RetryPolicy is how installation lookups, the installations listing
+// (ListInstallations and SyncInstallations), token creation and revocation
+// are retried after server errors (5xx), network failures and secondary rate
+// limits. The zero value does not retry.
+type RetryPolicy struct {
+	// Retries is how many times a failed request is repeated at most.
+	Retries int
+	// Delay is the wait before the first retry. It doubles for each retry
+	// after it, and up to half of it is added at random so that many
+	// clients failing together do not retry together.
+	Delay time.Duration
+	// MaxRateLimitWait is the longest wait a secondary rate limit may ask
+	// for, in Retry-After or as GitHub documents, to be waited out before
+	// retrying. Longer waits, and primary rate limits, fail at once.
+	MaxRateLimitWait time.Duration
+	// OnRetry, if set, is called with the error and the wait before each
+	// retry, e.g. to log it.
+	OnRetry func(err error, wait time.Duration)
+}
+
+// WithRetryPolicy sets how installation lookups, the installations listing,
+// token creation and revocation are retried. By default they are not.
+func (a *AppToken) WithRetryPolicy(p RetryPolicy) {
+	a.retryPolicy = p
+}
+
+// retry calls fn until it succeeds, fails with an error that is not
+// retried or the retries of the policy are used up. fn must return errors
+// passed through classifyError.
+func (a *AppToken) retry(ctx context.Context, fn func() error) error {
+	p := a.retryPolicy
+	delay := p.Delay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Retries || ctx.Err() != nil {
+			return err
+		}
+		wait, ok := p.wait(err, delay)
+		if !ok {
+			return err
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// wait returns how long to wait before retrying after err, or false if err
+// is not retried. A secondary rate limit is waited out if it allows
+// MaxRateLimitWait; other retryable errors wait delay plus jitter.
+func (p RetryPolicy) wait(err error, delay time.Duration) (time.Duration, bool) {
+	if wait, ok := secondaryRateLimitWait(err); ok {
+		return wait, wait <= p.MaxRateLimitWait
+	}
+	if !retryable(err) {
+		return 0, false
+	}
+	if delay > 0 {
+		delay += rand.N(delay/2 + 1)
+	}
+	return delay, true
+}
+
+// secondaryRateLimitWait returns how long a secondary rate limit asks to wait:
+// its Retry-After, or a minute without one, as GitHub documents. 403 and 429
+// responses with Retry-After count as well, and so do 403 responses whose
+// message names the limit, as some GitHub Enterprise Server releases send.
+func secondaryRateLimitWait(err error) (time.Duration, bool) {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return max(*abuseErr.RetryAfter, 0), true
+		}
+		return time.Minute, true
+	}
+
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return 0, false
+	}
+	code := respErr.Response.StatusCode
+	if code != http.StatusForbidden && code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if s := respErr.Response.Header.Get("Retry-After"); s != "" {
+		if seconds, err := strconv.Atoi(s); err == nil {
+			return max(time.Duration(seconds)*time.Second, 0), true
+		}
+	}
+	if code == http.StatusForbidden && secondaryRateLimitMessage(respErr.Message) {
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+// secondaryRateLimitMessage reports whether the message of a 403 response
+// names a secondary rate limit, formerly called abuse detection.
+func secondaryRateLimitMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection")
+}
+
+// retryable reports whether a failed request is worth repeating
+// after the backoff of the RetryPolicy: the server failed, or the network
+// did. A server in maintenance is not coming back within the backoff, and
+// rate limits are only retried by secondaryRateLimitWait.
+func retryable(err error) bool {
+	if errors.Is(err, ErrMaintenance) || errors.Is(err, ErrRateLimited) || errors.Is(err, errSignJWT) {
+		return false
+	}
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		return respErr.Response.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
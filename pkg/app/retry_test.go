@@ -0,0 +1,229 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var mints atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if mints.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_test","expires_at":"2030-01-01T00:00:00Z"}`))
+	})
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		if mints.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		if mints.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":1}]`))
+	})
+	mux.HandleFunc("/api/v3/orgs/missing/installation", func(w http.ResponseWriter, r *http.Request) {
+		mints.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	})
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		call    func(a *AppToken) error
+		want    int32
+		wantErr bool
+	}{
+		{
+			name:    "no retries by default",
+			call:    func(a *AppToken) error { _, err := a.CreateToken(t.Context(), 1); return err },
+			want:    1,
+			wantErr: true,
+		},
+		{
+			name:   "server errors retried",
+			policy: RetryPolicy{Retries: 2, Delay: time.Millisecond},
+			call:   func(a *AppToken) error { _, err := a.CreateToken(t.Context(), 1); return err },
+			want:   3,
+		},
+		{
+			name:    "retries used up",
+			policy:  RetryPolicy{Retries: 1, Delay: time.Millisecond},
+			call:    func(a *AppToken) error { _, err := a.CreateToken(t.Context(), 1); return err },
+			want:    2,
+			wantErr: true,
+		},
+		{
+			name:   "revocation retried",
+			policy: RetryPolicy{Retries: 2, Delay: time.Millisecond},
+			call:   func(a *AppToken) error { _, err := a.RevokeToken(t.Context(), "ghs_test"); return err },
+			want:   3,
+		},
+		{
+			name:   "installations listing retried",
+			policy: RetryPolicy{Retries: 2, Delay: time.Millisecond},
+			call: func(a *AppToken) error {
+				_, _, err := a.SyncInstallations(t.Context(), &InstallationPages{})
+				return err
+			},
+			want: 3,
+		},
+		{
+			name:   "uncached installations listing retried",
+			policy: RetryPolicy{Retries: 2, Delay: time.Millisecond},
+			call:   func(a *AppToken) error { _, err := a.ListInstallations(t.Context()); return err },
+			want:   3,
+		},
+		{
+			name:    "not found not retried",
+			policy:  RetryPolicy{Retries: 2, Delay: time.Millisecond},
+			call:    func(a *AppToken) error { _, err := a.FindOrgInstallation(t.Context(), "missing"); return err },
+			want:    1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mints.Store(0)
+			a := newTestApp(t, mux)
+			a.WithRetryPolicy(tt.policy)
+
+			err := tt.call(a)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n := mints.Load(); n != tt.want {
+				t.Errorf("sent %d requests, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	status := func(code int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: code, Header: http.Header{}}}
+	}
+	netErr := &url.Error{Op: "Post", URL: "https://api.github.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: status(http.StatusBadGateway), want: true},
+		{name: "network error", err: netErr, want: true},
+		{name: "not found", err: classifyError(status(http.StatusNotFound), ErrAppNotInstalled)},
+		{name: "rate limited", err: classifyError(status(http.StatusTooManyRequests), nil)},
+		{name: "signing failed", err: &url.Error{Op: "Post", Err: fmt.Errorf("%w: kms unavailable", errSignJWT)}},
+		{name: "other", err: errors.New("invalid request")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecondaryRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	limited := func(retryAfter string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) > 1 {
+				_, _ = w.Write([]byte(`{"id":1}`))
+				return
+			}
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit.","documentation_url":"https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`))
+		}
+	}
+	mux.HandleFunc("/api/v3/orgs/now/installation", limited("0"))
+	mux.HandleFunc("/api/v3/orgs/later/installation", limited("120"))
+
+	tests := []struct {
+		name     string
+		org      string
+		wantWait time.Duration
+		wantErr  bool
+	}{
+		{name: "waited out", org: "now", wantWait: 0},
+		{name: "too long", org: "later", wantWait: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			waited := time.Duration(-1)
+			a := newTestApp(t, mux)
+			a.WithRetryPolicy(RetryPolicy{
+				Retries:          1,
+				MaxRateLimitWait: time.Minute,
+				OnRetry:          func(err error, wait time.Duration) { waited = wait },
+			})
+
+			_, err := a.FindOrgInstallation(t.Context(), tt.org)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindOrgInstallation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrRateLimited) {
+				t.Errorf("FindOrgInstallation() error = %v, want ErrRateLimited", err)
+			}
+			if waited != tt.wantWait {
+				t.Errorf("OnRetry() wait = %v, want %v", waited, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestSecondaryRateLimitWait(t *testing.T) {
+	response := func(code int, retryAfter, message string) error {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: code, Header: header}, Message: message}
+	}
+	thirty := 30 * time.Second
+
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "abuse error", err: &github.AbuseRateLimitError{RetryAfter: &thirty}, want: thirty, wantOK: true},
+		{name: "abuse error without Retry-After", err: &github.AbuseRateLimitError{}, want: time.Minute, wantOK: true},
+		{name: "429 with Retry-After", err: response(http.StatusTooManyRequests, "5", ""), want: 5 * time.Second, wantOK: true},
+		{name: "403 message", err: response(http.StatusForbidden, "", "You have triggered an abuse detection mechanism."), want: time.Minute, wantOK: true},
+		{name: "403 permission", err: response(http.StatusForbidden, "", "Resource not accessible by integration")},
+		{name: "primary", err: &github.RateLimitError{}},
+		{name: "server error", err: response(http.StatusBadGateway, "5", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := secondaryRateLimitWait(tt.err)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("secondaryRateLimitWait() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
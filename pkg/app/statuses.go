@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// CreateStatus sets a commit status on ref with a token that can only write
+// statuses on owner/repo. The token is revoked afterwards.
+func (a *AppToken) CreateStatus(ctx context.Context, installationID int64, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, error) {
+	client, revoke, err := a.repoClient(ctx, installationID, repo, &github.InstallationPermissions{Statuses: github.Ptr("write")})
+	if err != nil {
+		return nil, err
+	}
+	defer revoke()
+
+	s, _, err := client.Repositories.CreateStatus(ctx, owner, repo, ref, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit status: %w", classifyError(err, nil))
+	}
+	return s, nil
+}
+
+// CreateCheckRun creates a check run with a token that can only write checks
+// on owner/repo. The token is revoked afterwards.
+func (a *AppToken) CreateCheckRun(ctx context.Context, installationID int64, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, error) {
+	client, revoke, err := a.repoClient(ctx, installationID, repo, &github.InstallationPermissions{Checks: github.Ptr("write")})
+	if err != nil {
+		return nil, err
+	}
+	defer revoke()
+
+	run, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check run: %w", classifyError(err, nil))
+	}
+	return run, nil
+}
+
+// UpdateCheckRun updates a check run created by the app, like
+// CreateCheckRun.
+func (a *AppToken) UpdateCheckRun(ctx context.Context, installationID int64, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, error) {
+	client, revoke, err := a.repoClient(ctx, installationID, repo, &github.InstallationPermissions{Checks: github.Ptr("write")})
+	if err != nil {
+		return nil, err
+	}
+	defer revoke()
+
+	run, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, checkRunID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update check run: %w", classifyError(err, nil))
+	}
+	return run, nil
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// scopedTokenHandler answers token requests after checking that the token is
+// limited to permission:level on repo.
+func scopedTokenHandler(t *testing.T, repo, permission, level string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts struct {
+			Repositories []string          `json:"repositories"`
+			Permissions  map[string]string `json:"permissions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Fatal(err)
+		}
+		if len(opts.Repositories) != 1 || opts.Repositories[0] != repo || len(opts.Permissions) != 1 || opts.Permissions[permission] != level {
+			t.Errorf("token options = %+v, want %s:%s on %s", opts, permission, level, repo)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_scoped","expires_at":"2030-01-01T00:00:00Z"}`))
+	}
+}
+
+func TestAppToken_CreateStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", scopedTokenHandler(t, "repo", "statuses", "write"))
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/statuses/abc123", func(w http.ResponseWriter, r *http.Request) {
+		var s github.RepoStatus
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s.GetState() != "success" || s.GetContext() != "ci/build" {
+			t.Errorf("status = %+v", s)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":7,"state":"success"}`))
+	})
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	s, err := app.CreateStatus(context.Background(), 1, "owner", "repo", "abc123", &github.RepoStatus{State: github.Ptr("success"), Context: github.Ptr("ci/build")})
+	if err != nil {
+		t.Fatalf("CreateStatus() error = %v", err)
+	}
+	if s.GetID() != 7 {
+		t.Errorf("CreateStatus().ID = %d, want 7", s.GetID())
+	}
+}
+
+func TestAppToken_CheckRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", scopedTokenHandler(t, "repo", "checks", "write"))
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":42,"status":"in_progress"}`))
+	})
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/check-runs/42", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":42,"status":"completed","conclusion":"success"}`))
+	})
+	mux.HandleFunc("DELETE /api/v3/installation/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	app := newTestApp(t, mux)
+
+	run, err := app.CreateCheckRun(context.Background(), 1, "owner", "repo", github.CreateCheckRunOptions{Name: "build", HeadSHA: "abc123", Status: github.Ptr("in_progress")})
+	if err != nil {
+		t.Fatalf("CreateCheckRun() error = %v", err)
+	}
+	if run.GetID() != 42 {
+		t.Errorf("CreateCheckRun().ID = %d, want 42", run.GetID())
+	}
+
+	run, err = app.UpdateCheckRun(context.Background(), 1, "owner", "repo", 42, github.UpdateCheckRunOptions{Name: "build", Conclusion: github.Ptr("success")})
+	if err != nil {
+		t.Fatalf("UpdateCheckRun() error = %v", err)
+	}
+	if run.GetConclusion() != "success" {
+		t.Errorf("UpdateCheckRun().Conclusion = %q, want success", run.GetConclusion())
+	}
+}
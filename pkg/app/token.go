@@ -0,0 +1,122 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// redacted replaces the secret part of a token in String and the other
+// human-readable forms.
+const redacted = "[REDACTED]"
+
+// Token is an installation access token together with the metadata GitHub
+// returns when minting it.
+//
+// Its human-readable forms (String, GoString, Format, MarshalText and
+// LogValue) are redacted, so that printing a Token with any verb or handing it
+// to a logger does not leak the secret; read the Token field to use it. MarshalJSON keeps the
+// secret, as JSON is how tokens are handed to other programs.
+type Token struct {
+	Token               string
+	ExpiresAt           time.Time
+	Permissions         *github.InstallationPermissions
+	RepositorySelection string
+}
+
+// tokenJSON is the JSON form of Token, with the field names of the REST API.
+type tokenJSON struct {
+	Token               string                          `json:"token"`
+	ExpiresAt           time.Time                       `json:"expires_at"`
+	Permissions         *github.InstallationPermissions `json:"permissions,omitempty"`
+	RepositorySelection string                          `json:"repository_selection,omitempty"`
+}
+
+// ExpiresIn returns the time left until the token expires, which is negative
+// once it has.
+func (t Token) ExpiresIn() time.Duration {
+	return time.Until(t.ExpiresAt)
+}
+
+// String returns the token with everything but its type prefix (e.g. ghs_)
+// redacted.
+func (t Token) String() string {
+	prefix, _, ok := strings.Cut(t.Token, "_")
+	if !ok || t.Token == "" {
+		return redacted
+	}
+	return prefix + "_" + redacted
+}
+
+// GoString redacts %#v like String does for %v.
+func (t Token) GoString() string {
+	return fmt.Sprintf("app.Token{Token:%q, ExpiresAt:%q, RepositorySelection:%q}",
+		t.String(), t.ExpiresAt.Format(time.RFC3339), t.RepositorySelection)
+}
+
+// Format redacts every verb, including %+v, %q, %x and %d, which would
+// otherwise print the fields of Token.
+func (t Token) Format(f fmt.State, verb rune) {
+	s := t.String()
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		s = t.GoString()
+	case verb == 'q':
+		s = strconv.Quote(s)
+	}
+	_, _ = io.WriteString(f, s)
+}
+
+// MarshalText returns the redacted String, for loggers and encoders that
+// prefer encoding.TextMarshaler.
+func (t Token) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// LogValue makes log/slog record the token redacted, even with a JSON
+// handler.
+func (t Token) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token", t.String()),
+		slog.Time("expires_at", t.ExpiresAt),
+		slog.String("repository_selection", t.RepositorySelection),
+	)
+}
+
+// MarshalJSON encodes the token, including the secret, like the REST API
+// returns it.
+func (t Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenJSON(t))
+}
+
+// UnmarshalJSON decodes what MarshalJSON encodes.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var v tokenJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = Token(v)
+	return nil
+}
+
+// installationToken extends github.InstallationToken with the fields the
+// library does not decode.
+type installationToken struct {
+	github.InstallationToken
+	RepositorySelection string `json:"repository_selection,omitempty"`
+}
+
+func newToken(t *installationToken) *Token {
+	return &Token{
+		Token:               t.GetToken(),
+		ExpiresAt:           t.GetExpiresAt().Time,
+		Permissions:         t.GetPermissions(),
+		RepositorySelection: t.RepositorySelection,
+	}
+}
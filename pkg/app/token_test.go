@@ -0,0 +1,85 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestToken_redacted(t *testing.T) {
+	token := &Token{Token: "ghs_secret", ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), RepositorySelection: "all"}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("minted", "token", token)
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("minted", "token", *token)
+
+	text, err := token.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error = %v", err)
+	}
+
+	for name, got := range map[string]string{
+		"%v":          fmt.Sprintf("%v", token),
+		"%+v":         fmt.Sprintf("%+v", *token),
+		"%#v":         fmt.Sprintf("%#v", token),
+		"%s":          fmt.Sprintf("%s", token),
+		"%q":          fmt.Sprintf("%q", token),
+		"%d":          fmt.Sprintf("%d", *token),
+		"%x":          fmt.Sprintf("%x", token),
+		"[]Token %+v": fmt.Sprintf("%+v", []Token{*token}),
+		"MarshalText": string(text),
+		"slog":        buf.String(),
+	} {
+		if strings.Contains(got, "secret") || strings.Contains(got, fmt.Sprintf("%x", "secret")) {
+			t.Errorf("%s = %q, want the token redacted", name, got)
+		}
+		if !strings.Contains(got, "ghs_[REDACTED]") {
+			t.Errorf("%s = %q, want the ghs_ prefix kept", name, got)
+		}
+	}
+
+	if got := (Token{Token: "v1.abc"}).String(); got != "[REDACTED]" {
+		t.Errorf("String() of a token without prefix = %q, want [REDACTED]", got)
+	}
+}
+
+func TestToken_JSON(t *testing.T) {
+	want := Token{
+		Token:               "ghs_secret",
+		ExpiresAt:           time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Permissions:         &github.InstallationPermissions{Contents: github.Ptr("read")},
+		RepositorySelection: "selected",
+	}
+
+	data, err := json.Marshal(&want)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"token":"ghs_secret"`) || !strings.Contains(string(data), `"expires_at":"2030-01-02T03:04:05Z"`) {
+		t.Errorf("json.Marshal() = %s, want the REST API fields", data)
+	}
+
+	var got Token
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got.Token != want.Token || !got.ExpiresAt.Equal(want.ExpiresAt) || got.Permissions.GetContents() != "read" || got.RepositorySelection != "selected" {
+		t.Errorf("json.Unmarshal() = %#v, want %#v", got, want)
+	}
+}
+
+func TestToken_ExpiresIn(t *testing.T) {
+	token := Token{ExpiresAt: time.Now().Add(time.Hour)}
+	if got := token.ExpiresIn(); got <= 59*time.Minute || got > time.Hour {
+		t.Errorf("ExpiresIn() = %v, want about 1h", got)
+	}
+	if got := (Token{ExpiresAt: time.Now().Add(-time.Minute)}).ExpiresIn(); got >= 0 {
+		t.Errorf("ExpiresIn() of an expired token = %v, want negative", got)
+	}
+}
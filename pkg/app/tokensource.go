@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RefreshMargin is how long before expiry a cached token is replaced.
+const RefreshMargin = 5 * time.Minute
+
+// TokenSource supplies installation tokens.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to TokenSource.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// hostTokenSource is implemented by the sources of tokens that are only
+// valid on one API host, so that Wrap sends them nowhere else.
+type hostTokenSource interface {
+	apiHost() string
+}
+
+// TokenSource returns a source that mints tokens for installationID and
+// reuses each one until it is about to expire. Clients from Wrap only send
+// its tokens to the AppToken's API host.
+func (a *AppToken) TokenSource(installationID int64) TokenSource {
+	return ReuseTokenSource(&installationTokenSource{a: a, installationID: installationID})
+}
+
+type installationTokenSource struct {
+	a              *AppToken
+	installationID int64
+}
+
+func (s *installationTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.a.CreateToken(ctx, s.installationID)
+}
+
+func (s *installationTokenSource) apiHost() string {
+	return s.a.client.BaseURL.Host
+}
+
+type reuseTokenSource struct {
+	mu  sync.Mutex
+	src TokenSource
+	t   *Token
+}
+
+// ReuseTokenSource caches the tokens of src and only asks it for a new one
+// when the cached token expires within RefreshMargin.
+func ReuseTokenSource(src TokenSource) TokenSource {
+	if r, ok := src.(*reuseTokenSource); ok {
+		return r
+	}
+	return &reuseTokenSource{src: src}
+}
+
+func (s *reuseTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.t != nil && time.Until(s.t.ExpiresAt) > RefreshMargin {
+		return s.t, nil
+	}
+
+	t, err := s.src.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.t = t
+	return t, nil
+}
+
+func (s *reuseTokenSource) apiHost() string {
+	if h, ok := s.src.(hostTokenSource); ok {
+		return h.apiHost()
+	}
+	return ""
+}
+
+// Wrap returns a copy of client whose requests are authenticated with tokens
+// from src. The original client is left untouched. Tokens of an AppToken's
+// TokenSource are only sent to its API host; those of other sources are sent
+// to the hosts requested, but never after a redirect to another host, e.g.
+// from an archive download to codeload or S3.
+func Wrap(client *http.Client, src TokenSource) *http.Client {
+	host := ""
+	if h, ok := src.(hostTokenSource); ok {
+		host = h.apiHost()
+	}
+	return wrap(client, src, host)
+}
+
+// wrap is Wrap with the API host the tokens are restricted to, or "" to
+// authenticate every request but cross-host redirects.
+func wrap(client *http.Client, src TokenSource, host string) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &tokenTransport{base: base, src: ReuseTokenSource(src), host: host}
+	return &wrapped
+}
+
+type tokenTransport struct {
+	base http.RoundTripper
+	src  TokenSource
+	host string
+}
+
+// authenticates reports whether the token is sent with req.
+func (t *tokenTransport) authenticates(req *http.Request) bool {
+	if t.host != "" {
+		return req.URL.Host == t.host
+	}
+	// req.Response is the redirect that led to req
+	return req.Response == nil || req.Response.Request.URL.Host == req.URL.Host
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.authenticates(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	token, err := t.src.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("failed to get installation token: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReuseTokenSource(t *testing.T) {
+	calls := 0
+	expiresAt := time.Now().Add(time.Hour)
+	src := ReuseTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		calls++
+		return &Token{Token: "t", ExpiresAt: expiresAt}, nil
+	}))
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := src.Token(ctx); err != nil {
+			t.Fatalf("Token() error = %v, want nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("source called %d times, want 1", calls)
+	}
+
+	expiresAt = time.Now().Add(time.Minute)
+	src = ReuseTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		calls++
+		return &Token{Token: "t", ExpiresAt: expiresAt}, nil
+	}))
+	calls = 0
+	for i := 0; i < 2; i++ {
+		if _, err := src.Token(ctx); err != nil {
+			t.Fatalf("Token() error = %v, want nil", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("source called %d times for a token about to expire, want 2", calls)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	_, keyPath := setupTestPrivateKey(t)
+	defer func() {
+		if err := os.Remove(keyPath); err != nil {
+			t.Errorf("Failed to remove key file: %v", err)
+		}
+	}()
+	app, err := New(12345, keyPath)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	setMockServerURL(t, app)
+
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	original := &http.Client{}
+	src := TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return app.CreateToken(ctx, 123)
+	})
+	client := Wrap(original, src)
+	if original.Transport != nil {
+		t.Error("Wrap() modified the original client")
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v, want nil", err)
+	}
+	_ = resp.Body.Close()
+
+	if want := "Bearer mocked_token"; gotAuth != want {
+		t.Errorf("Authorization = %q, want %q", gotAuth, want)
+	}
+
+	// The AppToken's source only authenticates its API host
+	resp, err = Wrap(original, app.TokenSource(123)).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v, want nil", err)
+	}
+	_ = resp.Body.Close()
+	if gotAuth != "" {
+		t.Errorf("Authorization = %q on another host, want none", gotAuth)
+	}
+
+	if _, err := Wrap(nil, app.TokenSource(321)).Get(app.BaseURL().String()); err == nil {
+		t.Error("Get() error = nil, want error when no token can be minted")
+	}
+}
+
+func TestWrap_redirect(t *testing.T) {
+	var downloadAuth string
+	download := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloadAuth = r.Header.Get("Authorization")
+	}))
+	defer download.Close()
+
+	var apiAuth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/1/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"ghs_test","expires_at":"2030-01-01T00:00:00Z"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/o/r/tarball", func(w http.ResponseWriter, r *http.Request) {
+		apiAuth = r.Header.Get("Authorization")
+		http.Redirect(w, r, download.URL+"/archive.tar.gz", http.StatusFound)
+	})
+	app := newTestApp(t, mux)
+
+	for name, client := range map[string]*http.Client{
+		"TokenSource":     Wrap(nil, app.TokenSource(1)),
+		"TokenSourceFunc": Wrap(nil, TokenSourceFunc(func(ctx context.Context) (*Token, error) { return app.CreateToken(ctx, 1) })),
+		"TokenClient":     app.TokenClient(app.TokenSource(1)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			apiAuth, downloadAuth = "", "unset"
+			resp, err := client.Get(app.BaseURL().String() + "repos/o/r/tarball")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if apiAuth != "Bearer ghs_test" {
+				t.Errorf("API Authorization = %q, want the token", apiAuth)
+			}
+			if downloadAuth != "" {
+				t.Errorf("redirect target Authorization = %q, want none", downloadAuth)
+			}
+		})
+	}
+}
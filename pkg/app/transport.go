@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// MaxConnsPerHost bounds the number of concurrent connections each API host
+// receives from the shared transport.
+const MaxConnsPerHost = 16
+
+// sharedTransport is used by every AppToken so that connections to the same
+// host are pooled and reused across instances instead of being dialed per
+// client.
+var sharedTransport = newTransport()
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// sharedDial is the dialer of sharedTransport set by SetDialOptions. The
+// transport reads it on every dial, so it is swapped atomically rather than
+// by writing the DialContext field of a transport in use.
+var sharedDial atomic.Pointer[dialFunc]
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxConnsPerHost = MaxConnsPerHost
+	t.MaxIdleConnsPerHost = MaxConnsPerHost
+	defaultDial := t.DialContext
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if dial := sharedDial.Load(); dial != nil {
+			return (*dial)(ctx, network, addr)
+		}
+		return defaultDial(ctx, network, addr)
+	}
+	return t
+}
+
+// DialOptions tune how the shared transport connects to API hosts, for
+// networks where IPv6 is advertised but broken and every connection stalls
+// until the dialer falls back to IPv4.
+type DialOptions struct {
+	// ForceIPv4 only dials IPv4 addresses.
+	ForceIPv4 bool
+	// FallbackDelay is how long a dual-stack dial waits for IPv6 before
+	// racing IPv4 ("Happy Eyeballs"). Zero keeps Go's default of 300ms; a
+	// negative value disables the race, so IPv4 is only tried once IPv6 fails.
+	FallbackDelay time.Duration
+}
+
+// SetDialOptions reconfigures the transport shared by every AppToken. It is
+// meant to be called once at startup, before the first request: it is safe
+// to call at any time, but connections already in use keep the options they
+// were dialed with. Idle connections are closed so that they are dialed again
+// with the new options.
+func SetDialOptions(opts DialOptions) {
+	dialer := &net.Dialer{
+		// The values of http.DefaultTransport
+		Timeout:       30 * time.Second,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: opts.FallbackDelay,
+	}
+	dial := dialContext(dialer, opts.ForceIPv4)
+	sharedDial.Store(&dial)
+	sharedTransport.CloseIdleConnections()
+}
+
+func dialContext(dialer *net.Dialer, forceIPv4 bool) dialFunc {
+	if !forceIPv4 {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network == "tcp" {
+			network = "tcp4"
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
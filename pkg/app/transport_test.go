@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDialContext_forceIPv4(t *testing.T) {
+	v4, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on IPv4: %v", err)
+	}
+	defer v4.Close()
+
+	dial := dialContext(&net.Dialer{}, true)
+	conn, err := dial(context.Background(), "tcp", v4.Addr().String())
+	if err != nil {
+		t.Fatalf("dial() to an IPv4 address error = %v", err)
+	}
+	if addr := conn.RemoteAddr().(*net.TCPAddr); addr.IP.To4() == nil {
+		t.Errorf("dial() connected to %s, want an IPv4 address", addr)
+	}
+	_ = conn.Close()
+
+	v6, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 is not available: %v", err)
+	}
+	defer v6.Close()
+	if conn, err := dial(context.Background(), "tcp", v6.Addr().String()); err == nil {
+		_ = conn.Close()
+		t.Error("dial() to an IPv6 address error = nil, want error with ForceIPv4")
+	}
+}
+
+func TestSetDialOptions(t *testing.T) {
+	t.Cleanup(func() { sharedDial.Store(nil) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	client := &http.Client{Transport: sharedTransport}
+
+	// Requests in flight while the options change, which the race detector
+	// checks
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := client.Get(srv.URL); err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+	}
+	SetDialOptions(DialOptions{ForceIPv4: true})
+	wg.Wait()
+
+	if sharedDial.Load() == nil {
+		t.Fatal("SetDialOptions() did not set the dialer of the shared transport")
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() with ForceIPv4 error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestSharedTransport_reusesConnections(t *testing.T) {
+	var dials atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/acme/installation", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		app, err := NewFromKey(12345, key)
+		if err != nil {
+			t.Fatalf("NewFromKey() error = %v", err)
+		}
+		if err := app.WithEnterprise(srv.URL + "/"); err != nil {
+			t.Fatalf("WithEnterprise() error = %v", err)
+		}
+		for range 2 {
+			if _, err := app.FindOrgInstallation(t.Context(), "acme"); err != nil {
+				t.Fatalf("FindOrgInstallation() error = %v", err)
+			}
+		}
+	}
+
+	if n := dials.Load(); n != 1 {
+		t.Errorf("two AppTokens opened %d connections, want 1", n)
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// UserToken is a user access token of a GitHub App. It acts on behalf of the
+// user who authorized it, limited to what both the user and the app can do.
+type UserToken struct {
+	Token     string
+	ExpiresAt time.Time
+	// RefreshToken and its expiry are empty when the app has user token
+	// expiration turned off.
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// UserAuth obtains user access tokens for a GitHub App identified by its
+// client ID. No client secret is needed for the device flow.
+type UserAuth struct {
+	config oauth2.Config
+}
+
+// NewUserAuth returns a UserAuth for the app with clientID on webURL, the
+// web (not API) URL of the GitHub host, e.g. https://github.com/.
+func NewUserAuth(clientID, webURL string) *UserAuth {
+	webURL = strings.TrimSuffix(webURL, "/")
+	return &UserAuth{config: oauth2.Config{
+		ClientID: clientID,
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: webURL + "/login/device/code",
+			TokenURL:      webURL + "/login/oauth/access_token",
+			AuthStyle:     oauth2.AuthStyleInParams,
+		},
+	}}
+}
+
+// WithClientSecret sets the app's client secret, which GitHub requires to
+// refresh tokens.
+func (u *UserAuth) WithClientSecret(secret string) {
+	u.config.ClientSecret = secret
+}
+
+// DeviceCode starts the device flow. Show the returned UserCode and
+// VerificationURI to the user, then call DeviceToken.
+func (u *UserAuth) DeviceCode(ctx context.Context) (*oauth2.DeviceAuthResponse, error) {
+	da, err := u.config.DeviceAuth(u.context(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start device flow: %w", err)
+	}
+	return da, nil
+}
+
+// DeviceToken waits until the user has entered the code of da and returns
+// the resulting token.
+func (u *UserAuth) DeviceToken(ctx context.Context, da *oauth2.DeviceAuthResponse) (*UserToken, error) {
+	t, err := u.config.DeviceAccessToken(u.context(ctx), da)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user token: %w", err)
+	}
+	return newUserToken(t), nil
+}
+
+// Refresh exchanges a refresh token for a new user token. The refresh token
+// can be used only once; the returned token carries its replacement.
+func (u *UserAuth) Refresh(ctx context.Context, refreshToken string) (*UserToken, error) {
+	t, err := u.config.TokenSource(u.context(ctx), &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh user token: %w", err)
+	}
+	return newUserToken(t), nil
+}
+
+// context makes the oauth2 package use the shared transport.
+func (u *UserAuth) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: sharedTransport})
+}
+
+func newUserToken(t *oauth2.Token) *UserToken {
+	ut := &UserToken{
+		Token:        t.AccessToken,
+		ExpiresAt:    t.Expiry,
+		RefreshToken: t.RefreshToken,
+	}
+	if secs := extraSeconds(t, "refresh_token_expires_in"); secs > 0 && t.RefreshToken != "" {
+		ut.RefreshTokenExpiresAt = time.Now().Add(time.Duration(secs) * time.Second)
+	}
+	return ut
+}
+
+// extraSeconds reads a number of seconds from the token response, which
+// GitHub sends form encoded or as JSON depending on the Accept header.
+func extraSeconds(t *oauth2.Token, key string) int64 {
+	var secs int64
+	switch v := t.Extra(key).(type) {
+	case int64:
+		secs = v
+	case float64:
+		secs = int64(v)
+	case string:
+		_, _ = fmt.Sscan(v, &secs)
+	}
+	return secs
+}
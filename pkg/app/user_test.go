@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUserAuth_deviceFlow(t *testing.T) {
+	var polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("client_id"); got != "Iv1.abc" {
+			t.Errorf("client_id = %q, want Iv1.abc", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"device_code":"dc","user_code":"ABCD-1234","verification_uri":"https://github.com/login/device","expires_in":900,"interval":1}`))
+	})
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("device_code"); got != "dc" {
+			t.Errorf("device_code = %q, want dc", got)
+		}
+		polls++
+		// GitHub answers 200 with an error while the user has not entered the code
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		if polls == 1 {
+			_, _ = w.Write([]byte("error=authorization_pending"))
+			return
+		}
+		_, _ = w.Write([]byte("access_token=ghu_user&expires_in=28800&refresh_token=ghr_refresh&refresh_token_expires_in=15811200&token_type=bearer"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ua := NewUserAuth("Iv1.abc", srv.URL+"/")
+	da, err := ua.DeviceCode(context.Background())
+	if err != nil {
+		t.Fatalf("DeviceCode() error = %v", err)
+	}
+	if da.UserCode != "ABCD-1234" {
+		t.Errorf("UserCode = %q, want ABCD-1234", da.UserCode)
+	}
+
+	token, err := ua.DeviceToken(context.Background(), da)
+	if err != nil {
+		t.Fatalf("DeviceToken() error = %v", err)
+	}
+	if token.Token != "ghu_user" || token.RefreshToken != "ghr_refresh" {
+		t.Errorf("DeviceToken() = %+v", token)
+	}
+	if d := time.Until(token.ExpiresAt); d < 7*time.Hour || d > 8*time.Hour {
+		t.Errorf("ExpiresAt in %s, want about 8h", d)
+	}
+	if d := time.Until(token.RefreshTokenExpiresAt); d < 180*24*time.Hour {
+		t.Errorf("RefreshTokenExpiresAt in %s, want about 6 months", d)
+	}
+	if polls != 2 {
+		t.Errorf("polled %d times, want 2", polls)
+	}
+}
+
+func TestUserAuth_Refresh(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		want := map[string]string{
+			"client_id":     "Iv1.abc",
+			"client_secret": "shh",
+			"grant_type":    "refresh_token",
+			"refresh_token": "ghr_old",
+		}
+		for k, v := range want {
+			if got := r.FormValue(k); got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"ghu_new","expires_in":28800,"refresh_token":"ghr_new","refresh_token_expires_in":15811200}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ua := NewUserAuth("Iv1.abc", srv.URL)
+	ua.WithClientSecret("shh")
+
+	token, err := ua.Refresh(context.Background(), "ghr_old")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if token.Token != "ghu_new" || token.RefreshToken != "ghr_new" || token.RefreshTokenExpiresAt.IsZero() {
+		t.Errorf("Refresh() = %+v", token)
+	}
+}
@@ -0,0 +1,199 @@
+// Package agent lets a separate, privileged helper process hold the app
+// private key and sign JWT digests over a unix socket, so the process that
+// calls the GitHub API never has the key in memory. Importing the package
+// registers the "unix://" scheme with auth.LoadSigner:
+//
+//	unix:///run/gh-app-token/agent.sock
+//
+// Each connection carries one JSON request and one JSON response:
+//
+//	{"op":"public"}                  -> {"public_key":"<base64 PKIX DER>"}
+//	{"op":"sign","digest":"<base64>"} -> {"signature":"<base64>"}
+//
+// Only SHA-256 digests are signed. Access is controlled by the permissions
+// of the socket file.
+package agent
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "unix"
+
+// timeout bounds a single request, on both sides of the socket.
+const timeout = 30 * time.Second
+
+const (
+	opPublic = "public"
+	opSign   = "sign"
+)
+
+type request struct {
+	Op     string `json:"op"`
+	Digest []byte `json:"digest,omitempty"`
+}
+
+type response struct {
+	PublicKey []byte `json:"public_key,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// Serve answers signing requests on l with signer until ctx is canceled or l
+// is closed.
+func Serve(ctx context.Context, l net.Listener, signer crypto.Signer) error {
+	pub, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go handle(conn, signer, pub)
+	}
+}
+
+func handle(conn net.Conn, signer crypto.Signer, pub []byte) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	var req request
+	if err := json.NewDecoder(io.LimitReader(conn, 64<<10)).Decode(&req); err != nil {
+		return
+	}
+
+	var resp response
+	switch req.Op {
+	case opPublic:
+		resp.PublicKey = pub
+	case opSign:
+		if len(req.Digest) != sha256.Size {
+			resp.Error = "digest must be a SHA-256 hash"
+			break
+		}
+		sig, err := signer.Sign(rand.Reader, req.Digest, crypto.SHA256)
+		if err != nil {
+			resp.Error = err.Error()
+			break
+		}
+		resp.Signature = sig
+	default:
+		resp.Error = fmt.Sprintf("unknown op %q", req.Op)
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// Signer is a crypto.Signer that forwards signing to an agent.
+type Signer struct {
+	path   string
+	public *rsa.PublicKey
+}
+
+// NewSigner resolves a "unix://" reference and fetches the public key from
+// the agent listening there.
+func NewSigner(ctx context.Context, ref string) (*Signer, error) {
+	path, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid agent socket reference %q (want unix://<path>)", ref)
+	}
+
+	s := &Signer{path: path}
+	resp, err := s.call(ctx, request{Op: opPublic})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key from agent at %s: %w", path, err)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key from agent at %s: %w", path, err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: agent at %s does not hold an RSA key", auth.ErrInvalidKey, path)
+	}
+	s.public = rsaPub
+
+	return s, nil
+}
+
+// Public returns the RSA public key held by the agent.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign asks the agent to sign a SHA-256 digest.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported hash function %v: only SHA-256 is supported", opts.HashFunc())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := s.call(ctx, request{Op: opSign, Digest: digest})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with agent at %s: %w", s.path, err)
+	}
+	return resp.Signature, nil
+}
+
+func (s *Signer) call(ctx context.Context, req request) (*response, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestAgent(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets are not available: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, l, key) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	}()
+
+	signer, err := NewSigner(context.Background(), "unix://"+path)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if !key.PublicKey.Equal(signer.Public()) {
+		t.Fatal("NewSigner() returned a different public key")
+	}
+
+	digest := sha256.Sum256([]byte("payload"))
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	sum512 := sha512.Sum512([]byte("payload"))
+	if _, err := signer.Sign(rand.Reader, sum512[:], crypto.SHA512); err == nil {
+		t.Error("Sign() with SHA-512 error = nil, want error")
+	}
+	if _, err := signer.call(context.Background(), request{Op: opSign, Digest: []byte("short")}); err == nil {
+		t.Error("sign with a malformed digest error = nil, want error")
+	}
+}
+
+func TestNewSigner_errors(t *testing.T) {
+	for _, ref := range []string{"unix://", "tcp://localhost:1", "unix://" + filepath.Join(t.TempDir(), "missing.sock")} {
+		if _, err := NewSigner(context.Background(), ref); err == nil {
+			t.Errorf("NewSigner(%q) error = nil, want error", ref)
+		}
+	}
+}
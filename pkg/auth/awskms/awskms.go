@@ -0,0 +1,133 @@
+// Package awskms signs app JWTs with an asymmetric AWS KMS key, so the
+// private key never leaves KMS. Importing the package registers the
+// "awskms://" scheme with auth.LoadSigner.
+//
+// The key is referenced by key ID, alias or ARN:
+//
+//	awskms://alias/my-app-key
+//	awskms://1234abcd-12ab-34cd-56ef-1234567890ab
+//	awskms://arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab
+//
+// Credentials and region are resolved through the standard AWS SDK chain
+// (environment, shared config, SSO, instance roles). The region embedded in
+// an ARN takes precedence.
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "awskms"
+
+// signTimeout bounds a single KMS Sign call, since crypto.Signer has no
+// context of its own.
+const signTimeout = 30 * time.Second
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// Signer is a crypto.Signer backed by an AWS KMS RSA key.
+type Signer struct {
+	client *kms.Client
+	keyID  string
+	public *rsa.PublicKey
+}
+
+// NewSigner resolves an "awskms://" reference and fetches the public key.
+func NewSigner(ctx context.Context, ref string) (*Signer, error) {
+	keyID, region, err := parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return newSigner(ctx, kms.NewFromConfig(cfg), keyID)
+}
+
+func newSigner(ctx context.Context, client *kms.Client, keyID string) (*Signer, error) {
+	out, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key for KMS key %s: %w", keyID, err)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key for KMS key %s: %w", keyID, err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: KMS key %s is not an RSA key", auth.ErrInvalidKey, keyID)
+	}
+
+	return &Signer{client: client, keyID: keyID, public: rsaPub}, nil
+}
+
+// Public returns the RSA public key of the KMS key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs a SHA-256 digest with RSASSA-PKCS1-v1_5 in KMS.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported hash function %v: only SHA-256 is supported", opts.HashFunc())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), signTimeout)
+	defer cancel()
+
+	out, err := s.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with KMS key %s: %w", s.keyID, err)
+	}
+	return out.Signature, nil
+}
+
+// parseRef returns the KMS key ID from an "awskms://" reference, and the
+// region when the key is given as an ARN.
+func parseRef(ref string) (keyID, region string, err error) {
+	keyID, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok || keyID == "" {
+		return "", "", fmt.Errorf("invalid AWS KMS key reference %q (want awskms://<key-id, alias or ARN>)", ref)
+	}
+
+	if strings.HasPrefix(keyID, "arn:") {
+		parts := strings.SplitN(keyID, ":", 6)
+		if len(parts) != 6 || parts[2] != "kms" {
+			return "", "", fmt.Errorf("invalid AWS KMS key ARN %q", keyID)
+		}
+		region = parts[3]
+	}
+
+	return keyID, region, nil
+}
@@ -0,0 +1,38 @@
+package awskms
+
+import (
+	"testing"
+)
+
+func Test_parseRef(t *testing.T) {
+	tests := []struct {
+		name       string
+		ref        string
+		wantKeyID  string
+		wantRegion string
+		wantErr    bool
+	}{
+		{"alias", "awskms://alias/my-app-key", "alias/my-app-key", "", false},
+		{"key id", "awskms://1234abcd-12ab-34cd-56ef-1234567890ab", "1234abcd-12ab-34cd-56ef-1234567890ab", "", false},
+		{
+			name:       "arn",
+			ref:        "awskms://arn:aws:kms:us-east-1:111122223333:key/1234abcd",
+			wantKeyID:  "arn:aws:kms:us-east-1:111122223333:key/1234abcd",
+			wantRegion: "us-east-1",
+		},
+		{"empty", "awskms://", "", "", true},
+		{"wrong scheme", "gcpkms://foo", "", "", true},
+		{"invalid arn", "awskms://arn:aws:s3:::bucket", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyID, region, err := parseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if keyID != tt.wantKeyID || region != tt.wantRegion {
+				t.Errorf("parseRef() = (%v, %v), want (%v, %v)", keyID, region, tt.wantKeyID, tt.wantRegion)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package awssm loads the app private key from AWS Secrets Manager at
+// runtime, so it never has to be written to the image or filesystem.
+// Importing the package registers the "aws-sm://" scheme with
+// auth.LoadSigner.
+//
+// The secret is referenced by name or ARN and must hold the key in any format
+// accepted by auth.ParsePrivateKey, as a string or binary secret:
+//
+//	aws-sm://my-app-key
+//	aws-sm://arn:aws:secretsmanager:us-east-1:111122223333:secret:my-app-key-AbCdEf
+//
+// Credentials and region are resolved through the standard AWS SDK chain
+// (environment, shared config, SSO, instance roles). The region embedded in
+// an ARN takes precedence.
+package awssm
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "aws-sm"
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// secretsClient is the subset of the Secrets Manager API used here.
+type secretsClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// NewSigner resolves an "aws-sm://" reference and parses the private key
+// stored in the secret.
+func NewSigner(ctx context.Context, ref string) (crypto.Signer, error) {
+	secretID, region, err := parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return newSigner(ctx, secretsmanager.NewFromConfig(cfg), secretID)
+}
+
+func newSigner(ctx context.Context, client secretsClient, secretID string) (crypto.Signer, error) {
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretID, err)
+	}
+
+	data := out.SecretBinary
+	if out.SecretString != nil {
+		data = []byte(*out.SecretString)
+	}
+
+	key, err := auth.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key from secret %s: %w", secretID, err)
+	}
+
+	return key, nil
+}
+
+// parseRef returns the secret ID from an "aws-sm://" reference, and the
+// region when the secret is given as an ARN.
+func parseRef(ref string) (secretID, region string, err error) {
+	secretID, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok || secretID == "" {
+		return "", "", fmt.Errorf("invalid AWS Secrets Manager reference %q (want aws-sm://<secret name or ARN>)", ref)
+	}
+
+	if strings.HasPrefix(secretID, "arn:") {
+		parts := strings.SplitN(secretID, ":", 7)
+		if len(parts) != 7 || parts[2] != "secretsmanager" || parts[5] != "secret" {
+			return "", "", fmt.Errorf("invalid AWS Secrets Manager secret ARN %q", secretID)
+		}
+		region = parts[3]
+	}
+
+	return secretID, region, nil
+}
@@ -0,0 +1,86 @@
+package awssm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+func Test_parseRef(t *testing.T) {
+	tests := []struct {
+		name         string
+		ref          string
+		wantSecretID string
+		wantRegion   string
+		wantErr      bool
+	}{
+		{"name", "aws-sm://my-app-key", "my-app-key", "", false},
+		{"path-like name", "aws-sm://prod/github/app-key", "prod/github/app-key", "", false},
+		{
+			name:         "arn",
+			ref:          "aws-sm://arn:aws:secretsmanager:us-east-1:111122223333:secret:my-app-key-AbCdEf",
+			wantSecretID: "arn:aws:secretsmanager:us-east-1:111122223333:secret:my-app-key-AbCdEf",
+			wantRegion:   "us-east-1",
+		},
+		{"empty", "aws-sm://", "", "", true},
+		{"wrong scheme", "awskms://foo", "", "", true},
+		{"invalid arn", "aws-sm://arn:aws:kms:us-east-1:111122223333:key/1234abcd", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretID, region, err := parseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if secretID != tt.wantSecretID || region != tt.wantRegion {
+				t.Errorf("parseRef() = (%v, %v), want (%v, %v)", secretID, region, tt.wantSecretID, tt.wantRegion)
+			}
+		})
+	}
+}
+
+type fakeClient struct {
+	out *secretsmanager.GetSecretValueOutput
+	err error
+}
+
+func (c *fakeClient) GetSecretValue(_ context.Context, _ *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return c.out, c.err
+}
+
+func Test_newSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tests := []struct {
+		name    string
+		client  *fakeClient
+		wantErr bool
+	}{
+		{"string secret", &fakeClient{out: &secretsmanager.GetSecretValueOutput{SecretString: aws.String(string(keyPEM))}}, false},
+		{"binary secret", &fakeClient{out: &secretsmanager.GetSecretValueOutput{SecretBinary: keyPEM}}, false},
+		{"not a key", &fakeClient{out: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("hunter2")}}, true},
+		{"api error", &fakeClient{err: errors.New("AccessDeniedException")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newSigner(context.Background(), tt.client, "my-app-key")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !key.PublicKey.Equal(signer.Public()) {
+				t.Error("newSigner() returned a different key")
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+// KeyFormat is a serialization format for an RSA private key.
+type KeyFormat string
+
+const (
+	// FormatPKCS1 is a PEM encoded "RSA PRIVATE KEY", as downloaded from GitHub.
+	FormatPKCS1 KeyFormat = "pkcs1"
+	// FormatPKCS8 is a PEM encoded "PRIVATE KEY".
+	FormatPKCS8 KeyFormat = "pkcs8"
+	// FormatJWK is a JSON Web Key.
+	FormatJWK KeyFormat = "jwk"
+)
+
+// KeyFormats lists the formats accepted by EncodePrivateKey.
+var KeyFormats = []KeyFormat{FormatPKCS1, FormatPKCS8, FormatJWK}
+
+// EncodePrivateKey serializes key in the given format.
+func EncodePrivateKey(key *rsa.PrivateKey, format KeyFormat) ([]byte, error) {
+	switch format {
+	case FormatPKCS1:
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		}), nil
+	case FormatPKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: der,
+		}), nil
+	case FormatJWK:
+		return encodeJWK(key)
+	default:
+		return nil, fmt.Errorf("unsupported key format %q", format)
+	}
+}
+
+func encodeJWK(key *rsa.PrivateKey) ([]byte, error) {
+	if len(key.Primes) != 2 {
+		return nil, fmt.Errorf("multi-prime RSA keys cannot be encoded as JWK")
+	}
+	key.Precompute()
+
+	enc := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	data, err := json.MarshalIndent(jwk{
+		Kty: "RSA",
+		N:   enc(key.N),
+		E:   enc(big.NewInt(int64(key.E))),
+		D:   enc(key.D),
+		P:   enc(key.Primes[0]),
+		Q:   enc(key.Primes[1]),
+		Dp:  enc(key.Precomputed.Dp),
+		Dq:  enc(key.Precomputed.Dq),
+		Qi:  enc(key.Precomputed.Qinv),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return append(data, '\n'), nil
+}
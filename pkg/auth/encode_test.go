@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestEncodePrivateKey(t *testing.T) {
+	key := generateTestKey(t)
+
+	for _, format := range KeyFormats {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := EncodePrivateKey(key, format)
+			if err != nil {
+				t.Fatalf("EncodePrivateKey() error = %v, want nil", err)
+			}
+
+			got, err := ParsePrivateKey(data)
+			if err != nil {
+				t.Fatalf("ParsePrivateKey() error = %v, want nil", err)
+			}
+			if !got.Equal(key) {
+				t.Error("round-tripped key differs from the original")
+			}
+		})
+	}
+
+	if _, err := EncodePrivateKey(key, "der"); err == nil {
+		t.Error("EncodePrivateKey() error = nil, want error for unknown format")
+	}
+}
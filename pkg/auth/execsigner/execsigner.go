@@ -0,0 +1,157 @@
+// Package execsigner signs app JWTs with an external command, so any KMS or
+// HSM can be integrated with a small script instead of a dependency in this
+// module.
+//
+// The command is run with one argument naming the operation:
+//
+//	<command> public-key
+//	    Print the RSA public key as PEM ("PUBLIC KEY" or "RSA PUBLIC KEY").
+//
+//	<command> sign
+//	    Read the JWT signing input ("<header>.<payload>") from stdin and print
+//	    its RS256 signature (RSASSA-PKCS1-v1_5 over SHA-256), base64 encoded
+//	    with the standard or URL alphabet.
+//
+// A non-zero exit status fails the operation; stderr is included in the
+// error. For example, with OpenSSL:
+//
+//	case "$1" in
+//	  public-key) openssl rsa -in key.pem -pubout ;;
+//	  sign)       openssl dgst -sha256 -sign key.pem | base64 ;;
+//	esac
+package execsigner
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// signTimeout bounds a single sign invocation, since crypto.Signer has no
+// context of its own.
+const signTimeout = 30 * time.Second
+
+// Signer is an auth.MessageSigner backed by an external command.
+type Signer struct {
+	args   []string
+	public *rsa.PublicKey
+}
+
+var _ auth.MessageSigner = (*Signer)(nil)
+
+// New prepares command, a program followed by optional space separated
+// arguments, and fetches its public key.
+func New(ctx context.Context, command string) (*Signer, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("signer command is empty")
+	}
+
+	s := &Signer{args: args}
+	out, err := s.run(ctx, "public-key", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key from signer command: %w", err)
+	}
+
+	pub, err := parsePublicKey(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key from signer command: %w", err)
+	}
+	s.public = pub
+
+	return s, nil
+}
+
+// Public returns the RSA public key reported by the command.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// SignMessage pipes message to the command and returns the signature.
+func (s *Signer) SignMessage(message []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), signTimeout)
+	defer cancel()
+
+	out, err := s.run(ctx, "sign", bytes.NewReader(message))
+	if err != nil {
+		return nil, fmt.Errorf("signer command failed: %w", err)
+	}
+
+	sig, err := decodeSignature(out)
+	if err != nil {
+		return nil, fmt.Errorf("signer command returned an invalid signature: %w", err)
+	}
+	return sig, nil
+}
+
+// Sign implements crypto.Signer. The command only ever sees whole signing
+// inputs, so signing a bare digest is not supported.
+func (s *Signer) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("signer command can only sign JWTs")
+}
+
+func (s *Signer) run(ctx context.Context, op string, stdin io.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.args[0], append(s.args[1:], op)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s %s: %w: %s", s.args[0], op, err, msg)
+		}
+		return nil, fmt.Errorf("%s %s: %w", s.args[0], op, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func parsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: GitHub Apps require an RSA key, got %T", auth.ErrInvalidKey, pub)
+		}
+		return rsaPub, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func decodeSignature(out []byte) ([]byte, error) {
+	s := strings.Join(strings.Fields(string(out)), "")
+	if s == "" {
+		return nil, errors.New("empty output")
+	}
+
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if sig, err := enc.DecodeString(s); err == nil {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("output is not base64")
+}
@@ -0,0 +1,105 @@
+package execsigner
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeScript writes an OpenSSL-backed signer command for key.
+func writeScript(t *testing.T, keyFile string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("signer command is a shell script")
+	}
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "signer")
+	script := `#!/bin/sh
+case "$2" in
+  public-key) openssl rsa -in "$1" -pubout 2>/dev/null ;;
+  sign) openssl dgst -sha256 -sign "$1" | base64 ;;
+  *) echo "unknown op $2" >&2; exit 2 ;;
+esac
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path + " " + keyFile
+}
+
+func TestSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := New(context.Background(), writeScript(t, keyFile))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !key.PublicKey.Equal(signer.Public()) {
+		t.Fatal("New() returned a different public key")
+	}
+
+	signed, err := auth.SignJWT(signer, "12345")
+	if err != nil {
+		t.Fatalf("SignJWT() error = %v", err)
+	}
+	if _, err := jwt.Parse(signed.Reveal(), func(*jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"})); err != nil {
+		t.Errorf("failed to verify JWT signed by command: %v", err)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	for _, command := range []string{"", "gh-app-token-no-such-signer", "false"} {
+		if _, err := New(context.Background(), command); err == nil {
+			t.Errorf("New(%q) error = nil, want error", command)
+		}
+	}
+}
+
+func Test_decodeSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    string
+		wantErr bool
+	}{
+		{"std with newline", "aGVsbG8=\n", "hello", false},
+		{"wrapped", "aGVs\nbG8=\n", "hello", false},
+		{"url raw", "_-8", "\xff\xef", false},
+		{"empty", "\n", "", true},
+		{"garbage", "not base64!", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSignature([]byte(tt.out))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
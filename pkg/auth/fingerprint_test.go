@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	key := generateTestKey(t)
+
+	got, err := Fingerprint(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v, want nil", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	sum := sha256.Sum256(der)
+	if want := "SHA256:" + base64.StdEncoding.EncodeToString(sum[:]); got != want {
+		t.Errorf("Fingerprint() = %v, want %v", got, want)
+	}
+
+	other, err := Fingerprint(&generateTestKey(t).PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v, want nil", err)
+	}
+	if strings.EqualFold(got, other) {
+		t.Error("Fingerprint() returned the same value for different keys")
+	}
+}
@@ -0,0 +1,188 @@
+// Package gcpkms signs app JWTs with Google Cloud KMS (AsymmetricSign), so the
+// private key stays in Google-managed hardware. Importing the package
+// registers the "gcpkms://" scheme with auth.LoadSigner.
+//
+// The key is referenced by its key version resource name:
+//
+//	gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1
+//
+// "versions/1" is accepted as a shorthand for "cryptoKeyVersions/1".
+// Credentials are resolved with Application Default Credentials.
+package gcpkms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"golang.org/x/oauth2/google"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "gcpkms"
+
+const (
+	endpoint    = "https://cloudkms.googleapis.com/v1/"
+	scope       = "https://www.googleapis.com/auth/cloudkms"
+	signTimeout = 30 * time.Second
+)
+
+var keyVersionName = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+/cryptoKeyVersions/[^/]+$`)
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// Signer is a crypto.Signer backed by a Cloud KMS RSA signing key version.
+type Signer struct {
+	client   *http.Client
+	endpoint string
+	name     string
+	public   *rsa.PublicKey
+}
+
+// NewSigner resolves a "gcpkms://" reference and fetches the public key.
+func NewSigner(ctx context.Context, ref string) (*Signer, error) {
+	name, err := parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := google.DefaultClient(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google application default credentials: %w", err)
+	}
+
+	return newSigner(ctx, client, endpoint, name)
+}
+
+func newSigner(ctx context.Context, client *http.Client, endpoint, name string) (*Signer, error) {
+	s := &Signer{client: client, endpoint: endpoint, name: name}
+
+	var resp struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := s.call(ctx, http.MethodGet, name+"/publicKey", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get public key for %s: %w", name, err)
+	}
+
+	if !strings.HasPrefix(resp.Algorithm, "RSA_SIGN_PKCS1_") || !strings.HasSuffix(resp.Algorithm, "_SHA256") {
+		return nil, fmt.Errorf("%w: %s uses %s, want an RSA_SIGN_PKCS1_*_SHA256 key", auth.ErrInvalidKey, name, resp.Algorithm)
+	}
+
+	block, _ := pem.Decode([]byte(resp.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key for %s", name)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key for %s: %w", name, err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an RSA key", auth.ErrInvalidKey, name)
+	}
+	s.public = rsaPub
+
+	return s, nil
+}
+
+// Public returns the RSA public key of the key version.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs a SHA-256 digest with Cloud KMS AsymmetricSign.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported hash function %v: only SHA-256 is supported", opts.HashFunc())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), signTimeout)
+	defer cancel()
+
+	req := map[string]any{
+		"digest": map[string]string{"sha256": base64.StdEncoding.EncodeToString(digest)},
+	}
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+	if err := s.call(ctx, http.MethodPost, s.name+":asymmetricSign", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sign with %s: %w", s.name, err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature from %s: %w", s.name, err)
+	}
+	return sig, nil
+}
+
+func (s *Signer) call(ctx context.Context, method, path string, body, v any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(data))
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// parseRef returns the key version resource name from a "gcpkms://" reference.
+func parseRef(ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok {
+		return "", fmt.Errorf("invalid Cloud KMS key reference %q", ref)
+	}
+
+	if i := strings.LastIndex(name, "/versions/"); i >= 0 {
+		name = name[:i] + "/cryptoKeyVersions/" + name[i+len("/versions/"):]
+	}
+
+	if !keyVersionName.MatchString(name) {
+		return "", fmt.Errorf("invalid Cloud KMS key reference %q (want gcpkms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v>)", ref)
+	}
+	return name, nil
+}
@@ -0,0 +1,102 @@
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testKeyName = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+func Test_parseRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"full name", "gcpkms://" + testKeyName, testKeyName, false},
+		{"versions shorthand", "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k/versions/1", testKeyName, false},
+		{"missing version", "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k", "", true},
+		{"wrong scheme", "awskms://alias/foo", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/"+testKeyName+"/publicKey", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"pem":       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			"algorithm": "RSA_SIGN_PKCS1_2048_SHA256",
+		})
+	})
+	mux.HandleFunc("/v1/"+testKeyName+":asymmetricSign", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Digest struct {
+				SHA256 []byte `json:"sha256"`
+			} `json:"digest"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, req.Digest.SHA256)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"signature": base64.StdEncoding.EncodeToString(sig)})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	s, err := newSigner(context.Background(), server.Client(), server.URL+"/v1/", testKeyName)
+	if err != nil {
+		t.Fatalf("newSigner() error = %v, want nil", err)
+	}
+	if !key.PublicKey.Equal(s.Public()) {
+		t.Error("Public() returned a different key")
+	}
+
+	digest := sha256.Sum256([]byte("payload"))
+	sig, err := s.Sign(nil, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign() error = %v, want nil", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	if _, err := newSigner(context.Background(), server.Client(), server.URL+"/v1/", "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/2"); err == nil {
+		t.Error("newSigner() error = nil, want error for unknown key version")
+	}
+}
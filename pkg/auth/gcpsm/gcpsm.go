@@ -0,0 +1,148 @@
+// Package gcpsm loads the app private key from Google Secret Manager at
+// runtime. Importing the package registers the "gcp-sm://" scheme with
+// auth.LoadSigner.
+//
+// The secret is referenced by its secret version resource name and must hold
+// the key in any format accepted by auth.ParsePrivateKey:
+//
+//	gcp-sm://projects/p/secrets/my-app-key/versions/latest
+//
+// Without "/versions/<v>" the latest version is used. Credentials are
+// resolved with Application Default Credentials.
+package gcpsm
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	"golang.org/x/oauth2/google"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "gcp-sm"
+
+const (
+	endpoint = "https://secretmanager.googleapis.com/v1/"
+	scope    = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+var (
+	secretName        = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+$`)
+	secretVersionName = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+/versions/[^/]+$`)
+)
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// NewSigner resolves a "gcp-sm://" reference and parses the private key
+// stored in the secret version.
+func NewSigner(ctx context.Context, ref string) (crypto.Signer, error) {
+	name, err := parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := google.DefaultClient(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google application default credentials: %w", err)
+	}
+
+	return newSigner(ctx, client, endpoint, name)
+}
+
+func newSigner(ctx context.Context, client *http.Client, endpoint, name string) (crypto.Signer, error) {
+	data, err := accessSecretVersion(ctx, client, endpoint, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access secret %s: %w", name, err)
+	}
+
+	key, err := auth.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key from secret %s: %w", name, err)
+	}
+
+	return key, nil
+}
+
+func accessSecretVersion(ctx context.Context, client *http.Client, endpoint, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s:access: %s: %s", name, resp.Status, bytes.TrimSpace(body))
+	}
+
+	var v struct {
+		Payload struct {
+			Data       string `json:"data"`
+			DataCrc32c string `json:"dataCrc32c"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(v.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	// The checksum is an int64 encoded as a JSON string.
+	if v.Payload.DataCrc32c != "" {
+		want, err := strconv.ParseUint(v.Payload.DataCrc32c, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload checksum %q", v.Payload.DataCrc32c)
+		}
+		if got := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)); uint64(got) != want {
+			return nil, fmt.Errorf("payload checksum mismatch")
+		}
+	}
+
+	return data, nil
+}
+
+// parseRef returns the secret version resource name from a "gcp-sm://"
+// reference, defaulting to the latest version.
+func parseRef(ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok {
+		return "", fmt.Errorf("invalid Secret Manager reference %q", ref)
+	}
+
+	if secretName.MatchString(name) {
+		name += "/versions/latest"
+	}
+
+	if !secretVersionName.MatchString(name) {
+		return "", fmt.Errorf("invalid Secret Manager reference %q (want gcp-sm://projects/<p>/secrets/<s>/versions/<v>)", ref)
+	}
+	return name, nil
+}
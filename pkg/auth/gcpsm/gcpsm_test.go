@@ -0,0 +1,94 @@
+package gcpsm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const testSecretName = "projects/p/secrets/app-key/versions/latest"
+
+func Test_parseRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"full name", "gcp-sm://" + testSecretName, testSecretName, false},
+		{"pinned version", "gcp-sm://projects/p/secrets/app-key/versions/3", "projects/p/secrets/app-key/versions/3", false},
+		{"without version", "gcp-sm://projects/p/secrets/app-key", testSecretName, false},
+		{"missing secret", "gcp-sm://projects/p", "", true},
+		{"wrong scheme", "gcpkms://projects/p/secrets/app-key", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	checksum := strconv.FormatUint(uint64(crc32.Checksum(keyPEM, crc32.MakeTable(crc32.Castagnoli))), 10)
+
+	tests := []struct {
+		name     string
+		status   int
+		data     []byte
+		checksum string
+		wantErr  bool
+	}{
+		{"valid", http.StatusOK, keyPEM, checksum, false},
+		{"no checksum", http.StatusOK, keyPEM, "", false},
+		{"checksum mismatch", http.StatusOK, keyPEM, "1", true},
+		{"not a key", http.StatusOK, []byte("hunter2"), "", true},
+		{"permission denied", http.StatusForbidden, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/"+testSecretName+":access" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"name": testSecretName,
+					"payload": map[string]string{
+						"data":       base64.StdEncoding.EncodeToString(tt.data),
+						"dataCrc32c": tt.checksum,
+					},
+				})
+			}))
+			defer srv.Close()
+
+			signer, err := newSigner(context.Background(), srv.Client(), srv.URL+"/v1/", testSecretName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !key.PublicKey.Equal(signer.Public()) {
+				t.Error("newSigner() returned a different key")
+			}
+		})
+	}
+}
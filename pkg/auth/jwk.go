@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d"`
+	P   string `json:"p"`
+	Q   string `json:"q"`
+	Dp  string `json:"dp,omitempty"`
+	Dq  string `json:"dq,omitempty"`
+	Qi  string `json:"qi,omitempty"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// parseJWK parses an RSA private key from a single JWK or from a JWK Set
+// ({"keys": [...]}) holding exactly one RSA private key.
+func parseJWK(data []byte) (*rsa.PrivateKey, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWK: %w", err)
+	}
+	if set.Keys != nil {
+		return parseJWKS(set)
+	}
+
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("failed to parse JWK: %w", err)
+	}
+
+	return k.privateKey()
+}
+
+// parseJWKS picks the RSA private key out of a JWK Set. Public keys published
+// alongside it are skipped; more than one private key is ambiguous.
+func parseJWKS(set jwks) (*rsa.PrivateKey, error) {
+	var found *jwk
+	for i, k := range set.Keys {
+		if k.Kty != "RSA" || k.D == "" {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("JWK set contains more than one RSA private key")
+		}
+		found = &set.Keys[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("JWK set contains no RSA private key")
+	}
+
+	return found.privateKey()
+}
+
+func (k *jwk) privateKey() (*rsa.PrivateKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported JWK key type %q: only RSA keys are supported", k.Kty)
+	}
+	if k.D == "" || k.P == "" || k.Q == "" {
+		return nil, fmt.Errorf("JWK is not an RSA private key: d, p and q are required")
+	}
+
+	var n, e, d, p, q big.Int
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *big.Int
+	}{
+		{"n", k.N, &n},
+		{"e", k.E, &e},
+		{"d", k.D, &d},
+		{"p", k.P, &p},
+		{"q", k.Q, &q},
+	} {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(f.value, "="))
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid JWK parameter %q", f.name)
+		}
+		f.dst.SetBytes(b)
+	}
+
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid JWK parameter %q", "e")
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: &n, E: int(e.Int64())},
+		D:         &d,
+		Primes:    []*big.Int{&p, &q},
+	}
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid JWK private key: %w", err)
+	}
+	key.Precompute()
+
+	return key, nil
+}
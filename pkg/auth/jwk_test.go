@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func testJWK(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+
+	enc := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	data, err := json.Marshal(map[string]string{
+		"kty": "RSA",
+		"n":   enc(key.N),
+		"e":   enc(big.NewInt(int64(key.E))),
+		"d":   enc(key.D),
+		"p":   enc(key.Primes[0]),
+		"q":   enc(key.Primes[1]),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal JWK: %v", err)
+	}
+	return data
+}
+
+func Test_parseJWK(t *testing.T) {
+	key := generateTestKey(t)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", string(testJWK(t, key)), false},
+		{"invalid json", `{"kty":`, true},
+		{"EC key", `{"kty":"EC","crv":"P-256","d":"AA"}`, true},
+		{"public key only", `{"kty":"RSA","n":"AQAB","e":"AQAB"}`, true},
+		{"bad encoding", `{"kty":"RSA","n":"!!","e":"AQAB","d":"AQAB","p":"AQAB","q":"AQAB"}`, true},
+		{"inconsistent key", `{"kty":"RSA","n":"AQAB","e":"AQAB","d":"AQAB","p":"Aw","q":"BQ"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWK([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJWK() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(key) {
+				t.Error("parseJWK() returned a different key")
+			}
+		})
+	}
+}
+
+func Test_parseJWK_set(t *testing.T) {
+	key := generateTestKey(t)
+	other := generateTestKey(t)
+	priv := string(testJWK(t, key))
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"single key", `{"keys":[` + priv + `]}`, false},
+		{"with public and EC keys", `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"},{"kty":"EC","crv":"P-256","d":"AA"},` + priv + `]}`, false},
+		{"empty", `{"keys":[]}`, true},
+		{"public only", `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`, true},
+		{"two private keys", `{"keys":[` + priv + `,` + string(testJWK(t, other)) + `]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWK([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJWK() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(key) {
+				t.Error("parseJWK() returned a different key")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTLifetime is how long an app JWT stays valid. GitHub accepts at most ten
+// minutes.
+const JWTLifetime = 10 * time.Minute
+
+// signingMethodSigner is RS256 backed by a crypto.Signer, so keys held in an
+// HSM, KMS or PKCS#11 token can sign without being exported.
+type signingMethodSigner struct{}
+
+// MessageSigner is implemented by signers that hash and sign the JWT signing
+// input themselves, such as external signer commands, instead of signing a
+// precomputed SHA-256 digest. SignJWT prefers it over crypto.Signer.Sign.
+type MessageSigner interface {
+	crypto.Signer
+	// SignMessage returns the RS256 signature of message.
+	SignMessage(message []byte) ([]byte, error)
+}
+
+func (signingMethodSigner) Alg() string {
+	return jwt.SigningMethodRS256.Alg()
+}
+
+func (signingMethodSigner) Verify(signingString string, sig []byte, key interface{}) error {
+	return jwt.SigningMethodRS256.Verify(signingString, sig, key)
+}
+
+func (signingMethodSigner) Sign(signingString string, key interface{}) ([]byte, error) {
+	if ms, ok := key.(MessageSigner); ok {
+		return ms.SignMessage([]byte(signingString))
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, jwt.ErrInvalidKeyType
+	}
+
+	digest := sha256.Sum256([]byte(signingString))
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// SignJWT returns an app JWT for issuer (the app ID) signed by signer. The
+// signer must hold an RSA key, as GitHub only accepts RS256.
+func SignJWT(signer crypto.Signer, issuer string) (JWT, error) {
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return "", fmt.Errorf("%w: GitHub Apps require an RSA key, got %T", ErrInvalidKey, signer.Public())
+	}
+
+	// Backdate the issue time to allow for clock drift
+	now := time.Now().Add(-1 * time.Minute)
+	token := jwt.NewWithClaims(signingMethodSigner{}, jwt.RegisteredClaims{
+		Issuer:    issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(JWTLifetime)),
+	})
+
+	signed, err := token.SignedString(signer)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return JWT(signed), nil
+}
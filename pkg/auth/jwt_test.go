@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestSignJWT(t *testing.T) {
+	key := generateTestKey(t)
+
+	signed, err := SignJWT(key, "12345")
+	if err != nil {
+		t.Fatalf("SignJWT() error = %v, want nil", err)
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(signed.Reveal(), claims, func(*jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil {
+		t.Fatalf("failed to verify signed JWT: %v", err)
+	}
+	if claims.Issuer != "12345" {
+		t.Errorf("issuer = %v, want %v", claims.Issuer, "12345")
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	if _, err := SignJWT(ecKey, "12345"); err == nil {
+		t.Error("SignJWT() error = nil, want error for non-RSA signer")
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrInvalidKey is wrapped by errors for key material that cannot be
+	// parsed.
+	ErrInvalidKey = errors.New("invalid private key")
+	// ErrPassphraseRequired is returned for an encrypted key when no
+	// passphrase was given.
+	ErrPassphraseRequired = errors.New("private key is encrypted and requires a passphrase")
+	// ErrIncorrectPassphrase is returned when an encrypted key cannot be
+	// decrypted with the given passphrase.
+	ErrIncorrectPassphrase = errors.New("incorrect passphrase for private key")
+)
+
+// LoadPrivateKey reads an RSA private key from a file. See ParsePrivateKey for
+// the supported formats.
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey parses an RSA private key from PEM (PKCS#1 or PKCS#8) or
+// from a JSON Web Key (kty=RSA). The format is detected from the content. PEM given on a single
+// line with escaped "\n" newlines is accepted as well.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	return parsePrivateKey(data, nil)
+}
+
+// ParsePrivateKeyWithPassphrase is like ParsePrivateKey but also decrypts
+// passphrase-protected PEM keys.
+func ParsePrivateKeyWithPassphrase(data, passphrase []byte) (*rsa.PrivateKey, error) {
+	if passphrase == nil {
+		passphrase = []byte{}
+	}
+	return parsePrivateKey(data, passphrase)
+}
+
+func parsePrivateKey(data, passphrase []byte) (*rsa.PrivateKey, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: key is empty", ErrInvalidKey)
+	}
+
+	if data[0] == '{' {
+		key, err := parseJWK(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		return key, nil
+	}
+
+	// CI systems often store multi-line secrets with escaped newlines
+	if !bytes.Contains(data, []byte("\n")) && bytes.Contains(data, []byte(`\n`)) {
+		data = bytes.ReplaceAll(data, []byte(`\n`), []byte("\n"))
+	}
+
+	key, err := parsePEM(data, passphrase)
+	if errors.Is(err, ErrPassphraseRequired) || errors.Is(err, ErrIncorrectPassphrase) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	return key, nil
+}
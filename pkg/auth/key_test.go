@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youmark/pkcs8"
+)
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	return privateKey
+}
+
+func encodePKCS1(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key := generateTestKey(t)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"PKCS#1 PEM", encodePKCS1(key), false},
+		{"PEM with escaped newlines", []byte(strings.ReplaceAll(string(encodePKCS1(key)), "\n", `\n`)), false},
+		{"JWK", testJWK(t, key), false},
+		{"empty", []byte("  \n"), true},
+		{"garbage", []byte("not a key"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrivateKey(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(key) {
+				t.Error("ParsePrivateKey() returned a different key")
+			}
+		})
+	}
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	key := generateTestKey(t)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, encodePKCS1(key), 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	got, err := LoadPrivateKey(keyPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error = %v, want nil", err)
+	}
+	if !got.Equal(key) {
+		t.Error("LoadPrivateKey() returned a different key")
+	}
+
+	if _, err := LoadPrivateKey(filepath.Join(t.TempDir(), "notfound.pem")); err == nil {
+		t.Error("LoadPrivateKey() error = nil, want error for missing key file")
+	}
+}
+
+func TestParsePrivateKeyWithPassphrase(t *testing.T) {
+	key := generateTestKey(t)
+	passphrase := []byte("secret")
+
+	//nolint:staticcheck // legacy PEM encryption is what we are testing
+	legacyBlock, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), passphrase, x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatalf("Failed to encrypt key: %v", err)
+	}
+	pkcs8DER, err := pkcs8.MarshalPrivateKey(key, passphrase, nil)
+	if err != nil {
+		t.Fatalf("Failed to encrypt key: %v", err)
+	}
+
+	for name, data := range map[string][]byte{
+		"legacy PEM":       pem.EncodeToMemory(legacyBlock),
+		"encrypted PKCS#8": pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8DER}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePrivateKey(data); !errors.Is(err, ErrPassphraseRequired) {
+				t.Errorf("ParsePrivateKey() error = %v, want %v", err, ErrPassphraseRequired)
+			}
+
+			got, err := ParsePrivateKeyWithPassphrase(data, passphrase)
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyWithPassphrase() error = %v, want nil", err)
+			}
+			if !got.Equal(key) {
+				t.Error("ParsePrivateKeyWithPassphrase() returned a different key")
+			}
+
+			if _, err := ParsePrivateKeyWithPassphrase(data, []byte("wrong")); !errors.Is(err, ErrIncorrectPassphrase) {
+				t.Errorf("ParsePrivateKeyWithPassphrase() error = %v, want %v", err, ErrIncorrectPassphrase)
+			}
+		})
+	}
+
+	if _, err := ParsePrivateKeyWithPassphrase(encodePKCS1(key), passphrase); err != nil {
+		t.Errorf("ParsePrivateKeyWithPassphrase() error = %v for an unencrypted key, want nil", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package keyring keeps app private keys in the OS keyring (macOS Keychain,
+// Windows Credential Manager, or the Secret Service on Linux, e.g. GNOME
+// Keyring) so they never sit in plaintext on disk. Importing the package
+// registers the "keyring://" scheme with auth.LoadSigner.
+//
+// Keys are stored under a name chosen at import time and referenced as:
+//
+//	keyring://myapp
+//
+// StoreToken and LoadToken use the same keyring to hand minted tokens to
+// other local processes.
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "keyring"
+
+// Service is the keyring service the keys are stored under.
+const Service = "gh-app-token"
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// Store saves key in the OS keyring under name, replacing any key already
+// stored there. The key is stored unencrypted as PKCS#1 PEM; the keyring
+// itself protects it.
+func Store(name string, key *rsa.PrivateKey) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+
+	data, err := auth.EncodePrivateKey(key, auth.FormatPKCS1)
+	if err != nil {
+		return err
+	}
+
+	if err := gokeyring.Set(Service, name, string(data)); err != nil {
+		return fmt.Errorf("failed to store key %q in the OS keyring: %w", name, err)
+	}
+	return nil
+}
+
+// NewSigner resolves a "keyring://" reference and parses the stored key.
+func NewSigner(_ context.Context, ref string) (crypto.Signer, error) {
+	name, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok {
+		return nil, fmt.Errorf("invalid keyring reference %q (want keyring://<name>)", ref)
+	}
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := gokeyring.Get(Service, name)
+	if errors.Is(err, gokeyring.ErrNotFound) {
+		return nil, fmt.Errorf("no key named %q in the OS keyring (store one with `gh app-token key import --name %s`)", name, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %q from the OS keyring: %w", name, err)
+	}
+
+	key, err := auth.ParsePrivateKey([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %q from the OS keyring: %w", name, err)
+	}
+
+	return key, nil
+}
+
+func checkName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("invalid key name %q: must be non-empty and contain no slashes", name)
+	}
+	return nil
+}
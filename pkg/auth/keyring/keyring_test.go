@@ -0,0 +1,63 @@
+package keyring
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+func TestStoreAndNewSigner(t *testing.T) {
+	gokeyring.MockInit()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+
+	if err := Store("myapp", key); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	signer, err := NewSigner(context.Background(), "keyring://myapp")
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if !key.PublicKey.Equal(signer.Public()) {
+		t.Error("NewSigner() returned a different key")
+	}
+
+	for _, ref := range []string{"keyring://missing", "keyring://", "keyring://a/b", "op://myapp"} {
+		if _, err := NewSigner(context.Background(), ref); err == nil {
+			t.Errorf("NewSigner(%q) error = nil, want error", ref)
+		}
+	}
+
+	if err := Store("", key); err == nil {
+		t.Error("Store() with empty name error = nil, want error")
+	}
+}
+
+func TestStoreAndLoadToken(t *testing.T) {
+	gokeyring.MockInit()
+
+	if err := StoreToken("ci", `{"token":"ghs_secret"}`); err != nil {
+		t.Fatalf("StoreToken() error = %v", err)
+	}
+	got, err := LoadToken("ci")
+	if err != nil {
+		t.Fatalf("LoadToken() error = %v", err)
+	}
+	if got != `{"token":"ghs_secret"}` {
+		t.Errorf("LoadToken() = %q", got)
+	}
+
+	if _, err := NewSigner(context.Background(), "keyring://ci"); err == nil {
+		t.Error("NewSigner() found a token stored with StoreToken, want tokens kept apart from keys")
+	}
+	if _, err := LoadToken("missing"); err == nil {
+		t.Error("LoadToken() error = nil for a missing token, want error")
+	}
+}
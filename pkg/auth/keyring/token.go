@@ -0,0 +1,40 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+// TokenService is the keyring service minted tokens are handed off under,
+// kept apart from Service so a token never shadows a private key.
+const TokenService = "gh-app-token:tokens"
+
+// StoreToken saves data, a minted token and its metadata, under name so
+// other local processes of the same user can pick it up with LoadToken.
+func StoreToken(name, data string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := gokeyring.Set(TokenService, name, data); err != nil {
+		return fmt.Errorf("failed to store token %q in the OS keyring: %w", name, err)
+	}
+	return nil
+}
+
+// LoadToken returns what StoreToken saved under name.
+func LoadToken(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+
+	data, err := gokeyring.Get(TokenService, name)
+	if errors.Is(err, gokeyring.ErrNotFound) {
+		return "", fmt.Errorf("no token named %q in the OS keyring", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read token %q from the OS keyring: %w", name, err)
+	}
+	return data, nil
+}
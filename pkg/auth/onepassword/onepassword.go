@@ -0,0 +1,86 @@
+// Package onepassword loads the app private key from 1Password at runtime
+// with the 1Password CLI. Importing the package registers the "op://" scheme
+// with auth.LoadSigner.
+//
+// The reference is a 1Password secret reference, passed unchanged to
+// "op read":
+//
+//	op://Engineering/GitHub App/private key
+//
+// The CLI handles authentication, so any sign-in method it supports works:
+// the desktop app integration, a service account (OP_SERVICE_ACCOUNT_TOKEN) or
+// a Connect server (OP_CONNECT_HOST and OP_CONNECT_TOKEN).
+package onepassword
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/buty4649/gh-app-token/pkg/auth"
+)
+
+// Scheme is the key reference prefix handled by this package.
+const Scheme = "op"
+
+// command is the 1Password CLI executable, replaced in tests.
+var command = "op"
+
+func init() {
+	auth.RegisterProvider(Scheme, func(ctx context.Context, ref string) (crypto.Signer, error) {
+		return NewSigner(ctx, ref)
+	})
+}
+
+// NewSigner reads the secret reference with "op read" and parses the private
+// key it holds.
+func NewSigner(ctx context.Context, ref string) (crypto.Signer, error) {
+	if err := checkRef(ref); err != nil {
+		return nil, err
+	}
+
+	data, err := read(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+
+	key, err := auth.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key from %s: %w", ref, err)
+	}
+
+	return key, nil
+}
+
+func read(ctx context.Context, ref string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, "read", "--no-newline", ref)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("the 1Password CLI (op) is not installed or not in PATH")
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// checkRef rejects references that cannot name a field, so a typo fails
+// before the CLI is started.
+func checkRef(ref string) error {
+	path, ok := strings.CutPrefix(ref, Scheme+"://")
+	if !ok || len(strings.Split(path, "/")) < 3 {
+		return fmt.Errorf("invalid 1Password secret reference %q (want op://<vault>/<item>/[<section>/]<field>)", ref)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package onepassword
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_checkRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		wantErr bool
+	}{
+		{"field", "op://Engineering/GitHub App/private key", false},
+		{"section and field", "op://Engineering/GitHub App/keys/private key", false},
+		{"missing field", "op://Engineering/GitHub App", true},
+		{"wrong scheme", "gcp-sm://projects/p/secrets/s", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkRef(tt.ref); (err != nil) != tt.wantErr {
+				t.Errorf("checkRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeOp installs a shell script standing in for the op CLI.
+func fakeOp(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake op CLI is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "op")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake op: %v", err)
+	}
+
+	orig := command
+	command = path
+	t.Cleanup(func() { command = orig })
+}
+
+func TestNewSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	const ref = "op://Engineering/GitHub App/private key"
+
+	t.Run("valid", func(t *testing.T) {
+		fakeOp(t, `[ "$1 $2 $3" = "read --no-newline `+ref+`" ] || exit 1; cat `+keyFile+"\n")
+		signer, err := NewSigner(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if !key.PublicKey.Equal(signer.Public()) {
+			t.Error("NewSigner() returned a different key")
+		}
+	})
+
+	t.Run("cli error", func(t *testing.T) {
+		fakeOp(t, "echo '[ERROR] item not found' >&2; exit 1\n")
+		if _, err := NewSigner(context.Background(), ref); err == nil {
+			t.Error("NewSigner() error = nil, want error")
+		}
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		orig := command
+		command = "gh-app-token-no-such-op"
+		t.Cleanup(func() { command = orig })
+		if _, err := NewSigner(context.Background(), ref); err == nil {
+			t.Error("NewSigner() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/youmark/pkcs8"
+)
+
+// parsePEM decodes the first PEM block in data as a PKCS#1 ("RSA PRIVATE
+// KEY") or PKCS#8 ("PRIVATE KEY") RSA private key. Encrypted keys, either
+// legacy "Proc-Type: 4,ENCRYPTED" PEM or "ENCRYPTED PRIVATE KEY", are
+// decrypted with passphrase.
+func parsePEM(data, passphrase []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		der := block.Bytes
+		//nolint:staticcheck // legacy PEM encryption is insecure but still produced by `openssl genrsa -aes256`
+		if x509.IsEncryptedPEMBlock(block) {
+			if passphrase == nil {
+				return nil, ErrPassphraseRequired
+			}
+			var err error
+			//nolint:staticcheck // see above
+			der, err = x509.DecryptPEMBlock(block, passphrase)
+			if errors.Is(err, x509.IncorrectPasswordError) {
+				return nil, ErrIncorrectPassphrase
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+			}
+		}
+		key, err := x509.ParsePKCS1PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#1 private key: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T: GitHub Apps require an RSA key", key)
+		}
+		return rsaKey, nil
+	case "ENCRYPTED PRIVATE KEY":
+		if passphrase == nil {
+			return nil, ErrPassphraseRequired
+		}
+		key, err := pkcs8.ParsePKCS8PrivateKeyRSA(block.Bytes, passphrase)
+		if err != nil {
+			// pkcs8 cannot tell a wrong passphrase from corrupt data
+			return nil, fmt.Errorf("%w or corrupt key: %w", ErrIncorrectPassphrase, err)
+		}
+		return key, nil
+	case "EC PRIVATE KEY", "OPENSSH PRIVATE KEY", "DSA PRIVATE KEY":
+		return nil, fmt.Errorf("unsupported key type %q: GitHub Apps require an RSA key", block.Type)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q: expected \"RSA PRIVATE KEY\" or \"PRIVATE KEY\"", block.Type)
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func Test_parsePEM(t *testing.T) {
+	key := generateTestKey(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS#8 key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal EC key: %v", err)
+	}
+	ecSEC1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal EC key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 key: %v", err)
+	}
+
+	encode := func(typ string, der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"PKCS#1", encodePKCS1(key), ""},
+		{"PKCS#8", encode("PRIVATE KEY", pkcs8), ""},
+		{"PKCS#8 EC", encode("PRIVATE KEY", ecPKCS8), "require an RSA key"},
+		{"PKCS#8 Ed25519", encode("PRIVATE KEY", edPKCS8), "require an RSA key"},
+		{"SEC1 EC", encode("EC PRIVATE KEY", ecSEC1), "require an RSA key"},
+		{"public key", encode("PUBLIC KEY", []byte{0}), "unsupported PEM block"},
+		{"corrupt PKCS#1", encode("RSA PRIVATE KEY", []byte{0}), "PKCS#1"},
+		{"not PEM", []byte("garbage"), "no PEM data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePEM(tt.data, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parsePEM() error = %v, want nil", err)
+				}
+				if !got.Equal(key) {
+					t.Error("parsePEM() returned a different key")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parsePEM() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
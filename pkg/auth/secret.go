@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// JWT is a signed app JWT. Its fmt, text and JSON forms are redacted, so
+// that it cannot leak through %v or a logger; Reveal returns the token.
+type JWT string
+
+// Reveal returns the encoded JWT, e.g. for an Authorization header.
+func (j JWT) Reveal() string {
+	return string(j)
+}
+
+func (j JWT) String() string {
+	return "[REDACTED JWT]"
+}
+
+// Format redacts every verb, including %s, %q, %x and %#v.
+func (j JWT) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, j.String())
+}
+
+// MarshalText returns the redacted form, which encoding/json and log/slog
+// use as well.
+func (j JWT) MarshalText() ([]byte, error) {
+	return []byte(j.String()), nil
+}
+
+// PrivateKey holds an RSA private key as a crypto.Signer whose fmt output
+// shows only the key fingerprint. Reveal returns the key material for the
+// few callers that need it, such as key format conversion.
+type PrivateKey struct {
+	key *rsa.PrivateKey
+}
+
+// NewPrivateKey wraps key.
+func NewPrivateKey(key *rsa.PrivateKey) *PrivateKey {
+	return &PrivateKey{key: key}
+}
+
+// Reveal returns the wrapped key.
+func (k *PrivateKey) Reveal() *rsa.PrivateKey {
+	return k.key
+}
+
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.key.Public()
+}
+
+func (k *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.key.Sign(rand, digest, opts)
+}
+
+func (k *PrivateKey) String() string {
+	if k == nil || k.key == nil {
+		return "[REDACTED private key]"
+	}
+	fingerprint, err := Fingerprint(k.key.Public())
+	if err != nil {
+		return "[REDACTED private key]"
+	}
+	return "[REDACTED private key " + fingerprint + "]"
+}
+
+// Format redacts every verb, including %s, %q, %x and %#v.
+func (k *PrivateKey) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, k.String())
+}
+
+// RedactSigner wraps an RSA private key in PrivateKey and returns other
+// signers, which do not hold key material, unchanged.
+func RedactSigner(signer crypto.Signer) crypto.Signer {
+	if key, ok := signer.(*rsa.PrivateKey); ok && key != nil {
+		return NewPrivateKey(key)
+	}
+	return signer
+}
+
+// formatRedacted writes s for any verb, quoted for %q, so that a secret type
+// never falls back to printing its underlying value.
+func formatRedacted(f fmt.State, verb rune, s string) {
+	if verb == 'q' {
+		s = strconv.Quote(s)
+	}
+	_, _ = io.WriteString(f, s)
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// verbs lists the fmt verbs a secret type must redact.
+var verbs = []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d", "%T"}
+
+func TestJWT_redacted(t *testing.T) {
+	key := generateTestKey(t)
+	jwt, err := SignJWT(key, "12345")
+	if err != nil {
+		t.Fatalf("SignJWT() error = %v", err)
+	}
+	secret := jwt.Reveal()
+	if strings.Count(secret, ".") != 2 {
+		t.Fatalf("Reveal() = %q, want an encoded JWT", secret)
+	}
+
+	for _, verb := range verbs {
+		for _, v := range []any{jwt, &jwt, []JWT{jwt}, struct{ Token JWT }{jwt}} {
+			if got := fmt.Sprintf(verb, v); strings.Contains(got, secret) || strings.Contains(got, fmt.Sprintf("%x", secret)) {
+				t.Errorf("Sprintf(%q, %T) = %q, want the JWT redacted", verb, v, got)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("signed", "jwt", jwt)
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("signed", "jwt", jwt)
+	data, err := json.Marshal(map[string]JWT{"jwt": jwt})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	buf.Write(data)
+	if strings.Contains(buf.String(), secret) {
+		t.Errorf("logged or encoded JWT is not redacted: %s", buf.String())
+	}
+}
+
+func TestPrivateKey_redacted(t *testing.T) {
+	raw := generateTestKey(t)
+	key := NewPrivateKey(raw)
+	if key.Reveal() != raw {
+		t.Fatal("Reveal() did not return the wrapped key")
+	}
+	fingerprint, err := Fingerprint(raw.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The private exponent and primes in any base fmt might use
+	var secrets []string
+	for _, n := range append(raw.Primes, raw.D) {
+		secrets = append(secrets, n.String(), n.Text(16))
+	}
+
+	for _, verb := range verbs {
+		for _, v := range []any{key, *key, struct{ Key *PrivateKey }{key}} {
+			got := fmt.Sprintf(verb, v)
+			for _, s := range secrets {
+				if strings.Contains(got, s) {
+					t.Errorf("Sprintf(%q, %T) = %q, want the key redacted", verb, v, got)
+				}
+			}
+		}
+	}
+	if got := fmt.Sprint(key); !strings.Contains(got, fingerprint) {
+		t.Errorf("Sprint() = %q, want the fingerprint %s", got, fingerprint)
+	}
+
+	// The wrapper still signs
+	if _, err := SignJWT(key, "12345"); err != nil {
+		t.Errorf("SignJWT() with a PrivateKey error = %v", err)
+	}
+}
+
+func TestRedactSigner(t *testing.T) {
+	key := generateTestKey(t)
+	if _, ok := RedactSigner(key).(*PrivateKey); !ok {
+		t.Errorf("RedactSigner(*rsa.PrivateKey) = %T, want *PrivateKey", RedactSigner(key))
+	}
+	wrapped := NewPrivateKey(key)
+	if RedactSigner(wrapped) != wrapped {
+		t.Error("RedactSigner() wrapped a PrivateKey again")
+	}
+}
+
+// TestRevealNotFormatted fails for any call in the module that passes the
+// result of Reveal straight to a fmt, log or slog function, which would undo
+// the redaction.
+func TestRevealNotFormatted(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isFormatting(call) {
+				return true
+			}
+			for _, arg := range call.Args {
+				if revealsSecret(arg) {
+					t.Errorf("%s: Reveal() passed to a formatting function", fset.Position(arg.Pos()))
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// isFormatting reports whether call is a fmt, log or slog call that formats
+// its arguments.
+func isFormatting(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+
+	switch pkg.Name {
+	case "fmt", "log":
+		return strings.Contains(sel.Sel.Name, "print") || strings.Contains(sel.Sel.Name, "Print") ||
+			sel.Sel.Name == "Errorf" || strings.HasPrefix(sel.Sel.Name, "Fatal") || strings.HasPrefix(sel.Sel.Name, "Panic")
+	case "slog":
+		return true
+	}
+	return false
+}
+
+// revealsSecret reports whether expr evaluates to the result of a Reveal
+// call, possibly converted or concatenated. Values computed from it, such as
+// key.Reveal().N.BitLen(), are not secret.
+func revealsSecret(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return revealsSecret(e.X)
+	case *ast.BinaryExpr:
+		return revealsSecret(e.X) || revealsSecret(e.Y)
+	case *ast.SelectorExpr:
+		return revealsSecret(e.X)
+	case *ast.CallExpr:
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Reveal" {
+			return true
+		}
+		// Conversions such as string(x.Reveal())
+		if _, ok := e.Fun.(*ast.Ident); ok && len(e.Args) == 1 {
+			return revealsSecret(e.Args[0])
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SignerProvider resolves a key reference such as "awskms://alias/my-key"
+// into a signer. It receives the full reference, including the scheme.
+type SignerProvider func(ctx context.Context, ref string) (crypto.Signer, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]SignerProvider{}
+)
+
+// RegisterProvider makes a provider available to LoadSigner for references
+// starting with "<scheme>://". Providers usually register themselves from an
+// init function, so importing their package is enough to enable them.
+func RegisterProvider(scheme string, p SignerProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if p == nil {
+		panic("auth: RegisterProvider provider is nil")
+	}
+	if _, dup := providers[scheme]; dup {
+		panic("auth: RegisterProvider called twice for scheme " + scheme)
+	}
+	providers[scheme] = p
+}
+
+// Schemes returns the registered provider schemes in sorted order.
+func Schemes() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	schemes := make([]string, 0, len(providers))
+	for s := range providers {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// LoadSigner resolves ref with the provider registered for its scheme, or
+// loads it as a private key file when it has no scheme. Private keys are
+// returned wrapped in PrivateKey.
+func LoadSigner(ctx context.Context, ref string) (crypto.Signer, error) {
+	scheme, _, ok := strings.Cut(ref, "://")
+	if !ok {
+		key, err := LoadPrivateKey(ref)
+		if err != nil {
+			return nil, err
+		}
+		return NewPrivateKey(key), nil
+	}
+
+	providersMu.RLock()
+	p, found := providers[scheme]
+	providersMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unsupported private key source %q (supported: %s)", scheme+"://", strings.Join(Schemes(), ", "))
+	}
+
+	signer, err := p(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return RedactSigner(signer), nil
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSigner(t *testing.T) {
+	key := generateTestKey(t)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, encodePKCS1(key), 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	var gotRef string
+	RegisterProvider("test", func(ctx context.Context, ref string) (crypto.Signer, error) {
+		gotRef = ref
+		return key, nil
+	})
+
+	ctx := context.Background()
+
+	if _, err := LoadSigner(ctx, keyPath); err != nil {
+		t.Errorf("LoadSigner(file) error = %v, want nil", err)
+	}
+
+	if _, err := LoadSigner(ctx, "test://some/key"); err != nil {
+		t.Errorf("LoadSigner(test://) error = %v, want nil", err)
+	}
+	if gotRef != "test://some/key" {
+		t.Errorf("provider got ref %q, want %q", gotRef, "test://some/key")
+	}
+
+	if _, err := LoadSigner(ctx, "unknown://key"); err == nil {
+		t.Error("LoadSigner(unknown://) error = nil, want error")
+	}
+}
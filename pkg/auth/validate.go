@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+)
+
+// MinKeySize is the smallest RSA modulus, in bits, accepted for app keys.
+const MinKeySize = 2048
+
+// ValidatePrivateKey checks without any network access that key can sign app
+// JWTs: the key must be consistent, at least MinKeySize bits, and a test
+// signature made with it must verify against its public key. This catches
+// keys that were truncated or mangled on their way into a secret store.
+func ValidatePrivateKey(key *rsa.PrivateKey) error {
+	if err := key.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	if size := key.N.BitLen(); size < MinKeySize {
+		return fmt.Errorf("%w: key is %d bits, at least %d are required", ErrInvalidKey, size, MinKeySize)
+	}
+
+	digest := sha256.Sum256([]byte("gh-app-token key validation"))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("%w: failed to sign test payload: %w", ErrInvalidKey, err)
+	}
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("%w: test signature does not verify: %w", ErrInvalidKey, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestValidatePrivateKey(t *testing.T) {
+	key := generateTestKey(t)
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate test private key: %v", err)
+	}
+
+	corrupt := *key
+	corrupt.D = new(big.Int).Add(key.D, big.NewInt(2))
+
+	tests := []struct {
+		name    string
+		key     *rsa.PrivateKey
+		wantErr bool
+	}{
+		{"valid", key, false},
+		{"too small", small, true},
+		{"corrupt exponent", &corrupt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePrivateKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("ValidatePrivateKey() error = %v, want ErrInvalidKey", err)
+			}
+		})
+	}
+}
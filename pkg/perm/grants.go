@@ -0,0 +1,313 @@
+package perm
+
+import "fmt"
+
+// Grant is one permission of the catalog at one access level. The functions
+// named after the permissions build them, so that code requesting a scoped
+// token is checked by the compiler rather than by Parse:
+//
+//	set, err := perm.Permissions(perm.Contents(perm.Read), perm.PullRequests(perm.Write))
+type Grant struct {
+	Name  string
+	Level Level
+}
+
+// Permissions builds a Set from grants, checking each level against the
+// catalog as Parse does.
+func Permissions(grants ...Grant) (Set, error) {
+	set := Set{}
+	for _, g := range grants {
+		p, ok := Lookup(g.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown permission %q", g.Name)
+		}
+		if err := p.check(g.Level); err != nil {
+			return nil, err
+		}
+		if prev, ok := set[g.Name]; ok && prev != string(g.Level) {
+			return nil, fmt.Errorf("permission %s is given twice (%s and %s)", g.Name, prev, g.Level)
+		}
+		set[g.Name] = string(g.Level)
+	}
+
+	if len(set) == 0 {
+		return nil, fmt.Errorf("no permissions given")
+	}
+	return set, nil
+}
+
+// Actions grants the actions permission.
+func Actions(level Level) Grant { return Grant{Name: "actions", Level: level} }
+
+// ActionsVariables grants the actions_variables permission.
+func ActionsVariables(level Level) Grant { return Grant{Name: "actions_variables", Level: level} }
+
+// Administration grants the administration permission.
+func Administration(level Level) Grant { return Grant{Name: "administration", Level: level} }
+
+// Attestations grants the attestations permission.
+func Attestations(level Level) Grant { return Grant{Name: "attestations", Level: level} }
+
+// Blocking grants the blocking permission.
+func Blocking(level Level) Grant { return Grant{Name: "blocking", Level: level} }
+
+// Checks grants the checks permission.
+func Checks(level Level) Grant { return Grant{Name: "checks", Level: level} }
+
+// Codespaces grants the codespaces permission.
+func Codespaces(level Level) Grant { return Grant{Name: "codespaces", Level: level} }
+
+// CodespacesLifecycleAdmin grants the codespaces_lifecycle_admin permission.
+func CodespacesLifecycleAdmin(level Level) Grant {
+	return Grant{Name: "codespaces_lifecycle_admin", Level: level}
+}
+
+// CodespacesMetadata grants the codespaces_metadata permission.
+func CodespacesMetadata(level Level) Grant { return Grant{Name: "codespaces_metadata", Level: level} }
+
+// CodespacesSecrets grants the codespaces_secrets permission.
+func CodespacesSecrets(level Level) Grant { return Grant{Name: "codespaces_secrets", Level: level} }
+
+// CodespacesUserSecrets grants the codespaces_user_secrets permission.
+func CodespacesUserSecrets(level Level) Grant {
+	return Grant{Name: "codespaces_user_secrets", Level: level}
+}
+
+// ContentReferences grants the content_references permission.
+func ContentReferences(level Level) Grant { return Grant{Name: "content_references", Level: level} }
+
+// Contents grants the contents permission.
+func Contents(level Level) Grant { return Grant{Name: "contents", Level: level} }
+
+// CopilotMessages grants the copilot_messages permission.
+func CopilotMessages(level Level) Grant { return Grant{Name: "copilot_messages", Level: level} }
+
+// DependabotSecrets grants the dependabot_secrets permission.
+func DependabotSecrets(level Level) Grant { return Grant{Name: "dependabot_secrets", Level: level} }
+
+// Deployments grants the deployments permission.
+func Deployments(level Level) Grant { return Grant{Name: "deployments", Level: level} }
+
+// Discussions grants the discussions permission.
+func Discussions(level Level) Grant { return Grant{Name: "discussions", Level: level} }
+
+// Emails grants the emails permission.
+func Emails(level Level) Grant { return Grant{Name: "emails", Level: level} }
+
+// Environments grants the environments permission.
+func Environments(level Level) Grant { return Grant{Name: "environments", Level: level} }
+
+// Followers grants the followers permission.
+func Followers(level Level) Grant { return Grant{Name: "followers", Level: level} }
+
+// Gists grants the gists permission.
+func Gists(level Level) Grant { return Grant{Name: "gists", Level: level} }
+
+// GitSigningSSHPublicKeys grants the git_signing_ssh_public_keys permission.
+func GitSigningSSHPublicKeys(level Level) Grant {
+	return Grant{Name: "git_signing_ssh_public_keys", Level: level}
+}
+
+// GPGKeys grants the gpg_keys permission.
+func GPGKeys(level Level) Grant { return Grant{Name: "gpg_keys", Level: level} }
+
+// InteractionLimits grants the interaction_limits permission.
+func InteractionLimits(level Level) Grant { return Grant{Name: "interaction_limits", Level: level} }
+
+// Issues grants the issues permission.
+func Issues(level Level) Grant { return Grant{Name: "issues", Level: level} }
+
+// Keys grants the keys permission.
+func Keys(level Level) Grant { return Grant{Name: "keys", Level: level} }
+
+// Members grants the members permission.
+func Members(level Level) Grant { return Grant{Name: "members", Level: level} }
+
+// MergeQueues grants the merge_queues permission.
+func MergeQueues(level Level) Grant { return Grant{Name: "merge_queues", Level: level} }
+
+// Metadata grants the metadata permission.
+func Metadata(level Level) Grant { return Grant{Name: "metadata", Level: level} }
+
+// OrganizationActionsVariables grants the organization_actions_variables permission.
+func OrganizationActionsVariables(level Level) Grant {
+	return Grant{Name: "organization_actions_variables", Level: level}
+}
+
+// OrganizationAdministration grants the organization_administration permission.
+func OrganizationAdministration(level Level) Grant {
+	return Grant{Name: "organization_administration", Level: level}
+}
+
+// OrganizationAnnouncementBanners grants the organization_announcement_banners permission.
+func OrganizationAnnouncementBanners(level Level) Grant {
+	return Grant{Name: "organization_announcement_banners", Level: level}
+}
+
+// OrganizationAPIInsights grants the organization_api_insights permission.
+func OrganizationAPIInsights(level Level) Grant {
+	return Grant{Name: "organization_api_insights", Level: level}
+}
+
+// OrganizationCodespaces grants the organization_codespaces permission.
+func OrganizationCodespaces(level Level) Grant {
+	return Grant{Name: "organization_codespaces", Level: level}
+}
+
+// OrganizationCodespacesSecrets grants the organization_codespaces_secrets permission.
+func OrganizationCodespacesSecrets(level Level) Grant {
+	return Grant{Name: "organization_codespaces_secrets", Level: level}
+}
+
+// OrganizationCodespacesSettings grants the organization_codespaces_settings permission.
+func OrganizationCodespacesSettings(level Level) Grant {
+	return Grant{Name: "organization_codespaces_settings", Level: level}
+}
+
+// OrganizationCopilotSeatManagement grants the organization_copilot_seat_management permission.
+func OrganizationCopilotSeatManagement(level Level) Grant {
+	return Grant{Name: "organization_copilot_seat_management", Level: level}
+}
+
+// OrganizationCustomOrgRoles grants the organization_custom_org_roles permission.
+func OrganizationCustomOrgRoles(level Level) Grant {
+	return Grant{Name: "organization_custom_org_roles", Level: level}
+}
+
+// OrganizationCustomProperties grants the organization_custom_properties permission.
+func OrganizationCustomProperties(level Level) Grant {
+	return Grant{Name: "organization_custom_properties", Level: level}
+}
+
+// OrganizationCustomRoles grants the organization_custom_roles permission.
+func OrganizationCustomRoles(level Level) Grant {
+	return Grant{Name: "organization_custom_roles", Level: level}
+}
+
+// OrganizationDependabotSecrets grants the organization_dependabot_secrets permission.
+func OrganizationDependabotSecrets(level Level) Grant {
+	return Grant{Name: "organization_dependabot_secrets", Level: level}
+}
+
+// OrganizationEvents grants the organization_events permission.
+func OrganizationEvents(level Level) Grant { return Grant{Name: "organization_events", Level: level} }
+
+// OrganizationHooks grants the organization_hooks permission.
+func OrganizationHooks(level Level) Grant { return Grant{Name: "organization_hooks", Level: level} }
+
+// OrganizationKnowledgeBases grants the organization_knowledge_bases permission.
+func OrganizationKnowledgeBases(level Level) Grant {
+	return Grant{Name: "organization_knowledge_bases", Level: level}
+}
+
+// OrganizationPackages grants the organization_packages permission.
+func OrganizationPackages(level Level) Grant {
+	return Grant{Name: "organization_packages", Level: level}
+}
+
+// OrganizationPersonalAccessTokenRequests grants the organization_personal_access_token_requests permission.
+func OrganizationPersonalAccessTokenRequests(level Level) Grant {
+	return Grant{Name: "organization_personal_access_token_requests", Level: level}
+}
+
+// OrganizationPersonalAccessTokens grants the organization_personal_access_tokens permission.
+func OrganizationPersonalAccessTokens(level Level) Grant {
+	return Grant{Name: "organization_personal_access_tokens", Level: level}
+}
+
+// OrganizationPlan grants the organization_plan permission.
+func OrganizationPlan(level Level) Grant { return Grant{Name: "organization_plan", Level: level} }
+
+// OrganizationPreReceiveHooks grants the organization_pre_receive_hooks permission.
+func OrganizationPreReceiveHooks(level Level) Grant {
+	return Grant{Name: "organization_pre_receive_hooks", Level: level}
+}
+
+// OrganizationProjects grants the organization_projects permission.
+func OrganizationProjects(level Level) Grant {
+	return Grant{Name: "organization_projects", Level: level}
+}
+
+// OrganizationSecrets grants the organization_secrets permission.
+func OrganizationSecrets(level Level) Grant { return Grant{Name: "organization_secrets", Level: level} }
+
+// OrganizationSelfHostedRunners grants the organization_self_hosted_runners permission.
+func OrganizationSelfHostedRunners(level Level) Grant {
+	return Grant{Name: "organization_self_hosted_runners", Level: level}
+}
+
+// OrganizationUserBlocking grants the organization_user_blocking permission.
+func OrganizationUserBlocking(level Level) Grant {
+	return Grant{Name: "organization_user_blocking", Level: level}
+}
+
+// Packages grants the packages permission.
+func Packages(level Level) Grant { return Grant{Name: "packages", Level: level} }
+
+// Pages grants the pages permission.
+func Pages(level Level) Grant { return Grant{Name: "pages", Level: level} }
+
+// Plan grants the plan permission.
+func Plan(level Level) Grant { return Grant{Name: "plan", Level: level} }
+
+// Profile grants the profile permission.
+func Profile(level Level) Grant { return Grant{Name: "profile", Level: level} }
+
+// PullRequests grants the pull_requests permission.
+func PullRequests(level Level) Grant { return Grant{Name: "pull_requests", Level: level} }
+
+// RepositoryAdvisories grants the repository_advisories permission.
+func RepositoryAdvisories(level Level) Grant {
+	return Grant{Name: "repository_advisories", Level: level}
+}
+
+// RepositoryCustomProperties grants the repository_custom_properties permission.
+func RepositoryCustomProperties(level Level) Grant {
+	return Grant{Name: "repository_custom_properties", Level: level}
+}
+
+// RepositoryHooks grants the repository_hooks permission.
+func RepositoryHooks(level Level) Grant { return Grant{Name: "repository_hooks", Level: level} }
+
+// RepositoryPreReceiveHooks grants the repository_pre_receive_hooks permission.
+func RepositoryPreReceiveHooks(level Level) Grant {
+	return Grant{Name: "repository_pre_receive_hooks", Level: level}
+}
+
+// RepositoryProjects grants the repository_projects permission.
+func RepositoryProjects(level Level) Grant { return Grant{Name: "repository_projects", Level: level} }
+
+// SecretScanningAlerts grants the secret_scanning_alerts permission.
+func SecretScanningAlerts(level Level) Grant {
+	return Grant{Name: "secret_scanning_alerts", Level: level}
+}
+
+// Secrets grants the secrets permission.
+func Secrets(level Level) Grant { return Grant{Name: "secrets", Level: level} }
+
+// SecurityEvents grants the security_events permission.
+func SecurityEvents(level Level) Grant { return Grant{Name: "security_events", Level: level} }
+
+// SingleFile grants the single_file permission.
+func SingleFile(level Level) Grant { return Grant{Name: "single_file", Level: level} }
+
+// Starring grants the starring permission.
+func Starring(level Level) Grant { return Grant{Name: "starring", Level: level} }
+
+// Statuses grants the statuses permission.
+func Statuses(level Level) Grant { return Grant{Name: "statuses", Level: level} }
+
+// TeamDiscussions grants the team_discussions permission.
+func TeamDiscussions(level Level) Grant { return Grant{Name: "team_discussions", Level: level} }
+
+// UserEvents grants the user_events permission.
+func UserEvents(level Level) Grant { return Grant{Name: "user_events", Level: level} }
+
+// VulnerabilityAlerts grants the vulnerability_alerts permission.
+func VulnerabilityAlerts(level Level) Grant { return Grant{Name: "vulnerability_alerts", Level: level} }
+
+// Watching grants the watching permission.
+func Watching(level Level) Grant { return Grant{Name: "watching", Level: level} }
+
+// Workflows grants the workflows permission.
+func Workflows(level Level) Grant { return Grant{Name: "workflows", Level: level} }
@@ -0,0 +1,296 @@
+// Package perm parses and validates the permissions requested for a scoped
+// installation token, such as "contents:read,issues:write", against a
+// catalog of the permissions GitHub Apps can hold.
+package perm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// Level is the access a permission grants.
+type Level string
+
+// Access levels.
+const (
+	Read  Level = "read"
+	Write Level = "write"
+	Admin Level = "admin"
+)
+
+// Permission describes one permission of the catalog.
+type Permission struct {
+	Name   string
+	Levels []Level
+	// GHES is the first GitHub Enterprise Server release that accepts the
+	// permission, or empty if every supported release does.
+	GHES string
+	// DotcomOnly is set for permissions that GitHub Enterprise Server does
+	// not have at all.
+	DotcomOnly bool
+}
+
+var (
+	readWrite      = []Level{Read, Write}
+	readWriteAdmin = []Level{Read, Write, Admin}
+)
+
+// Catalog lists the permissions an installation token can be scoped to,
+// sorted by name. The GHES columns are maintained by hand from the GitHub
+// Enterprise Server release notes.
+var Catalog = []Permission{
+	{Name: "actions", Levels: readWrite},
+	{Name: "actions_variables", Levels: readWrite},
+	{Name: "administration", Levels: readWrite},
+	{Name: "attestations", Levels: readWrite, GHES: "3.16"},
+	{Name: "blocking", Levels: readWrite},
+	{Name: "checks", Levels: readWrite},
+	{Name: "codespaces", Levels: readWrite, DotcomOnly: true},
+	{Name: "codespaces_lifecycle_admin", Levels: readWrite, DotcomOnly: true},
+	{Name: "codespaces_metadata", Levels: []Level{Read}, DotcomOnly: true},
+	{Name: "codespaces_secrets", Levels: readWrite, DotcomOnly: true},
+	{Name: "codespaces_user_secrets", Levels: readWrite, DotcomOnly: true},
+	{Name: "content_references", Levels: readWrite},
+	{Name: "contents", Levels: readWrite},
+	{Name: "copilot_messages", Levels: readWrite, DotcomOnly: true},
+	{Name: "dependabot_secrets", Levels: readWrite},
+	{Name: "deployments", Levels: readWrite},
+	{Name: "discussions", Levels: readWrite},
+	{Name: "emails", Levels: readWrite},
+	{Name: "environments", Levels: readWrite},
+	{Name: "followers", Levels: readWrite},
+	{Name: "gists", Levels: []Level{Write}},
+	{Name: "git_signing_ssh_public_keys", Levels: readWrite},
+	{Name: "gpg_keys", Levels: readWrite},
+	{Name: "interaction_limits", Levels: readWrite},
+	{Name: "issues", Levels: readWrite},
+	{Name: "keys", Levels: readWrite},
+	{Name: "members", Levels: readWrite},
+	{Name: "merge_queues", Levels: readWrite},
+	{Name: "metadata", Levels: []Level{Read}},
+	{Name: "organization_actions_variables", Levels: readWrite},
+	{Name: "organization_administration", Levels: readWrite},
+	{Name: "organization_announcement_banners", Levels: readWrite},
+	{Name: "organization_api_insights", Levels: []Level{Read}, DotcomOnly: true},
+	{Name: "organization_codespaces", Levels: readWrite, DotcomOnly: true},
+	{Name: "organization_codespaces_secrets", Levels: readWrite, DotcomOnly: true},
+	{Name: "organization_codespaces_settings", Levels: readWrite, DotcomOnly: true},
+	{Name: "organization_copilot_seat_management", Levels: readWrite, DotcomOnly: true},
+	{Name: "organization_custom_org_roles", Levels: readWrite, GHES: "3.14"},
+	{Name: "organization_custom_properties", Levels: readWriteAdmin, GHES: "3.12"},
+	{Name: "organization_custom_roles", Levels: readWrite},
+	{Name: "organization_dependabot_secrets", Levels: readWrite},
+	{Name: "organization_events", Levels: []Level{Read}},
+	{Name: "organization_hooks", Levels: readWrite},
+	{Name: "organization_knowledge_bases", Levels: readWrite, DotcomOnly: true},
+	{Name: "organization_packages", Levels: readWrite},
+	{Name: "organization_personal_access_token_requests", Levels: readWrite, GHES: "3.10"},
+	{Name: "organization_personal_access_tokens", Levels: readWrite, GHES: "3.10"},
+	{Name: "organization_plan", Levels: []Level{Read}},
+	{Name: "organization_pre_receive_hooks", Levels: readWrite},
+	{Name: "organization_projects", Levels: readWriteAdmin},
+	{Name: "organization_secrets", Levels: readWrite},
+	{Name: "organization_self_hosted_runners", Levels: readWrite},
+	{Name: "organization_user_blocking", Levels: readWrite},
+	{Name: "packages", Levels: readWrite},
+	{Name: "pages", Levels: readWrite},
+	{Name: "plan", Levels: []Level{Read}},
+	{Name: "profile", Levels: []Level{Write}},
+	{Name: "pull_requests", Levels: readWrite},
+	{Name: "repository_advisories", Levels: readWrite},
+	{Name: "repository_custom_properties", Levels: readWrite, GHES: "3.12"},
+	{Name: "repository_hooks", Levels: readWrite},
+	{Name: "repository_pre_receive_hooks", Levels: readWrite},
+	{Name: "repository_projects", Levels: readWriteAdmin},
+	{Name: "secret_scanning_alerts", Levels: readWrite},
+	{Name: "secrets", Levels: readWrite},
+	{Name: "security_events", Levels: readWrite},
+	{Name: "single_file", Levels: readWrite},
+	{Name: "starring", Levels: readWrite},
+	{Name: "statuses", Levels: readWrite},
+	{Name: "team_discussions", Levels: readWrite},
+	{Name: "user_events", Levels: []Level{Read}},
+	{Name: "vulnerability_alerts", Levels: readWrite},
+	{Name: "watching", Levels: readWrite},
+	{Name: "workflows", Levels: []Level{Write}},
+}
+
+// Lookup returns the catalog entry for name.
+func Lookup(name string) (Permission, bool) {
+	i, ok := slices.BinarySearchFunc(Catalog, name, func(p Permission, name string) int {
+		return strings.Compare(p.Name, name)
+	})
+	if !ok {
+		return Permission{}, false
+	}
+	return Catalog[i], true
+}
+
+// check reports whether p can be granted at level.
+func (p Permission) check(level Level) error {
+	if slices.Contains(p.Levels, level) {
+		return nil
+	}
+	levels := make([]string, len(p.Levels))
+	for i, l := range p.Levels {
+		levels[i] = string(l)
+	}
+	return fmt.Errorf("invalid level %q for permission %s: must be %s", level, p.Name, strings.Join(levels, " or "))
+}
+
+// Set maps permission names to access levels.
+type Set map[string]string
+
+// Parse reads a comma separated list of name:level pairs. Names are
+// normalized to the API spelling, so "Pull-Requests:Write" becomes
+// pull_requests:write, and must be in the catalog.
+func Parse(spec string) (Set, error) {
+	set := Set{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, level, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid permission %q: must be in format 'name:level'", pair)
+		}
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+		level = strings.ToLower(strings.TrimSpace(level))
+
+		p, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown permission %q", name)
+		}
+		if err := p.check(Level(level)); err != nil {
+			return nil, err
+		}
+		if prev, ok := set[name]; ok && prev != level {
+			return nil, fmt.Errorf("permission %s is given twice (%s and %s)", name, prev, level)
+		}
+		set[name] = level
+	}
+
+	if len(set) == 0 {
+		return nil, fmt.Errorf("no permissions given")
+	}
+	return set, nil
+}
+
+// Validate checks that the server accepts every permission of s. version is
+// the GitHub Enterprise Server release, such as "3.12.4", or empty for
+// github.com.
+func (s Set) Validate(version string) error {
+	if version == "" {
+		return nil
+	}
+
+	for _, name := range s.Names() {
+		p, _ := Lookup(name)
+		switch {
+		case p.DotcomOnly:
+			return fmt.Errorf("permission %s is not available on GitHub Enterprise Server (server is %s)", name, version)
+		case p.GHES != "" && CompareVersions(version, p.GHES) < 0:
+			return fmt.Errorf("permission %s requires GitHub Enterprise Server %s or later (server is %s)", name, p.GHES, version)
+		}
+	}
+	return nil
+}
+
+// Names returns the permission names of s in sorted order.
+func (s Set) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InstallationPermissions converts s for use in
+// github.InstallationTokenOptions.
+func (s Set) InstallationPermissions() (*github.InstallationPermissions, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &github.InstallationPermissions{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FromInstallation converts the permissions granted to an installation, as
+// in github.Installation, into a Set.
+func FromInstallation(p *github.InstallationPermissions) (Set, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	set := Set{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// rank orders the levels; a higher level includes the lower ones.
+func rank(level string) int {
+	switch Level(level) {
+	case Read:
+		return 1
+	case Write:
+		return 2
+	case Admin:
+		return 3
+	}
+	return 0
+}
+
+// Missing describes each permission of s that granted does not cover, such
+// as "contents: requested write, granted read", in sorted order. It is empty
+// if s is a subset of granted.
+func (s Set) Missing(granted Set) []string {
+	var missing []string
+	for _, name := range s.Names() {
+		have, ok := granted[name]
+		switch {
+		case !ok:
+			missing = append(missing, fmt.Sprintf("%s: requested %s, not granted", name, s[name]))
+		case rank(have) < rank(s[name]):
+			missing = append(missing, fmt.Sprintf("%s: requested %s, granted %s", name, s[name], have))
+		}
+	}
+	return missing
+}
+
+// CompareVersions compares dotted release numbers such as "3.9" and
+// "3.12.4" numerically, returning -1, 0 or 1. Missing components count as
+// zero.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
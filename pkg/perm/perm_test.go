@@ -0,0 +1,205 @@
+package perm
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestCatalog(t *testing.T) {
+	if !slices.IsSortedFunc(Catalog, func(a, b Permission) int { return strings.Compare(a.Name, b.Name) }) {
+		t.Error("Catalog is not sorted by name")
+	}
+
+	// Every catalog entry must be a field of github.InstallationPermissions,
+	// or it would be dropped from the token request.
+	for _, p := range Catalog {
+		data, err := json.Marshal(map[string]Level{p.Name: p.Levels[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ip github.InstallationPermissions
+		if err := json.Unmarshal(data, &ip); err != nil {
+			t.Fatal(err)
+		}
+		if reflect.ValueOf(ip).IsZero() {
+			t.Errorf("%s is not a field of github.InstallationPermissions", p.Name)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Set
+		wantErr bool
+	}{
+		{spec: "contents:read,issues:write", want: Set{"contents": "read", "issues": "write"}},
+		{spec: " Pull-Requests : Write , ", want: Set{"pull_requests": "write"}},
+		{spec: "contents:read,contents:read", want: Set{"contents": "read"}},
+		{spec: "contents:read,contents:write", wantErr: true},
+		{spec: "contents", wantErr: true},
+		{spec: "contents:admin", wantErr: true},
+		{spec: "metadata:write", wantErr: true},
+		{spec: "contnets:read", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     Set
+		version string
+		wantErr string
+	}{
+		{"github.com", Set{"codespaces": "read", "attestations": "write"}, "", ""},
+		{"supported everywhere", Set{"contents": "read"}, "3.9.0", ""},
+		{"new enough", Set{"repository_custom_properties": "read"}, "3.12.1", ""},
+		{"too old", Set{"repository_custom_properties": "read"}, "3.11.7", "requires GitHub Enterprise Server 3.12 or later (server is 3.11.7)"},
+		{"dotcom only", Set{"codespaces": "read"}, "3.14.0", "not available on GitHub Enterprise Server (server is 3.14.0)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.set.Validate(tt.version)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.9", "3.12", -1},
+		{"3.12.4", "3.12", 1},
+		{"3.12.0", "3.12", 0},
+		{"3.14", "3.10", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		grants  []Grant
+		want    Set
+		wantErr bool
+	}{
+		{name: "valid", grants: []Grant{Contents(Read), PullRequests(Write)}, want: Set{"contents": "read", "pull_requests": "write"}},
+		{name: "repeated", grants: []Grant{Issues(Write), Issues(Write)}, want: Set{"issues": "write"}},
+		{name: "admin", grants: []Grant{OrganizationProjects(Admin)}, want: Set{"organization_projects": "admin"}},
+		{name: "conflicting", grants: []Grant{Contents(Read), Contents(Write)}, wantErr: true},
+		{name: "level not offered", grants: []Grant{Metadata(Write)}, wantErr: true},
+		{name: "unknown level", grants: []Grant{Contents("none")}, wantErr: true},
+		{name: "unknown permission", grants: []Grant{{Name: "contnets", Level: Read}}, wantErr: true},
+		{name: "empty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Permissions(tt.grants...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Permissions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Permissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGrantFunctions checks that grants.go has a function for every catalog
+// entry, and only for those.
+func TestGrantFunctions(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "grants.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Name" {
+			if lit, ok := kv.Value.(*ast.BasicLit); ok {
+				name, _ := strconv.Unquote(lit.Value)
+				got = append(got, name)
+			}
+		}
+		return true
+	})
+
+	var want []string
+	for _, p := range Catalog {
+		want = append(want, p.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("grants.go has functions for %v, want one per catalog entry in order: %v", got, want)
+	}
+}
+
+func TestSet_Missing(t *testing.T) {
+	granted, err := FromInstallation(&github.InstallationPermissions{
+		Contents:           github.Ptr("read"),
+		Issues:             github.Ptr("write"),
+		RepositoryProjects: github.Ptr("admin"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		requested Set
+		want      []string
+	}{
+		{name: "subset", requested: Set{"contents": "read", "issues": "read", "repository_projects": "write"}},
+		{name: "same", requested: Set{"issues": "write"}},
+		{
+			name:      "exceeds",
+			requested: Set{"contents": "write", "issues": "write", "pull_requests": "read"},
+			want:      []string{"contents: requested write, granted read", "pull_requests: requested read, not granted"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.requested.Missing(granted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Missing() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}